@@ -2,15 +2,26 @@ package main
 
 import (
 	"context"
+	"crypto/rsa"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
+	"unicode"
 
 	"github.com/stepkareserva/obsermon/internal/agent/client"
+	"github.com/stepkareserva/obsermon/internal/agent/collector"
 	"github.com/stepkareserva/obsermon/internal/agent/config"
+	"github.com/stepkareserva/obsermon/internal/agent/scrape"
+	"github.com/stepkareserva/obsermon/internal/agent/spool"
 	"github.com/stepkareserva/obsermon/internal/agent/watchdog"
+	"github.com/stepkareserva/obsermon/internal/encryption"
 )
 
 func main() {
@@ -25,11 +36,68 @@ func main() {
 		return
 	}
 
-	// metrics client
-	metricsClient, err := client.New(cfg.EndpointURL(), cfg.ReportSignKey, cfg.RateLimit)
-	if err != nil {
-		log.Printf("metrics client initialization: %v", err)
-		return
+	var cryptoKey *rsa.PublicKey
+	if cfg.CryptoKeyPath != "" {
+		if cryptoKey, err = encryption.LoadPublicKey(cfg.CryptoKeyPath); err != nil {
+			log.Printf("crypto key loading: %v", err)
+			return
+		}
+	}
+
+	// metrics clients, one per endpoint
+	var metricsClient client.MetricsClients
+	defer func() {
+		metricsClient.Close()
+	}()
+	for i, endpoint := range cfg.EndpointURLs() {
+		c, err := client.New(endpoint, cfg.ReportSignKey, cfg.RateLimit, cfg.TLSConfig())
+		if err != nil {
+			log.Printf("metrics client initialization: %v", err)
+			return
+		}
+		c.SetKeyID(cfg.ReportSignKeyID)
+		c.SetCryptoKey(cryptoKey)
+		metricsClient = append(metricsClient, c)
+
+		if cfg.SpoolDir == "" {
+			continue
+		}
+		// additional endpoints have their own spools inside main one
+		spoolDir := cfg.SpoolDir
+		if i > 0 {
+			name, err := spoolDirName(endpoint)
+			if err != nil {
+				log.Printf("spool initialization: %v", err)
+				return
+			}
+			spoolDir = filepath.Join(cfg.SpoolDir, name)
+		}
+		metricsSpool, err := spool.Open(spoolDir, cfg.SpoolMaxSize)
+		if err != nil {
+			log.Printf("spool initialization: %v", err)
+			return
+		}
+		c.SetSpool(metricsSpool)
+	}
+
+	// metrics collectors
+	collectors := collector.Builtin()
+	if len(cfg.ExecScripts) > 0 {
+		execCollector, err := collector.NewExec(cfg.ExecScripts, cfg.ExecTimeout())
+		if err != nil {
+			log.Printf("exec collector initialization: %v", err)
+			return
+		}
+		if err := collectors.Register(execCollector, 0); err != nil {
+			log.Printf("exec collector initialization: %v", err)
+			return
+		}
+	}
+	if intervals := cfg.CollectorIntervals(); intervals != nil {
+		if err := collectors.EnableOnly(intervals); err != nil {
+			log.Printf("collectors initialization: %v", err)
+			return
+		}
 	}
 
 	// context to stop on interription
@@ -50,6 +118,9 @@ func main() {
 		PollInterval:        time.Duration(cfg.PollInterval()),
 		ReportInterval:      time.Duration(cfg.ReportInterval()),
 		MetricsServerClient: metricsClient,
+		Collectors:          collectors,
+		Aggregations:        cfg.GaugeAggregations(),
+		Filter:              cfg.MetricsFilter(),
 	}
 	watchdog, err := watchdog.New(watchdogParams)
 	if err != nil {
@@ -57,7 +128,41 @@ func main() {
 		return
 	}
 	defer watchdog.Close()
+
+	// prometheus endpoints relay
+	var wg sync.WaitGroup
+	if len(cfg.ScrapeTargets) > 0 {
+		relay, err := scrape.New(cfg.ScrapeTargets, cfg.ScrapeInterval(), metricsClient)
+		if err != nil {
+			log.Printf("scrape relay initialization: %v", err)
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			relay.Start(ctx)
+		}()
+	}
+
 	watchdog.Start(ctx)
+	wg.Wait()
 
 	log.Println("Agent shut down")
 }
+
+// endpoint host as directory name, like localhost_8080
+func spoolDirName(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("endpoint parsing: %v", err)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("endpoint %s without host", endpoint)
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, u.Host), nil
+}
@@ -2,29 +2,53 @@ package app
 
 import (
 	"context"
+	"crypto/rsa"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/stepkareserva/obsermon/internal/encryption"
+	"github.com/stepkareserva/obsermon/internal/server/alerting"
 	"github.com/stepkareserva/obsermon/internal/server/config"
 	"github.com/stepkareserva/obsermon/internal/server/http/handlers"
+	"github.com/stepkareserva/obsermon/internal/server/http/middleware"
 	"github.com/stepkareserva/obsermon/internal/server/http/router"
 	"github.com/stepkareserva/obsermon/internal/server/metrics/service"
 	"github.com/stepkareserva/obsermon/internal/server/metrics/storage/dbstorage"
 	"github.com/stepkareserva/obsermon/internal/server/metrics/storage/memstorage"
 	"github.com/stepkareserva/obsermon/internal/server/metrics/storage/persistence"
 	"github.com/stepkareserva/obsermon/internal/server/server"
+	"github.com/stepkareserva/obsermon/internal/server/signkeys"
+	"github.com/stepkareserva/obsermon/internal/server/statsd"
 	"go.uber.org/zap"
 )
 
+const (
+	statsdFlushInterval = time.Second
+	statsdBatchSize     = 1000
+)
+
 type App struct {
-	storage service.Storage
-	service handlers.Service
-	handler http.Handler
-	server  *server.Server
-	log     *zap.Logger
+	storage   service.Storage
+	alerting  *alerting.Engine
+	notifier  *alerting.WebhookNotifier
+	service   handlers.Service
+	signKeys  *signkeys.Registry
+	cryptoKey *rsa.PrivateKey
+	caches    router.Caches
+	handler   *swappableHandler
+	server    *server.Server
+	statsd    *statsd.Listener
+	log       *zap.Logger
+
+	// current config and lock for reloading
+	mu        sync.Mutex
+	cfg       config.Config
+	logReload LogReloader
+	running   bool
 }
 
 func New(cfg config.Config, log *zap.Logger) (*App, error) {
@@ -32,7 +56,7 @@ func New(cfg config.Config, log *zap.Logger) (*App, error) {
 		log = zap.NewNop()
 	}
 
-	app := App{log: log}
+	app := App{log: log, cfg: cfg}
 
 	if err := app.initStorage(cfg); err != nil {
 		if closeErr := app.Close(); closeErr != nil {
@@ -41,6 +65,13 @@ func New(cfg config.Config, log *zap.Logger) (*App, error) {
 		return nil, fmt.Errorf("init storage: %v", err)
 	}
 
+	if err := app.initAlerting(cfg); err != nil {
+		if closeErr := app.Close(); closeErr != nil {
+			log.Error("app close", zap.Error(closeErr))
+		}
+		return nil, fmt.Errorf("init alerting: %v", err)
+	}
+
 	if err := app.initService(cfg); err != nil {
 		if closeErr := app.Close(); closeErr != nil {
 			log.Error("app close", zap.Error(closeErr))
@@ -62,6 +93,13 @@ func New(cfg config.Config, log *zap.Logger) (*App, error) {
 		return nil, fmt.Errorf("init server: %v", err)
 	}
 
+	if err := app.initStatsD(cfg); err != nil {
+		if closeErr := app.Close(); closeErr != nil {
+			log.Error("app close", zap.Error(closeErr))
+		}
+		return nil, fmt.Errorf("init statsd: %v", err)
+	}
+
 	return &app, nil
 }
 
@@ -69,6 +107,8 @@ func (a *App) Close() error {
 	if a == nil {
 		return nil
 	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
 	var closingErrs error
 	// cancel server if exists
@@ -83,6 +123,35 @@ func (a *App) Close() error {
 		a.server = nil
 	}
 
+	// stop statsd listener if exists,
+	// it flushes collected metrics, so before storage closing
+	if a.statsd != nil {
+		context, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := a.statsd.Shutdown(context); err != nil {
+			closingErrs = errors.Join(err, fmt.Errorf("statsd shutdown: %v", err))
+		} else {
+			a.log.Info("statsd listener stopped")
+		}
+		a.statsd = nil
+	}
+
+	// stop alerting, it uses storage
+	if a.alerting != nil {
+		context, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := a.alerting.Shutdown(context); err != nil {
+			closingErrs = errors.Join(err, fmt.Errorf("alerting shutdown: %v", err))
+		} else {
+			a.log.Info("alerting stopped")
+		}
+		a.alerting = nil
+	}
+	if a.notifier != nil {
+		a.notifier.Close()
+		a.notifier = nil
+	}
+
 	// close storage, if it can be closed
 	if a.storage != nil {
 		if c, ok := a.storage.(io.Closer); ok {
@@ -111,6 +180,19 @@ func (a *App) Run(ctx context.Context) error {
 	// start server
 	serverErrCh := a.server.Start()
 
+	a.mu.Lock()
+	if a.alerting != nil {
+		a.alerting.Start()
+	}
+	a.running = true
+	a.mu.Unlock()
+
+	// start statsd listener, nil channel blocks forever if disabled
+	var statsdErrCh <-chan error
+	if a.statsd != nil {
+		statsdErrCh = a.statsd.Start()
+	}
+
 	// wait for cancel or server error (it's critical)
 	select {
 	case <-ctx.Done():
@@ -118,6 +200,11 @@ func (a *App) Run(ctx context.Context) error {
 		return nil
 	case srvErr := <-serverErrCh:
 		return fmt.Errorf("server running: %v", srvErr)
+	case statsdErr, ok := <-statsdErrCh:
+		if !ok {
+			return fmt.Errorf("statsd listener stopped")
+		}
+		return fmt.Errorf("statsd listener running: %v", statsdErr)
 	}
 }
 
@@ -174,6 +261,9 @@ func (a *App) initStorage(cfg config.Config) error {
 				StoreInterval: cfg.StoreInterval(),
 				Restore:       cfg.Restore,
 			}
+			if cfg.UseWAL {
+				persistenceCfg.WALPath = cfg.FileStoragePath + ".wal"
+			}
 			var err error
 			a.storage, err = persistence.New(persistenceCfg, a.storage, a.log)
 			if err != nil {
@@ -185,12 +275,41 @@ func (a *App) initStorage(cfg config.Config) error {
 	return nil
 }
 
+func (a *App) initAlerting(cfg config.Config) error {
+	if cfg.AlertRulesPath == "" {
+		a.log.Info("alert rules not passed, don't use alerting")
+		return nil
+	}
+
+	var err error
+	a.alerting, a.notifier, err = newAlerting(cfg, a.storage, a.log)
+	return err
+}
+
+func newAlerting(cfg config.Config, storage service.Storage, log *zap.Logger) (
+	*alerting.Engine, *alerting.WebhookNotifier, error) {
+	rules, err := alerting.LoadConfig(cfg.AlertRulesPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load alert rules: %v", err)
+	}
+	notifier := alerting.NewWebhookNotifier(rules.Webhooks, log)
+	engine, err := alerting.New(*rules, storage, notifier, log)
+	if err != nil {
+		notifier.Close()
+		return nil, nil, fmt.Errorf("alerting engine creation: %v", err)
+	}
+	return engine, notifier, nil
+}
+
 func (a *App) initService(cfg config.Config) error {
 	// service
 	service, err := service.New(a.storage)
 	if err != nil {
 		return fmt.Errorf("service creation: %v", err)
 	}
+	if a.alerting != nil {
+		service.SetAlertsSource(a.alerting)
+	}
 
 	a.service = service
 
@@ -198,16 +317,86 @@ func (a *App) initService(cfg config.Config) error {
 }
 
 func (a *App) initHandler(cfg config.Config) error {
-	handler, err := router.New(a.log, cfg.ReportSignKey, a.service)
+	if cfg.SignKeysPath != "" {
+		keys, err := signkeys.Load(cfg.SignKeysPath)
+		if err != nil {
+			return fmt.Errorf("load sign keys: %v", err)
+		}
+		a.signKeys = keys
+	}
+
+	if cfg.CryptoKeyPath != "" {
+		key, err := encryption.LoadPrivateKey(cfg.CryptoKeyPath)
+		if err != nil {
+			return fmt.Errorf("load crypto key: %v", err)
+		}
+		a.cryptoKey = key
+	}
+
+	trusted, err := cfg.TrustedSubnets()
+	if err != nil {
+		return fmt.Errorf("trusted subnets: %v", err)
+	}
+	// kept across handler rebuilds on reload
+	a.caches = router.NewCaches()
+	handler, err := router.New(a.log, signConfig(cfg, a.signKeys), a.cryptoKey, trusted, a.caches, a.service)
 	if err != nil {
 		return fmt.Errorf("init handler: %v", err)
 	}
-	a.handler = handler
+	a.handler = newSwappableHandler(handler)
 
 	return nil
 }
 
+func signConfig(cfg config.Config, keys *signkeys.Registry) middleware.SignConfig {
+	sign := middleware.SignConfig{
+		Key:         cfg.ReportSignKey,
+		Strict:      cfg.SignStrict,
+		MaxSkew:     cfg.SignMaxSkew(),
+		AllowLegacy: cfg.SignLegacy,
+	}
+	// nil registry must be nil interface
+	if keys != nil {
+		sign.Keys = keys
+	}
+	return sign
+}
+
 func (a *App) initServer(cfg config.Config) error {
-	a.server = server.New(cfg.Endpoint, a.handler)
+	if cfg.TLSCertPath == "" {
+		a.server = server.New(cfg.Endpoint, a.handler)
+		return nil
+	}
+
+	tlsCfg := server.TLSConfig{
+		CertPath:     cfg.TLSCertPath,
+		KeyPath:      cfg.TLSKeyPath,
+		ClientCAPath: cfg.TLSClientCAPath,
+	}
+	srv, err := server.NewTLS(cfg.Endpoint, a.handler, tlsCfg, a.log)
+	if err != nil {
+		return fmt.Errorf("tls server creation: %v", err)
+	}
+	a.server = srv
+	return nil
+}
+
+func (a *App) initStatsD(cfg config.Config) error {
+	if cfg.StatsDEndpoint == "" {
+		a.log.Info("statsd endpoint not passed, don't listen statsd")
+		return nil
+	}
+
+	statsdCfg := statsd.Config{
+		Endpoint:      cfg.StatsDEndpoint,
+		FlushInterval: statsdFlushInterval,
+		BatchSize:     statsdBatchSize,
+	}
+	listener, err := statsd.New(statsdCfg, a.service, a.log)
+	if err != nil {
+		return fmt.Errorf("statsd listener creation: %v", err)
+	}
+	a.statsd = listener
+
 	return nil
 }
@@ -0,0 +1,253 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/server/alerting"
+	"github.com/stepkareserva/obsermon/internal/server/config"
+	"github.com/stepkareserva/obsermon/internal/server/http/router"
+	"github.com/stepkareserva/obsermon/internal/server/metrics/service"
+	"github.com/stepkareserva/obsermon/internal/server/signkeys"
+	"go.uber.org/zap"
+)
+
+// changes log mode of app's log, like logging.Reloadable
+type LogReloader interface {
+	SetMode(m config.AppMode) error
+}
+
+// without log reloader log mode changes require restart
+func (a *App) SetLogReloader(r LogReloader) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.logReload = r
+}
+
+// handler which may be replaced while server is running,
+// in-flight requests are finished by the old one
+type swappableHandler struct {
+	current atomic.Pointer[http.Handler]
+}
+
+func newSwappableHandler(h http.Handler) *swappableHandler {
+	s := &swappableHandler{}
+	s.Store(h)
+	return s
+}
+
+func (s *swappableHandler) Store(h http.Handler) {
+	s.current.Store(&h)
+}
+
+func (s *swappableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*s.current.Load()).ServeHTTP(w, r)
+}
+
+type storeIntervalSetter interface {
+	SetStoreInterval(interval time.Duration)
+}
+
+type alertsSourceSetter interface {
+	SetAlertsSource(alerts service.AlertsSource)
+}
+
+// apply settings which can be changed live: log mode, sign settings,
+// trusted subnet, store interval and alert rules. changes of other settings
+// are only reported, they need restart. all new settings are loaded
+// and checked first, so on error nothing is changed.
+func (a *App) Reload(cfg config.Config) error {
+	if a == nil {
+		return fmt.Errorf("app not exists")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.server == nil {
+		return fmt.Errorf("app is closed")
+	}
+
+	a.reportRestartRequired(cfg)
+
+	next, err := a.prepareReload(cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Mode != a.cfg.Mode {
+		if a.logReload == nil {
+			a.log.Warn("log mode change requires restart")
+			cfg.Mode = a.cfg.Mode
+		} else if err := a.logReload.SetMode(cfg.Mode); err != nil {
+			next.discard()
+			return fmt.Errorf("log mode changing: %v", err)
+		} else {
+			a.log.Info("log mode changed", zap.String("mode", cfg.Mode.String()))
+		}
+	}
+
+	a.applyReload(cfg, next)
+	return nil
+}
+
+// state loaded from new config, not used by app yet
+type reloadState struct {
+	// new keys, or nil if keys file is not used
+	signKeys *signkeys.Registry
+	// new handler, or nil if it's not changed
+	handler http.Handler
+	// new alerting, not started yet, nil if disabled
+	alerting *alerting.Engine
+	notifier *alerting.WebhookNotifier
+}
+
+func (s *reloadState) discard() {
+	if s.notifier != nil {
+		s.notifier.Close()
+	}
+}
+
+func (a *App) prepareReload(cfg config.Config) (*reloadState, error) {
+	var next reloadState
+
+	// keys file may be changed even if path is the same
+	if cfg.SignKeysPath != "" {
+		keys, err := signkeys.Load(cfg.SignKeysPath)
+		if err != nil {
+			return nil, fmt.Errorf("sign keys reloading: %v", err)
+		}
+		next.signKeys = keys
+	}
+
+	// existing registry gets new keys in place, so handler
+	// is kept if only keys are changed
+	keys := next.signKeys
+	if keys != nil && a.signKeys != nil {
+		keys = a.signKeys
+	}
+	if signConfig(cfg, keys) != signConfig(a.cfg, a.signKeys) || cfg.TrustedSubnet != a.cfg.TrustedSubnet {
+		trusted, err := cfg.TrustedSubnets()
+		if err != nil {
+			return nil, fmt.Errorf("trusted subnets: %v", err)
+		}
+		next.handler, err = router.New(a.log, signConfig(cfg, keys), a.cryptoKey, trusted, a.caches, a.service)
+		if err != nil {
+			return nil, fmt.Errorf("handler creation: %v", err)
+		}
+	}
+
+	// rules file may be changed even if path is the same
+	if cfg.AlertRulesPath != "" {
+		if _, ok := a.service.(alertsSourceSetter); !ok {
+			return nil, fmt.Errorf("alerting reloading: service does not support alerts")
+		}
+		var err error
+		if next.alerting, next.notifier, err = newAlerting(cfg, a.storage, a.log); err != nil {
+			return nil, fmt.Errorf("alerting reloading: %v", err)
+		}
+	}
+
+	return &next, nil
+}
+
+// swap prepared state in, it can't fail
+func (a *App) applyReload(cfg config.Config, next *reloadState) {
+	switch {
+	case next.signKeys == nil:
+		a.signKeys = nil
+	case a.signKeys == nil:
+		a.signKeys = next.signKeys
+	default:
+		a.signKeys.Replace(next.signKeys)
+		a.log.Info("sign keys reloaded", zap.String("path", cfg.SignKeysPath))
+	}
+	if next.handler != nil {
+		a.handler.Store(next.handler)
+		a.log.Info("sign or trusted subnet settings changed")
+	}
+
+	if cfg.StoreIntervalS != a.cfg.StoreIntervalS {
+		if setter, ok := a.storage.(storeIntervalSetter); ok {
+			setter.SetStoreInterval(cfg.StoreInterval())
+			a.log.Info("store interval changed", zap.Duration("interval", cfg.StoreInterval()))
+		} else {
+			a.log.Info("store interval is not used by storage")
+		}
+	}
+
+	a.swapAlerting(cfg, next.alerting, next.notifier)
+
+	a.cfg.Mode = cfg.Mode
+	a.cfg.ReportSignKey = cfg.ReportSignKey
+	a.cfg.SignMaxSkewS = cfg.SignMaxSkewS
+	a.cfg.SignLegacy = cfg.SignLegacy
+	a.cfg.SignStrict = cfg.SignStrict
+	a.cfg.SignKeysPath = cfg.SignKeysPath
+	a.cfg.TrustedSubnet = cfg.TrustedSubnet
+	a.cfg.StoreIntervalS = cfg.StoreIntervalS
+	a.cfg.AlertRulesPath = cfg.AlertRulesPath
+}
+
+func (a *App) reportRestartRequired(cfg config.Config) {
+	changed := func(name string, old, new any) {
+		if old != new {
+			a.log.Warn("setting change requires restart", zap.String("setting", name),
+				zap.Any("current", old), zap.Any("new", new))
+		}
+	}
+	changed("address", a.cfg.Endpoint, cfg.Endpoint)
+	changed("statsd_address", a.cfg.StatsDEndpoint, cfg.StatsDEndpoint)
+	changed("file_storage_path", a.cfg.FileStoragePath, cfg.FileStoragePath)
+	changed("restore", a.cfg.Restore, cfg.Restore)
+	changed("wal", a.cfg.UseWAL, cfg.UseWAL)
+	changed("crypto_key", a.cfg.CryptoKeyPath, cfg.CryptoKeyPath)
+	// files themselves are reloaded on change
+	changed("tls_cert", a.cfg.TLSCertPath, cfg.TLSCertPath)
+	changed("tls_key", a.cfg.TLSKeyPath, cfg.TLSKeyPath)
+	changed("tls_client_ca", a.cfg.TLSClientCAPath, cfg.TLSClientCAPath)
+	// dsn may contain password
+	if a.cfg.DBConnection != cfg.DBConnection {
+		a.log.Warn("setting change requires restart", zap.String("setting", "database_dsn"))
+	}
+}
+
+// old engine is stopped before new one takes its alerts
+// states, so alerts are not notified twice. old notifier
+// delivers pending notifications in background.
+func (a *App) swapAlerting(cfg config.Config, engine *alerting.Engine, notifier *alerting.WebhookNotifier) {
+	// checked on preparing if alerting is enabled
+	setter, ok := a.service.(alertsSourceSetter)
+
+	if a.alerting != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := a.alerting.Shutdown(ctx); err != nil {
+			a.log.Error("previous alerting shutdown", zap.Error(err))
+		}
+	}
+	if a.notifier != nil {
+		go a.notifier.Close()
+	}
+
+	if engine != nil {
+		if a.alerting != nil {
+			engine.Restore(a.alerting)
+		}
+		if a.running {
+			engine.Start()
+		}
+		setter.SetAlertsSource(engine)
+		a.log.Info("alert rules loaded", zap.String("path", cfg.AlertRulesPath))
+	} else {
+		if ok {
+			setter.SetAlertsSource(nil)
+		}
+		if a.alerting != nil {
+			a.log.Info("alerting disabled")
+		}
+	}
+	a.alerting, a.notifier = engine, notifier
+}
@@ -24,11 +24,12 @@ func main() {
 
 	// create log. use std log to log log errors,
 	// because who log the log
-	log, err := logging.New(cfg.Mode)
+	logs, err := logging.NewReloadable(cfg.Mode)
 	if err != nil {
 		stdlog.Print(err)
 		return
 	}
+	log := logs.Logger()
 	defer func() {
 		if err := log.Sync(); err != nil {
 			stdlog.Print(err)
@@ -46,6 +47,8 @@ func main() {
 		}
 	}()
 
+	app.SetLogReloader(logs)
+
 	// create gentle cancelling to context
 	ctx, err := gracefulCancellingCtx(log)
 	if err != nil {
@@ -53,6 +56,8 @@ func main() {
 		return
 	}
 
+	go reloadOnSighup(ctx, app, log)
+
 	if err := app.Run(ctx); err != nil {
 		log.Error("app running", zap.Error(err))
 		return
@@ -76,6 +81,32 @@ func gracefulCancellingCtx(log *zap.Logger) (context.Context, error) {
 	return ctx, nil
 }
 
+// reload config on SIGHUP, bad config is reported and ignored
+func reloadOnSighup(ctx context.Context, app *app.App, log *zap.Logger) {
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	defer signal.Stop(hupChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupChan:
+			log.Info("SIGHUP received, reloading config...")
+			cfg, err := loadConfig()
+			if err != nil {
+				log.Error("config reloading", zap.Error(err))
+				continue
+			}
+			if err := app.Reload(*cfg); err != nil {
+				log.Error("config reloading", zap.Error(err))
+				continue
+			}
+			log.Info("config reloaded")
+		}
+	}
+}
+
 func loadConfig() (*config.Config, error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
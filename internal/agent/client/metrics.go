@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -13,25 +15,50 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/stepkareserva/obsermon/internal/agent/spool"
 	"github.com/stepkareserva/obsermon/internal/agent/taskpool"
+	"github.com/stepkareserva/obsermon/internal/encryption"
 	"github.com/stepkareserva/obsermon/internal/models"
 )
 
 type MetricsClient struct {
-	client    *resty.Client
-	secretkey string
-	tp        *taskpool.TaskPool
+	client     *resty.Client
+	serverAddr string
+	secretkey  string
+	keyID      string
+	tp         *taskpool.TaskPool
+	// server's public key to encrypt requests, optional
+	cryptoKey *rsa.PublicKey
+	// optional storage for batches undelivered
+	// because of server unavailability
+	spool *spool.Spool
 }
 
 const requestTimeout = 5 * time.Second
 
+var errServerUnavailable = errors.New("server unavailable")
+
 // header HashSHA256 is forbidden by checker
 var signHeader = http.CanonicalHeaderKey("HashSHA256")
 
-func New(endpoint string, secretkey string, rateLimit int) (*MetricsClient, error) {
+// signed together with body, so server
+// rejects replays of captured requests
+var (
+	signTimestampHeader = http.CanonicalHeaderKey("X-Sign-Timestamp")
+	signNonceHeader     = http.CanonicalHeaderKey("X-Sign-Nonce")
+	signKeyIDHeader     = http.CanonicalHeaderKey("X-Sign-Key-Id")
+)
+
+// server returns the original response for requests with the same key,
+// so batch retried after lost response is not counted twice
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// tlsCfg is for https endpoint, zero value for default settings
+func New(endpoint string, secretkey string, rateLimit int, tlsCfg TLSConfig) (*MetricsClient, error) {
 	u, err := url.ParseRequestURI(endpoint)
 	if err != nil {
 		return nil, err
@@ -39,15 +66,26 @@ func New(endpoint string, secretkey string, rateLimit int) (*MetricsClient, erro
 	if u.Scheme != "http" && u.Scheme != "https" {
 		return nil, fmt.Errorf("invalid endpoint scheme %s", u.Scheme)
 	}
+	if tlsCfg.Enabled() && u.Scheme != "https" {
+		return nil, fmt.Errorf("tls settings for not https endpoint %s", endpoint)
+	}
 
 	client := resty.New()
 	client.SetBaseURL(endpoint)
 	client.SetTimeout(requestTimeout)
+	if tlsCfg.Enabled() {
+		config, err := tlsCfg.clientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("tls config: %v", err)
+		}
+		client.SetTLSClientConfig(config)
+	}
 
 	return &MetricsClient{
-		client:    client,
-		secretkey: secretkey,
-		tp:        taskpool.New(rateLimit),
+		client:     client,
+		serverAddr: serverAddr(u),
+		secretkey:  secretkey,
+		tp:         taskpool.New(rateLimit),
 	}, nil
 }
 
@@ -58,6 +96,23 @@ func (c *MetricsClient) Close() {
 	c.tp.Close()
 }
 
+// batches which couldn't be delivered will be spooled
+// and replayed when server is reachable again
+func (c *MetricsClient) SetSpool(s *spool.Spool) {
+	c.spool = s
+}
+
+// requests are signed with agent's own key registered
+// on server with this id instead of common key
+func (c *MetricsClient) SetKeyID(id string) {
+	c.keyID = id
+}
+
+// requests bodies are encrypted by server's public key
+func (c *MetricsClient) SetCryptoKey(key *rsa.PublicKey) {
+	c.cryptoKey = key
+}
+
 func (c *MetricsClient) UpdateCounter(value models.Counter) {
 	c.BatchUpdate(models.CountersList{value}, nil)
 }
@@ -76,14 +131,72 @@ func (c *MetricsClient) BatchUpdate(counters models.CountersList, gauges models.
 	}
 
 	c.tp.AddTask(func() {
-		err := c.sendUpdateRequest(metrics)
-		if err != nil {
+		c.deliver(metrics)
+	})
+}
+
+func (c *MetricsClient) deliver(metrics models.Metrics) {
+	if c.spool == nil {
+		if err := c.sendNewUpdateRequest(metrics); err != nil {
 			log.Printf("send update request: %v", err)
 		}
+		return
+	}
+
+	// spooled batches are older and must be sent first,
+	// so just enqueue new batch after them.
+	// batch is spooled with the key it was sent with,
+	// server may have applied it before failure
+	var key string
+	if c.spool.Len() == 0 {
+		var err error
+		if key, err = newIdempotencyKey(); err != nil {
+			log.Printf("idempotency key generation: %v", err)
+			return
+		}
+		err = c.sendUpdateRequest(key, metrics)
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, errServerUnavailable) {
+			log.Printf("send update request: %v", err)
+			return
+		}
+	}
+
+	evicted, err := c.spool.Push(key, metrics)
+	if err != nil {
+		log.Printf("spool batch: %v", err)
+	}
+	if evicted > 0 {
+		log.Printf("spool is full, %d oldest batches dropped", evicted)
+	}
+
+	err = c.spool.Replay(func(key string, batch models.Metrics) error {
+		err := c.sendUpdateRequest(key, batch)
+		if err != nil && !errors.Is(err, errServerUnavailable) {
+			// server rejects batch, no reason to keep it
+			log.Printf("send spooled update request: %v", err)
+			return nil
+		}
+		return err
 	})
+	if err != nil {
+		log.Printf("replay spooled batches: %v", err)
+	}
+}
+
+// send batch with new idempotency key
+func (c *MetricsClient) sendNewUpdateRequest(metrics models.Metrics) error {
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return fmt.Errorf("idempotency key generation: %v", err)
+	}
+	return c.sendUpdateRequest(key, metrics)
 }
 
-func (c *MetricsClient) sendUpdateRequest(metrics models.Metrics) error {
+// key is the same for all attempts of the batch
+func (c *MetricsClient) sendUpdateRequest(key string, metrics models.Metrics) error {
 	if len(metrics) == 0 {
 		return nil
 	}
@@ -100,9 +213,18 @@ func (c *MetricsClient) sendUpdateRequest(metrics models.Metrics) error {
 	for _, waitIterval := range attemptsIntervals {
 		time.Sleep(waitIterval)
 
-		resp, err = c.postJSON("/updates", metrics)
+		resp, err = c.postJSON("/updates", key, metrics)
 
 		switch {
+		case err == nil && resp.StatusCode() >= http.StatusInternalServerError:
+			return fmt.Errorf("post %s request status %d: %w",
+				resp.Request.URL, resp.StatusCode(), errServerUnavailable)
+		case err == nil && resp.StatusCode() == http.StatusConflict:
+			// previous attempt with the key is still in progress
+			// on server, the next attempt gets its response.
+			// batch is spooled if it's not finished in time
+			err = fmt.Errorf("request status %d", resp.StatusCode())
+			continue
 		case err == nil:
 			if resp.StatusCode() != http.StatusOK {
 				return fmt.Errorf("post %s request status %d",
@@ -110,14 +232,31 @@ func (c *MetricsClient) sendUpdateRequest(metrics models.Metrics) error {
 			}
 			return nil
 		case !isServerUnavailableErr(err):
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				// connection refused and so on, not worth to retry now
+				return fmt.Errorf("post updates: %v: %w", err, errServerUnavailable)
+			}
 			return fmt.Errorf("post updates: %v", err)
 		}
 	}
 
-	return fmt.Errorf("post updates: %v", err)
+	return fmt.Errorf("post updates: %v: %w", err, errServerUnavailable)
+}
+
+func newIdempotencyKey() (string, error) {
+	return randomHex(16)
+}
+
+func randomHex(n int) (string, error) {
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
 }
 
-func (c *MetricsClient) postJSON(url string, object interface{}) (*resty.Response, error) {
+func (c *MetricsClient) postJSON(url string, key string, object interface{}) (*resty.Response, error) {
 	body, err := json.Marshal(object)
 	if err != nil {
 		return nil, fmt.Errorf("json marshalling body: %v", err)
@@ -128,13 +267,44 @@ func (c *MetricsClient) postJSON(url string, object interface{}) (*resty.Respons
 	// doesn't guarantee that request bodt will be equal to 'body'
 	req := c.client.R().
 		SetHeader("Content-Type", "application/json").
+		SetHeader(idempotencyKeyHeader, key).
 		SetBody(bytes.NewReader(body))
 
+	// without it server with trusted subnet rejects request
+	if ip, err := c.outboundIP(); err != nil {
+		log.Printf("outbound ip detection: %v", err)
+	} else {
+		req.SetHeader(realIPHeader, ip.String())
+	}
+
 	if len(c.secretkey) > 0 {
+		// every attempt is signed with its own nonce
+		nonce, err := randomHex(16)
+		if err != nil {
+			return nil, fmt.Errorf("nonce generation: %v", err)
+		}
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
 		h := hmac.New(sha256.New, []byte(c.secretkey))
+		h.Write([]byte(timestamp + "\n" + nonce + "\n"))
 		h.Write(body)
 		hashSum := hex.EncodeToString(h.Sum(nil))
 		req.SetHeader(signHeader, hashSum)
+		req.SetHeader(signTimestampHeader, timestamp)
+		req.SetHeader(signNonceHeader, nonce)
+		if c.keyID != "" {
+			req.SetHeader(signKeyIDHeader, c.keyID)
+		}
+	}
+
+	// body is signed before encryption, server decrypts it first
+	if c.cryptoKey != nil {
+		encrypted, err := encryption.Encrypt(c.cryptoKey, body)
+		if err != nil {
+			return nil, fmt.Errorf("body encryption: %v", err)
+		}
+		req.SetHeader(encryption.Header, encryption.Scheme)
+		req.SetBody(bytes.NewReader(encrypted))
 	}
 
 	return req.Post(url)
@@ -153,3 +323,18 @@ func isServerUnavailableErr(err error) bool {
 
 	return false
 }
+
+// fan-out of the same metrics to several servers
+type MetricsClients []*MetricsClient
+
+func (c MetricsClients) BatchUpdate(counters models.CountersList, gauges models.GaugesList) {
+	for _, client := range c {
+		client.BatchUpdate(counters, gauges)
+	}
+}
+
+func (c MetricsClients) Close() {
+	for _, client := range c {
+		client.Close()
+	}
+}
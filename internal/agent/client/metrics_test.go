@@ -1,10 +1,13 @@
 package client
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
+	"github.com/stepkareserva/obsermon/internal/agent/spool"
 	"github.com/stepkareserva/obsermon/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,7 +26,7 @@ func TestUpdateCounter(t *testing.T) {
 	}))
 	defer mockServer.Close()
 
-	metricsClient, err := New(mockServer.URL, "", 1)
+	metricsClient, err := New(mockServer.URL, "", 1, TLSConfig{})
 	require.NoError(t, err)
 	defer metricsClient.Close()
 
@@ -43,9 +46,131 @@ func TestUpdateGauge(t *testing.T) {
 	}))
 	defer mockServer.Close()
 
-	metricsClient, err := New(mockServer.URL, "", 1)
+	metricsClient, err := New(mockServer.URL, "", 1, TLSConfig{})
 	require.NoError(t, err)
 	defer metricsClient.Close()
 
 	metricsClient.UpdateGauge(gauge)
 }
+
+func TestSpoolUndeliveredBatches(t *testing.T) {
+	var mu sync.Mutex
+	available := false
+	var received []models.Metrics
+	var firstKey string
+	var receivedKeys []string
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !available {
+			if firstKey == "" {
+				firstKey = r.Header.Get("Idempotency-Key")
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var metrics models.Metrics
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&metrics))
+		received = append(received, metrics)
+		receivedKeys = append(receivedKeys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+
+	metricsClient, err := New(mockServer.URL, "", 1, TLSConfig{})
+	require.NoError(t, err)
+	defer metricsClient.Close()
+	metricsSpool, err := spool.Open(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	metricsClient.SetSpool(metricsSpool)
+
+	gauge := func(v models.GaugeValue) models.Metrics {
+		return models.Metrics{models.GaugeMetric(models.Gauge{Name: "name", Value: v})}
+	}
+
+	metricsClient.deliver(gauge(1))
+	metricsClient.deliver(gauge(2))
+	assert.Equal(t, 2, metricsSpool.Len())
+
+	mu.Lock()
+	available = true
+	mu.Unlock()
+
+	metricsClient.deliver(gauge(3))
+	assert.Equal(t, 0, metricsSpool.Len())
+	assert.Equal(t, []models.Metrics{gauge(1), gauge(2), gauge(3)}, received)
+	// spooled batch is replayed with the key of its first attempt
+	require.Len(t, receivedKeys, 3)
+	assert.Equal(t, firstKey, receivedKeys[0])
+}
+
+func TestIdempotencyKeyPerBatch(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+
+	metricsClient, err := New(mockServer.URL, "", 1, TLSConfig{})
+	require.NoError(t, err)
+	defer metricsClient.Close()
+
+	batch := models.Metrics{models.CounterMetric(models.Counter{Name: "PollCount", Value: 1})}
+	require.NoError(t, metricsClient.sendNewUpdateRequest(batch))
+	require.NoError(t, metricsClient.sendNewUpdateRequest(batch))
+
+	require.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[0])
+	assert.NotEqual(t, keys[0], keys[1])
+}
+
+func TestRetryKeyInProgress(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		// the first attempt is still processed by server
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+
+	metricsClient, err := New(mockServer.URL, "", 1, TLSConfig{})
+	require.NoError(t, err)
+	defer metricsClient.Close()
+
+	batch := models.Metrics{models.CounterMetric(models.Counter{Name: "PollCount", Value: 1})}
+	require.NoError(t, metricsClient.sendUpdateRequest("key", batch))
+	assert.Equal(t, []string{"key", "key"}, keys)
+}
+
+func TestRealIPHeader(t *testing.T) {
+	var realIP string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		realIP = r.Header.Get("X-Real-IP")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+
+	metricsClient, err := New(mockServer.URL, "", 1, TLSConfig{})
+	require.NoError(t, err)
+	defer metricsClient.Close()
+
+	batch := models.Metrics{models.CounterMetric(models.Counter{Name: "PollCount", Value: 1})}
+	require.NoError(t, metricsClient.sendNewUpdateRequest(batch))
+
+	// server is local, so request is sent from loopback
+	assert.Equal(t, "127.0.0.1", realIP)
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// server may accept updates only from trusted subnets
+const realIPHeader = "X-Real-IP"
+
+// udp address of server, any port is fine as
+// nothing is sent, only route is resolved
+func serverAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// ip of interface used for requests to server, it's detected
+// for every request as route may be changed while agent works
+func (c *MetricsClient) outboundIP() (net.IP, error) {
+	conn, err := net.Dial("udp", c.serverAddr)
+	if err != nil {
+		return nil, fmt.Errorf("route to server: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return nil, fmt.Errorf("unexpected local address %v", conn.LocalAddr())
+	}
+	return addr.IP, nil
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// empty config for plain http
+type TLSConfig struct {
+	// CA of server certificate, system CAs if empty
+	CAPath string
+	// client certificate and its key for mtls, optional
+	CertPath string
+	KeyPath  string
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CAPath != "" || c.CertPath != "" || c.KeyPath != ""
+}
+
+func (c TLSConfig) clientConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CAPath != "" {
+		data, err := os.ReadFile(c.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("ca file reading: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s: no certificates found", c.CAPath)
+		}
+		config.RootCAs = pool
+	}
+	if c.CertPath != "" || c.KeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertPath, c.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate loading: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
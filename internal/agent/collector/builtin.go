@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
+	"github.com/stepkareserva/obsermon/internal/agent/monitor"
+)
+
+const (
+	RuntimeCollector = "runtime"
+	SystemCollector  = "system"
+	DiskCollector    = "disk"
+	NetCollector     = "net"
+	LoadCollector    = "load"
+)
+
+// registry of all builtin collectors, enabled with default interval
+func Builtin() *Registry {
+	r := NewRegistry()
+	system := monitor.NewSystemMonitor()
+	for _, c := range []Collector{
+		NewFunc(RuntimeCollector, func(context.Context) (*metrics.Metrics, error) {
+			return monitor.GetRuntimeMetrics()
+		}),
+		NewFunc(SystemCollector, system.GetGolangMetrics),
+		NewFunc(DiskCollector, system.GetDiskMetrics),
+		NewFunc(NetCollector, system.GetNetMetrics),
+		NewFunc(LoadCollector, monitor.GetLoadMetrics),
+	} {
+		// names are unique, can't fail
+		_ = r.Register(c, 0)
+	}
+	return r
+}
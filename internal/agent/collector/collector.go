@@ -0,0 +1,139 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
+)
+
+// source of agent metrics, polled by watchdog
+// with its own interval
+type Collector interface {
+	// unique collector name, used in config
+	Name() string
+	Collect(ctx context.Context) (*metrics.Metrics, error)
+}
+
+type funcCollector struct {
+	name    string
+	collect func(ctx context.Context) (*metrics.Metrics, error)
+}
+
+// collector from plain function
+func NewFunc(name string, collect func(ctx context.Context) (*metrics.Metrics, error)) Collector {
+	return &funcCollector{
+		name:    name,
+		collect: collect,
+	}
+}
+
+func (c *funcCollector) Name() string {
+	return c.name
+}
+
+func (c *funcCollector) Collect(ctx context.Context) (*metrics.Metrics, error) {
+	return c.collect(ctx)
+}
+
+type Entry struct {
+	Collector Collector
+	// poll interval, zero for watchdog's default
+	Interval time.Duration
+	Enabled  bool
+}
+
+// collectors in order of registration
+type Registry struct {
+	entries []Entry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register enabled collector, zero interval for default one
+func (r *Registry) Register(c Collector, interval time.Duration) error {
+	if c == nil {
+		return fmt.Errorf("collector not exists")
+	}
+	if interval < 0 {
+		return fmt.Errorf("invalid collector %s interval %v", c.Name(), interval)
+	}
+	if r.find(c.Name()) != nil {
+		return fmt.Errorf("collector %s already registered", c.Name())
+	}
+	r.entries = append(r.entries, Entry{
+		Collector: c,
+		Interval:  interval,
+		Enabled:   true,
+	})
+	return nil
+}
+
+func (r *Registry) SetEnabled(name string, enabled bool) error {
+	entry := r.find(name)
+	if entry == nil {
+		return fmt.Errorf("unknown collector %s", name)
+	}
+	entry.Enabled = enabled
+	return nil
+}
+
+func (r *Registry) SetInterval(name string, interval time.Duration) error {
+	entry := r.find(name)
+	if entry == nil {
+		return fmt.Errorf("unknown collector %s", name)
+	}
+	if interval < 0 {
+		return fmt.Errorf("invalid collector %s interval %v", name, interval)
+	}
+	entry.Interval = interval
+	return nil
+}
+
+// enable only listed collectors, with intervals if non-zero
+func (r *Registry) EnableOnly(intervals map[string]time.Duration) error {
+	for name := range intervals {
+		if r.find(name) == nil {
+			return fmt.Errorf("unknown collector %s", name)
+		}
+	}
+	for i := range r.entries {
+		interval, enabled := intervals[r.entries[i].Collector.Name()]
+		r.entries[i].Enabled = enabled
+		if enabled && interval > 0 {
+			r.entries[i].Interval = interval
+		}
+	}
+	return nil
+}
+
+// names of registered collectors
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.entries))
+	for _, entry := range r.entries {
+		names = append(names, entry.Collector.Name())
+	}
+	return names
+}
+
+func (r *Registry) Enabled() []Entry {
+	var enabled []Entry
+	for _, entry := range r.entries {
+		if entry.Enabled {
+			enabled = append(enabled, entry)
+		}
+	}
+	return enabled
+}
+
+func (r *Registry) find(name string) *Entry {
+	for i := range r.entries {
+		if r.entries[i].Collector.Name() == name {
+			return &r.entries[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
+)
+
+func testCollector(name string) Collector {
+	return NewFunc(name, func(context.Context) (*metrics.Metrics, error) {
+		m := metrics.New()
+		return &m, nil
+	})
+}
+
+func enabledNames(r *Registry) map[string]time.Duration {
+	names := make(map[string]time.Duration)
+	for _, entry := range r.Enabled() {
+		names[entry.Collector.Name()] = entry.Interval
+	}
+	return names
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(testCollector("a"), 0))
+	require.NoError(t, r.Register(testCollector("b"), time.Second))
+	require.NoError(t, r.Register(testCollector("c"), 0))
+
+	assert.Error(t, r.Register(testCollector("a"), 0))
+	assert.Error(t, r.Register(testCollector("d"), -time.Second))
+	assert.Equal(t, map[string]time.Duration{"a": 0, "b": time.Second, "c": 0}, enabledNames(r))
+
+	require.NoError(t, r.SetEnabled("b", false))
+	require.NoError(t, r.SetInterval("c", 5*time.Second))
+	assert.Equal(t, map[string]time.Duration{"a": 0, "c": 5 * time.Second}, enabledNames(r))
+
+	require.NoError(t, r.EnableOnly(map[string]time.Duration{"b": 0, "c": time.Minute}))
+	assert.Equal(t, map[string]time.Duration{"b": time.Second, "c": time.Minute}, enabledNames(r))
+
+	assert.Error(t, r.SetEnabled("unknown", true))
+	assert.Error(t, r.EnableOnly(map[string]time.Duration{"unknown": 0}))
+}
@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+const ExecCollector = "exec"
+
+// counters of failed and timed out runs, per script,
+// like ExecFailures.disk_quota.sh
+const (
+	ExecFailuresCounter = "ExecFailures"
+	ExecTimeoutsCounter = "ExecTimeouts"
+)
+
+// give up on script's stdout after it was killed
+// but its children still keep stdout open
+const execWaitDelay = time.Second
+
+// runs executables and collects metrics from their stdout,
+// either lines like "gauge name 1.5" and "counter name 2",
+// or json array of metrics like /updates request body
+type execCollector struct {
+	scripts []string
+	timeout time.Duration
+}
+
+func NewExec(scripts []string, timeout time.Duration) (Collector, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("invalid exec timeout %v", timeout)
+	}
+	return &execCollector{
+		scripts: scripts,
+		timeout: timeout,
+	}, nil
+}
+
+func (c *execCollector) Name() string {
+	return ExecCollector
+}
+
+func (c *execCollector) Collect(ctx context.Context) (*metrics.Metrics, error) {
+	result := metrics.New()
+	for _, script := range c.scripts {
+		name := filepath.Base(script)
+		m, err := c.run(ctx, script)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			log.Printf("exec %s: timeout", script)
+			result.Counters[ExecTimeoutsCounter+"."+name]++
+		case err != nil:
+			log.Printf("exec %s: %v", script, err)
+			result.Counters[ExecFailuresCounter+"."+name]++
+		default:
+			if err := result.Update(*m); err != nil {
+				log.Printf("exec %s: %v", script, err)
+				result.Counters[ExecFailuresCounter+"."+name]++
+			}
+		}
+	}
+	return &result, nil
+}
+
+func (c *execCollector) run(ctx context.Context, script string) (*metrics.Metrics, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, script)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = execWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return parseExecOutput(stdout.Bytes())
+}
+
+func parseExecOutput(output []byte) (*metrics.Metrics, error) {
+	if trimmed := bytes.TrimSpace(output); len(trimmed) > 0 && trimmed[0] == '[' {
+		return parseExecJSON(trimmed)
+	}
+
+	result := metrics.New()
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected \"type name value\"", n)
+		}
+		mtype, name, value := models.MetricType(fields[0]), fields[1], fields[2]
+		switch mtype {
+		case models.MetricTypeGauge:
+			var v models.GaugeValue
+			if err := v.FromString(value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			result.Gauges[name] = v
+		case models.MetricTypeCounter:
+			var v models.CounterValue
+			if err := v.FromString(value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			if err := result.Counters.Update(models.CountersMap{name: v}); err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unsupported metric type %q", n, mtype)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("output reading: %v", err)
+	}
+	return &result, nil
+}
+
+func parseExecJSON(output []byte) (*metrics.Metrics, error) {
+	var list models.Metrics
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("output json decoding: %v", err)
+	}
+
+	result := metrics.New()
+	for _, m := range list {
+		switch m.MType {
+		case models.MetricTypeGauge:
+			gauge, err := m.Gauge()
+			if err != nil {
+				return nil, fmt.Errorf("gauge %s: %v", m.ID, err)
+			}
+			result.Gauges[gauge.Name] = gauge.Value
+		case models.MetricTypeCounter:
+			counter, err := m.Counter()
+			if err != nil {
+				return nil, fmt.Errorf("counter %s: %v", m.ID, err)
+			}
+			if err := result.Counters.Update(models.CountersMap{counter.Name: counter.Value}); err != nil {
+				return nil, fmt.Errorf("counter %s: %v", m.ID, err)
+			}
+		default:
+			return nil, fmt.Errorf("metric %s: unsupported metric type %q", m.ID, m.MType)
+		}
+	}
+	return &result, nil
+}
@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestParseExecOutput(t *testing.T) {
+	m, err := parseExecOutput([]byte("# comment\n\ngauge queue 1.5\ncounter jobs 2\ncounter jobs 3\n"))
+	require.NoError(t, err)
+	assert.Equal(t, models.GaugesMap{"queue": 1.5}, m.Gauges)
+	assert.Equal(t, models.CountersMap{"jobs": 5}, m.Counters)
+
+	m, err = parseExecOutput([]byte(`[{"id":"queue","type":"gauge","value":2},
+		{"id":"jobs","type":"counter","delta":1}]`))
+	require.NoError(t, err)
+	assert.Equal(t, models.GaugesMap{"queue": 2}, m.Gauges)
+	assert.Equal(t, models.CountersMap{"jobs": 1}, m.Counters)
+
+	invalid := []string{
+		"gauge queue",
+		"gauge queue 1.5 extra",
+		"histogram queue 1",
+		"counter jobs 1.5",
+		`[{"id":"queue","type":"gauge"}]`,
+		`[{"id":"queue"`,
+	}
+	for _, output := range invalid {
+		_, err := parseExecOutput([]byte(output))
+		assert.Error(t, err, output)
+	}
+}
+
+func writeScript(t *testing.T, dir, name, body string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755))
+	return path
+}
+
+func TestExecCollector(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported")
+	}
+
+	dir := t.TempDir()
+	scripts := []string{
+		writeScript(t, dir, "ok.sh", "echo 'gauge queue 3'\necho 'counter jobs 4'\n"),
+		writeScript(t, dir, "fail.sh", "echo oops >&2\nexit 1\n"),
+		writeScript(t, dir, "garbage.sh", "echo garbage\n"),
+		writeScript(t, dir, "slow.sh", "sleep 5\n"),
+	}
+
+	c, err := NewExec(scripts, 200*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, ExecCollector, c.Name())
+
+	m, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.GaugesMap{"queue": 3}, m.Gauges)
+	assert.Equal(t, models.CountersMap{
+		"jobs":                    4,
+		"ExecFailures.fail.sh":    1,
+		"ExecFailures.garbage.sh": 1,
+		"ExecTimeouts.slow.sh":    1,
+	}, m.Counters)
+}
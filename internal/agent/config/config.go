@@ -2,26 +2,110 @@ package config
 
 import (
 	"time"
+
+	"github.com/stepkareserva/obsermon/internal/agent/client"
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
 )
 
+// every setting may be set in config file, env or flags,
+// except of structured ones which are file only.
+// json names are used in config file and validation errors.
 type Config struct {
+	// path to json or yaml config file, optional
+	ConfigPath string `env:"CONFIG" json:"-"`
 	// endpoint address, without protocol.
 	// to get endpoint URL call EndpointURL()
-	Endpoint string `env:"ADDRESS"`
+	Endpoint string `env:"ADDRESS" json:"address"`
+	// additional endpoints addresses, metrics are sent
+	// to every one of them. config file only.
+	// to get all endpoints URLs call EndpointURLs()
+	Endpoints []string `json:"endpoints,omitempty"`
 	// pool interval in seconds.
 	// to get duration call PollInterval()
-	PollIntervalS int `env:"POLL_INTERVAL"`
+	PollIntervalS int `env:"POLL_INTERVAL" json:"poll_interval"`
 	// pool interval in seconds.
 	// to get duration call ReportInterval()
-	ReportIntervalS int `env:"REPORT_INTERVAL"`
+	ReportIntervalS int `env:"REPORT_INTERVAL" json:"report_interval"`
 	// key to sign report requests
-	ReportSignKey string `env:"KEY"`
+	ReportSignKey string `env:"KEY" json:"key"`
+	// id of agent's own sign key registered on server,
+	// empty for common key
+	ReportSignKeyID string `env:"KEY_ID" json:"key_id"`
+	// path to server's PEM public RSA key
+	// to encrypt requests, empty to don't encrypt
+	CryptoKeyPath string `env:"CRYPTO_KEY" json:"crypto_key"`
+	// use https, implied by other tls settings
+	TLS bool `env:"TLS" json:"tls"`
+	// CA of server certificate, system CAs if empty
+	TLSCAPath string `env:"TLS_CA" json:"tls_ca"`
+	// client certificate and its key for mtls
+	TLSCertPath string `env:"TLS_CERT" json:"tls_cert"`
+	TLSKeyPath  string `env:"TLS_KEY" json:"tls_key"`
 	// requests rate limit
-	RateLimit int `env:"RATE_LIMIT"`
+	RateLimit int `env:"RATE_LIMIT" json:"rate_limit"`
+	// enabled collectors with optional poll intervals,
+	// empty to enable all with default poll interval.
+	// to get intervals call CollectorIntervals()
+	Collectors CollectorsConfig `env:"COLLECTORS" json:"collectors,omitempty"`
+	// gauges aggregations over report window,
+	// "*" for all other gauges.
+	// to get aggregations call GaugeAggregations()
+	Aggregations AggregationsConfig `env:"GAUGE_AGGREGATIONS" json:"aggregations,omitempty"`
+	// collected metrics names filters. config file only
+	Filters FiltersConfig `json:"filters"`
+	// executables for exec collector
+	ExecScripts List `env:"EXEC_SCRIPTS" json:"exec_scripts,omitempty"`
+	// exec collector's script timeout in seconds.
+	// to get duration call ExecTimeout()
+	ExecTimeoutS int `env:"EXEC_TIMEOUT" json:"exec_timeout"`
+	// prometheus endpoints urls to scrape,
+	// like http://localhost:9100/metrics
+	ScrapeTargets List `env:"SCRAPE_TARGETS" json:"scrape_targets,omitempty"`
+	// scrape interval in seconds.
+	// to get duration call ScrapeInterval()
+	ScrapeIntervalS int `env:"SCRAPE_INTERVAL" json:"scrape_interval"`
+	// directory for batches undelivered because of
+	// server unavailability, empty to drop them
+	SpoolDir string `env:"SPOOL_DIR" json:"spool_dir"`
+	// max total size of spooled batches in bytes,
+	// oldest batches are dropped when exceeded
+	SpoolMaxSize int64 `env:"SPOOL_MAX_SIZE" json:"spool_max_size"`
+}
+
+// glob patterns like "Heap*" of metrics names,
+// metric is reported if it matches any of include patterns
+// (or include is empty) and none of exclude ones
+type FiltersConfig struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
 }
 
 func (c *Config) EndpointURL() string {
-	return "http://" + c.Endpoint
+	return c.scheme() + c.Endpoint
+}
+
+// urls of main and additional endpoints
+func (c *Config) EndpointURLs() []string {
+	urls := []string{c.EndpointURL()}
+	for _, endpoint := range c.Endpoints {
+		urls = append(urls, c.scheme()+endpoint)
+	}
+	return urls
+}
+
+func (c *Config) scheme() string {
+	if c.TLS || c.TLSConfig().Enabled() {
+		return "https://"
+	}
+	return "http://"
+}
+
+func (c *Config) TLSConfig() client.TLSConfig {
+	return client.TLSConfig{
+		CAPath:   c.TLSCAPath,
+		CertPath: c.TLSCertPath,
+		KeyPath:  c.TLSKeyPath,
+	}
 }
 
 func (c *Config) PollInterval() time.Duration {
@@ -31,3 +115,36 @@ func (c *Config) PollInterval() time.Duration {
 func (c *Config) ReportInterval() time.Duration {
 	return time.Duration(c.ReportIntervalS) * time.Second
 }
+
+func (c *Config) ExecTimeout() time.Duration {
+	return time.Duration(c.ExecTimeoutS) * time.Second
+}
+
+func (c *Config) ScrapeInterval() time.Duration {
+	return time.Duration(c.ScrapeIntervalS) * time.Second
+}
+
+// enabled collectors with poll intervals,
+// zero interval for default one, nil for all collectors
+func (c *Config) CollectorIntervals() map[string]time.Duration {
+	if len(c.Collectors) == 0 {
+		return nil
+	}
+	intervals := make(map[string]time.Duration, len(c.Collectors))
+	for _, collector := range c.Collectors {
+		intervals[collector.Name] = time.Duration(collector.IntervalS) * time.Second
+	}
+	return intervals
+}
+
+// aggregations of gauges over report window, nil if not configured
+func (c *Config) GaugeAggregations() metrics.Aggregations {
+	return c.Aggregations.Aggregations()
+}
+
+func (c *Config) MetricsFilter() metrics.Filter {
+	return metrics.Filter{
+		Include: c.Filters.Include,
+		Exclude: c.Filters.Exclude,
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestReadFileParams(t *testing.T) {
+	yamlPath := writeFile(t, "agent.yaml", `
+address: example.com:8080
+endpoints: [backup.example.com:8080]
+report_interval: 30
+collectors:
+  - name: runtime
+  - name: disk
+    interval: 60
+aggregations:
+  HeapAlloc: [max, p95]
+  "*": [mean]
+filters:
+  exclude: ["Net*"]
+exec_scripts: /opt/a.sh,/opt/b.sh
+`)
+	jsonPath := writeFile(t, "agent.json", `{
+		"address": "example.com:8080",
+		"endpoints": ["backup.example.com:8080"],
+		"report_interval": 30,
+		"collectors": "runtime,disk:60",
+		"aggregations": "HeapAlloc:max:p95,*:mean",
+		"filters": {"exclude": ["Net*"]},
+		"exec_scripts": ["/opt/a.sh", "/opt/b.sh"]
+	}`)
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			cfg := defaultConfig()
+			require.NoError(t, readFileParams(cfg, path))
+
+			assert.Equal(t, []string{"http://example.com:8080", "http://backup.example.com:8080"},
+				cfg.EndpointURLs())
+			assert.Equal(t, 30*time.Second, cfg.ReportInterval())
+			assert.Equal(t, 2*time.Second, cfg.PollInterval())
+			assert.Equal(t, map[string]time.Duration{"runtime": 0, "disk": time.Minute},
+				cfg.CollectorIntervals())
+			assert.Equal(t, metrics.Aggregations{
+				"HeapAlloc": {metrics.AggregationMax, metrics.AggregationP95},
+				"*":         {metrics.AggregationMean},
+			}, cfg.GaugeAggregations())
+			assert.Equal(t, []string{"Net*"}, cfg.Filters.Exclude)
+			assert.Equal(t, List{"/opt/a.sh", "/opt/b.sh"}, cfg.ExecScripts)
+			require.NoError(t, Validate(*cfg))
+		})
+	}
+
+	cfg := defaultConfig()
+	assert.Error(t, readFileParams(cfg, writeFile(t, "agent.json", `{"adress": "typo"}`)))
+	assert.Error(t, readFileParams(cfg, writeFile(t, "agent.yaml", "collectors: [{nam: runtime}]")))
+	assert.Error(t, readFileParams(cfg, writeFile(t, "agent.toml", "")))
+}
+
+func TestPrecedence(t *testing.T) {
+	path := writeFile(t, "agent.json", `{
+		"address": "file:1",
+		"poll_interval": 3,
+		"report_interval": 30,
+		"key": "file"
+	}`)
+	t.Setenv("REPORT_INTERVAL", "20")
+	t.Setenv("KEY", "env")
+	t.Setenv("CONFIG", "ignored.json")
+	args := []string{"-config", path, "-k", "flag"}
+
+	configFile, err := configPath(args)
+	require.NoError(t, err)
+	require.Equal(t, path, configFile)
+
+	cfg := defaultConfig()
+	require.NoError(t, readFileParams(cfg, configFile))
+	require.NoError(t, readEnvParams(cfg))
+	require.NoError(t, readCLIParams(cfg, args))
+
+	assert.Equal(t, "file:1", cfg.Endpoint)
+	assert.Equal(t, 3, cfg.PollIntervalS)
+	assert.Equal(t, 20, cfg.ReportIntervalS)
+	assert.Equal(t, "flag", cfg.ReportSignKey)
+	assert.Equal(t, 1, cfg.RateLimit)
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.PollIntervalS = 0
+	cfg.Endpoints = []string{"bad host:1:2"}
+	cfg.Collectors = CollectorsConfig{{Name: "runtime"}, {Name: "runtime", IntervalS: -1},
+		{Name: "exec"}, {Name: "gpu"}}
+	cfg.Aggregations = AggregationsConfig{"HeapAlloc": {"max", "median"}}
+	cfg.Filters.Include = []string{"Heap*", "Disk[Free"}
+	cfg.ScrapeTargets = List{"ftp://localhost/metrics"}
+
+	err := Validate(*cfg)
+	require.Error(t, err)
+	lines := strings.Split(err.Error(), "\n")
+	paths := make([]string, 0, len(lines))
+	for _, line := range lines {
+		path, _, _ := strings.Cut(line, ":")
+		paths = append(paths, path)
+	}
+	assert.Equal(t, []string{
+		"endpoints[0]",
+		"poll_interval",
+		"collectors[1].name",
+		"collectors[1].interval",
+		"collectors[2].name",
+		"collectors[3].name",
+		"aggregations.HeapAlloc[1]",
+		"filters.include[1]",
+		"scrape_targets[0]",
+	}, paths)
+}
@@ -1,20 +1,37 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/caarlos0/env/v6"
+	"gopkg.in/yaml.v3"
 )
 
+// precedence is defaults < config file < env < flags
 func LoadConfig() (*Config, error) {
 	cfg := defaultConfig()
-	if err := readCLIParams(cfg); err != nil {
+
+	path, err := configPath(os.Args[1:])
+	if err != nil {
 		return nil, err
 	}
+	if path != "" {
+		if err := readFileParams(cfg, path); err != nil {
+			return nil, err
+		}
+	}
 	if err := readEnvParams(cfg); err != nil {
 		return nil, err
 	}
+	if err := readCLIParams(cfg, os.Args[1:]); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
@@ -24,12 +41,65 @@ func defaultConfig() *Config {
 		PollIntervalS:   2,
 		ReportIntervalS: 10,
 		RateLimit:       1,
+		ExecTimeoutS:    10,
+		ScrapeIntervalS: 10,
+		SpoolMaxSize:    16 << 20,
+	}
+}
+
+// config file path from flags or env
+func configPath(args []string) (string, error) {
+	var c Config
+	fs := newFlagSet(&c)
+	fs.SetOutput(&bytes.Buffer{})
+	if err := fs.Parse(args); err != nil {
+		// will be reported on real flags parsing
+		return os.Getenv("CONFIG"), nil
+	}
+	if c.ConfigPath != "" {
+		return c.ConfigPath, nil
+	}
+	return os.Getenv("CONFIG"), nil
+}
+
+// json or yaml file depending on extension
+func readFileParams(c *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file reading: %v", err)
 	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// yaml is converted to json to share
+		// unmarshalling and unknown fields checks
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("config file %s decoding: %v", path, err)
+		}
+		if v == nil {
+			return nil
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return fmt.Errorf("config file %s decoding: %v", path, err)
+		}
+	case ".json":
+	default:
+		return fmt.Errorf("config file %s: unsupported format, json or yaml expected", path)
+	}
+
+	if err := unmarshalJSONStrict(data, c); err != nil {
+		return fmt.Errorf("config file %s decoding: %v", path, err)
+	}
+	return nil
 }
 
-func readCLIParams(c *Config) error {
+func newFlagSet(c *Config) *flag.FlagSet {
 	fs := flag.NewFlagSet("", flag.ContinueOnError)
 
+	fs.StringVar(&c.ConfigPath, "config", c.ConfigPath,
+		"path to json or yaml config file,\n"+
+			"flags and env take precedence over it")
 	fs.StringVar(&c.Endpoint, "a", c.Endpoint,
 		"server endpoint tcp address, like :8080, 127.0.0.1:80,\n"+
 			"localhost:22 (without protocol)")
@@ -42,14 +112,54 @@ func readCLIParams(c *Config) error {
 	fs.StringVar(&c.ReportSignKey, "k", c.ReportSignKey,
 		"report sign key,\n"+
 			"string")
+	fs.StringVar(&c.ReportSignKeyID, "key-id", c.ReportSignKeyID,
+		"id of agent's own sign key registered on server,\n"+
+			"empty for common key")
+	fs.StringVar(&c.CryptoKeyPath, "crypto-key", c.CryptoKeyPath,
+		"path to server's PEM public RSA key to encrypt requests,\n"+
+			"empty to don't encrypt")
+	fs.BoolVar(&c.TLS, "tls", c.TLS,
+		"use https, implied by other tls settings")
+	fs.StringVar(&c.TLSCAPath, "tls-ca", c.TLSCAPath,
+		"path to PEM CA of server certificate,\n"+
+			"empty for system CAs")
+	fs.StringVar(&c.TLSCertPath, "tls-cert", c.TLSCertPath,
+		"path to PEM client certificate for mtls")
+	fs.StringVar(&c.TLSKeyPath, "tls-key", c.TLSKeyPath,
+		"path to PEM client certificate private key")
 	fs.IntVar(&c.RateLimit, "l", c.RateLimit,
 		"requests rate limit, 0 for unlimited,\n"+
 			"non-negative integer")
-	if err := fs.Parse(os.Args[1:]); err != nil {
-		return err
-	}
+	fs.Var(&c.Collectors, "c",
+		"comma-separated enabled collectors with optional poll\n"+
+			"interval in seconds, like runtime,system:5, empty for all")
+	fs.Var(&c.Aggregations, "g",
+		"comma-separated gauges aggregations over report window\n"+
+			"(last, min, max, mean, p95), like HeapAlloc:max:p95,*:mean")
+	fs.Var(&c.ExecScripts, "exec",
+		"comma-separated executables for exec collector,\n"+
+			"stdout lines like \"gauge name 1.5\" or /updates json")
+	fs.IntVar(&c.ExecTimeoutS, "exec-timeout", c.ExecTimeoutS,
+		"exec collector script timeout, in seconds,\n"+
+			"positive integer")
+	fs.Var(&c.ScrapeTargets, "scrape",
+		"comma-separated prometheus endpoints to scrape and relay,\n"+
+			"like http://localhost:9100/metrics")
+	fs.IntVar(&c.ScrapeIntervalS, "scrape-interval", c.ScrapeIntervalS,
+		"prometheus endpoints scrape interval, in seconds,\n"+
+			"positive integer")
+	fs.StringVar(&c.SpoolDir, "spool", c.SpoolDir,
+		"directory to spool batches undelivered because of\n"+
+			"server unavailability, empty to drop them")
+	fs.Int64Var(&c.SpoolMaxSize, "spool-max-size", c.SpoolMaxSize,
+		"max spool size in bytes, oldest batches are dropped\n"+
+			"when exceeded, positive integer")
 
-	return nil
+	return fs
+}
+
+func readCLIParams(c *Config, args []string) error {
+	return newFlagSet(c).Parse(args)
 }
 
 func readEnvParams(c *Config) error {
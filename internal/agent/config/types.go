@@ -0,0 +1,182 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
+)
+
+// settings below are comma-separated strings in env and flags,
+// and either the same strings or structured values in config file
+
+// list like "a,b,c" or ["a", "b", "c"]
+type List []string
+
+func (l *List) UnmarshalText(text []byte) error {
+	*l = nil
+	for _, item := range strings.Split(string(text), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+func (l *List) UnmarshalJSON(data []byte) error {
+	if isJSONString(data) {
+		return unmarshalJSONText(data, l)
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// flag.Value
+func (l *List) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
+
+func (l *List) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+type CollectorConfig struct {
+	Name string `json:"name"`
+	// poll interval in seconds, zero for default one
+	IntervalS int `json:"interval,omitempty"`
+}
+
+// enabled collectors, like "runtime,system:5"
+// or [{"name": "runtime"}, {"name": "system", "interval": 5}],
+// empty to enable all
+type CollectorsConfig []CollectorConfig
+
+func (c *CollectorsConfig) UnmarshalText(text []byte) error {
+	*c = nil
+	var names List
+	_ = names.UnmarshalText(text)
+	for _, item := range names {
+		name, intervalS, hasInterval := strings.Cut(item, ":")
+		collector := CollectorConfig{Name: name}
+		if hasInterval {
+			interval, err := strconv.Atoi(intervalS)
+			if err != nil {
+				return fmt.Errorf("invalid collector %s interval %q", name, intervalS)
+			}
+			collector.IntervalS = interval
+		}
+		*c = append(*c, collector)
+	}
+	return nil
+}
+
+func (c *CollectorsConfig) UnmarshalJSON(data []byte) error {
+	if isJSONString(data) {
+		return unmarshalJSONText(data, c)
+	}
+	return unmarshalJSONStrict(data, (*[]CollectorConfig)(c))
+}
+
+func (c *CollectorsConfig) Set(s string) error {
+	return c.UnmarshalText([]byte(s))
+}
+
+func (c *CollectorsConfig) String() string {
+	if c == nil {
+		return ""
+	}
+	items := make([]string, 0, len(*c))
+	for _, collector := range *c {
+		if collector.IntervalS != 0 {
+			items = append(items, fmt.Sprintf("%s:%d", collector.Name, collector.IntervalS))
+		} else {
+			items = append(items, collector.Name)
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// gauges aggregations, like "HeapAlloc:max:p95,*:mean"
+// or {"HeapAlloc": ["max", "p95"], "*": ["mean"]}
+type AggregationsConfig map[string][]string
+
+func (a *AggregationsConfig) UnmarshalText(text []byte) error {
+	*a = nil
+	var items List
+	_ = items.UnmarshalText(text)
+	for _, item := range items {
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 {
+			return fmt.Errorf("invalid gauge aggregation %q", item)
+		}
+		if *a == nil {
+			*a = make(AggregationsConfig)
+		}
+		if _, exists := (*a)[parts[0]]; exists {
+			return fmt.Errorf("duplicated gauge %s aggregation", parts[0])
+		}
+		(*a)[parts[0]] = parts[1:]
+	}
+	return nil
+}
+
+func (a *AggregationsConfig) UnmarshalJSON(data []byte) error {
+	if isJSONString(data) {
+		return unmarshalJSONText(data, a)
+	}
+	return json.Unmarshal(data, (*map[string][]string)(a))
+}
+
+func (a *AggregationsConfig) Set(s string) error {
+	return a.UnmarshalText([]byte(s))
+}
+
+func (a *AggregationsConfig) String() string {
+	if a == nil {
+		return ""
+	}
+	var items []string
+	for gauge, aggregations := range *a {
+		items = append(items, gauge+":"+strings.Join(aggregations, ":"))
+	}
+	return strings.Join(items, ",")
+}
+
+// parsed aggregations, call after validation
+func (a AggregationsConfig) Aggregations() metrics.Aggregations {
+	if len(a) == 0 {
+		return nil
+	}
+	aggregations := make(metrics.Aggregations, len(a))
+	for gauge, names := range a {
+		for _, name := range names {
+			if aggregation, err := metrics.ParseAggregation(name); err == nil {
+				aggregations[gauge] = append(aggregations[gauge], aggregation)
+			}
+		}
+	}
+	return aggregations
+}
+
+func isJSONString(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`))
+}
+
+func unmarshalJSONText(data []byte, v interface{ UnmarshalText([]byte) error }) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+func unmarshalJSONStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
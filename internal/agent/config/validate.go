@@ -1,23 +1,121 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
+	"path"
+	"slices"
+	"sort"
+
+	"github.com/stepkareserva/obsermon/internal/agent/collector"
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
 )
 
+// all problems at once, every one prefixed
+// with field path like "collectors[1].interval"
 func Validate(c Config) error {
-	_, err := url.ParseRequestURI(c.EndpointURL())
-	if err != nil {
-		return fmt.Errorf("invalid endpoint: %v", err)
+	var errs []error
+	report := func(path string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	if _, err := url.ParseRequestURI(c.EndpointURL()); err != nil {
+		report("address", "invalid endpoint: %v", err)
+	}
+	for i, endpoint := range c.Endpoints {
+		if _, err := url.ParseRequestURI("http://" + endpoint); err != nil {
+			report(fmt.Sprintf("endpoints[%d]", i), "invalid endpoint: %v", err)
+		}
 	}
 	if c.PollInterval() <= 0 {
-		return fmt.Errorf("invalid poll interval %v", c.PollInterval())
+		report("poll_interval", "invalid poll interval %v", c.PollInterval())
 	}
 	if c.ReportInterval() <= 0 {
-		return fmt.Errorf("invalid report interval %v", c.ReportInterval())
+		report("report_interval", "invalid report interval %v", c.ReportInterval())
+	}
+	if c.ReportSignKeyID != "" && c.ReportSignKey == "" {
+		report("key_id", "key id without key")
+	}
+	if (c.TLSCertPath == "") != (c.TLSKeyPath == "") {
+		report("tls_cert", "tls certificate and key must be passed together")
 	}
 	if c.RateLimit < 0 {
-		return fmt.Errorf("invalid rate limit %v", c.RateLimit)
+		report("rate_limit", "invalid rate limit %v", c.RateLimit)
+	}
+
+	// exec collector is registered only with scripts
+	known := collector.Builtin().Names()
+	if len(c.ExecScripts) > 0 {
+		known = append(known, collector.ExecCollector)
+	}
+	names := make(map[string]struct{}, len(c.Collectors))
+	for i, coll := range c.Collectors {
+		path := fmt.Sprintf("collectors[%d]", i)
+		if coll.Name == "" {
+			report(path+".name", "empty collector name")
+		} else if _, exists := names[coll.Name]; exists {
+			report(path+".name", "duplicated collector %s", coll.Name)
+		} else if !slices.Contains(known, coll.Name) {
+			report(path+".name", "unknown collector %s", coll.Name)
+		}
+		names[coll.Name] = struct{}{}
+		if coll.IntervalS < 0 {
+			report(path+".interval", "invalid collector interval %d", coll.IntervalS)
+		}
+	}
+
+	// sorted for stable errors order
+	gauges := make([]string, 0, len(c.Aggregations))
+	for gauge := range c.Aggregations {
+		gauges = append(gauges, gauge)
+	}
+	sort.Strings(gauges)
+	for _, gauge := range gauges {
+		path := fmt.Sprintf("aggregations.%s", gauge)
+		if gauge == "" {
+			report(path, "empty gauge name")
+		}
+		if len(c.Aggregations[gauge]) == 0 {
+			report(path, "no aggregations")
+		}
+		for i, name := range c.Aggregations[gauge] {
+			if _, err := metrics.ParseAggregation(name); err != nil {
+				report(fmt.Sprintf("%s[%d]", path, i), "%v", err)
+			}
+		}
+	}
+
+	// filter is not path.Match, but uses the same syntax
+	checkPatterns := func(field string, patterns []string) {
+		for i, pattern := range patterns {
+			if pattern == "" {
+				report(fmt.Sprintf("%s[%d]", field, i), "empty pattern")
+			} else if _, err := path.Match(pattern, ""); err != nil {
+				report(fmt.Sprintf("%s[%d]", field, i), "invalid pattern %q: %v", pattern, err)
+			}
+		}
+	}
+	checkPatterns("filters.include", c.Filters.Include)
+	checkPatterns("filters.exclude", c.Filters.Exclude)
+
+	if len(c.ExecScripts) > 0 && c.ExecTimeout() <= 0 {
+		report("exec_timeout", "invalid exec timeout %v", c.ExecTimeout())
+	}
+	for i, target := range c.ScrapeTargets {
+		u, err := url.ParseRequestURI(target)
+		if err != nil {
+			report(fmt.Sprintf("scrape_targets[%d]", i), "invalid url: %v", err)
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			report(fmt.Sprintf("scrape_targets[%d]", i), "invalid url scheme %s", u.Scheme)
+		}
+	}
+	if len(c.ScrapeTargets) > 0 && c.ScrapeInterval() <= 0 {
+		report("scrape_interval", "invalid scrape interval %v", c.ScrapeInterval())
 	}
-	return nil
+	if c.SpoolDir != "" && c.SpoolMaxSize <= 0 {
+		report("spool_max_size", "invalid spool max size %v", c.SpoolMaxSize)
+	}
+
+	return errors.Join(errs...)
 }
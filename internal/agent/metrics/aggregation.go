@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// function of gauge samples collected over report window,
+// reported as gauge with suffix, like HeapAlloc.max
+type Aggregation string
+
+const (
+	AggregationLast Aggregation = "last"
+	AggregationMin  Aggregation = "min"
+	AggregationMax  Aggregation = "max"
+	AggregationMean Aggregation = "mean"
+	AggregationP95  Aggregation = "p95"
+)
+
+// aggregations of every gauge, "*" for all gauges not listed
+type Aggregations map[string][]Aggregation
+
+const AllGauges = "*"
+
+func ParseAggregation(s string) (Aggregation, error) {
+	switch a := Aggregation(s); a {
+	case AggregationLast, AggregationMin, AggregationMax,
+		AggregationMean, AggregationP95:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown aggregation %q", s)
+	}
+}
+
+func (a Aggregation) Name(gauge string) string {
+	return gauge + "." + string(a)
+}
+
+// samples are in order of polling, must be non-empty
+func (a Aggregation) Apply(samples []models.GaugeValue) models.GaugeValue {
+	switch a {
+	case AggregationMin:
+		return slices.Min(samples)
+	case AggregationMax:
+		return slices.Max(samples)
+	case AggregationMean:
+		var sum models.GaugeValue
+		for _, s := range samples {
+			sum += s
+		}
+		return sum / models.GaugeValue(len(samples))
+	case AggregationP95:
+		// nearest-rank percentile
+		sorted := slices.Clone(samples)
+		slices.Sort(sorted)
+		rank := int(math.Ceil(0.95 * float64(len(sorted))))
+		return sorted[max(rank, 1)-1]
+	default:
+		return samples[len(samples)-1]
+	}
+}
+
+func (a Aggregations) For(gauge string) []Aggregation {
+	if aggregations, exists := a[gauge]; exists {
+		return aggregations
+	}
+	return a[AllGauges]
+}
+
+// suffixed aggregated gauges from samples
+func (a Aggregations) Apply(samples map[string][]models.GaugeValue) models.GaugesMap {
+	gauges := make(models.GaugesMap)
+	for name, values := range samples {
+		if len(values) == 0 {
+			continue
+		}
+		for _, aggregation := range a.For(name) {
+			gauges[aggregation.Name(name)] = aggregation.Apply(values)
+		}
+	}
+	return gauges
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestAggregationApply(t *testing.T) {
+	samples := []models.GaugeValue{3, 1, 4, 1, 5, 9, 2, 6, 5, 3,
+		5, 8, 9, 7, 9, 3, 2, 3, 8, 4}
+
+	assert.Equal(t, models.GaugeValue(4), AggregationLast.Apply(samples))
+	assert.Equal(t, models.GaugeValue(1), AggregationMin.Apply(samples))
+	assert.Equal(t, models.GaugeValue(9), AggregationMax.Apply(samples))
+	assert.InDelta(t, 4.85, float64(AggregationMean.Apply(samples)), 1e-9)
+	assert.Equal(t, models.GaugeValue(9), AggregationP95.Apply(samples))
+	assert.Equal(t, models.GaugeValue(2), AggregationP95.Apply([]models.GaugeValue{2}))
+
+	// samples are not reordered
+	assert.Equal(t, models.GaugeValue(3), samples[0])
+}
+
+func TestAggregationsApply(t *testing.T) {
+	aggregations := Aggregations{
+		"HeapAlloc": {AggregationMax, AggregationMin},
+		AllGauges:   {AggregationMean},
+	}
+	gauges := aggregations.Apply(map[string][]models.GaugeValue{
+		"HeapAlloc": {1, 3, 2},
+		"Alloc":     {1, 2},
+	})
+	assert.Equal(t, models.GaugesMap{
+		"HeapAlloc.max": 3,
+		"HeapAlloc.min": 1,
+		"Alloc.mean":    1.5,
+	}, gauges)
+
+	_, err := ParseAggregation("median")
+	require.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package metrics
+
+// glob patterns of metrics names, where "*" matches
+// any sequence of characters and "?" any single one.
+// metric passes if it matches any of include patterns
+// (or include is empty) and none of exclude ones
+type Filter struct {
+	Include []string
+	Exclude []string
+}
+
+func (f *Filter) Match(name string) bool {
+	if len(f.Include) > 0 && !matchAny(f.Include, name) {
+		return false
+	}
+	return !matchAny(f.Exclude, name)
+}
+
+// remove metrics not passing filter
+func (f *Filter) Apply(m *Metrics) {
+	if len(f.Include) == 0 && len(f.Exclude) == 0 {
+		return
+	}
+	for name := range m.Gauges {
+		if !f.Match(name) {
+			delete(m.Gauges, name)
+		}
+	}
+	for name := range m.Counters {
+		if !f.Match(name) {
+			delete(m.Counters, name)
+		}
+	}
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// unlike path.Match, "*" matches "/" too,
+// metrics like DiskFree./home have slashes
+func match(pattern, name string) bool {
+	p, n := []rune(pattern), []rune(name)
+	// position of last star and name position matched by it
+	star, next := -1, 0
+	i, j := 0, 0
+	for j < len(n) {
+		switch {
+		case i < len(p) && p[i] == '*':
+			star, next = i, j
+			i++
+		case i < len(p) && (p[i] == '?' || p[i] == n[j]):
+			i++
+			j++
+		case star >= 0:
+			// let star match one more character
+			next++
+			i, j = star+1, next
+		default:
+			return false
+		}
+	}
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+	return i == len(p)
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestFilter(t *testing.T) {
+	f := Filter{
+		Include: []string{"Heap*", "Disk*", "PollCount"},
+		Exclude: []string{"*Idle", "Disk?ree./boot"},
+	}
+
+	m := Metrics{
+		Gauges: models.GaugesMap{
+			"HeapAlloc":       1,
+			"HeapIdle":        1,
+			"DiskFree./home":  1,
+			"DiskFree./boot":  1,
+			"CPUutilization1": 1,
+		},
+		Counters: models.CountersMap{
+			"PollCount": 1,
+			"Other":     1,
+		},
+	}
+	f.Apply(&m)
+	assert.Equal(t, models.GaugesMap{"HeapAlloc": 1, "DiskFree./home": 1}, m.Gauges)
+	assert.Equal(t, models.CountersMap{"PollCount": 1}, m.Counters)
+
+	assert.True(t, match("*", ""))
+	assert.True(t, match("a*b*c", "aXbYbZc"))
+	assert.False(t, match("a*b", "aXbY"))
+	assert.False(t, match("?", ""))
+}
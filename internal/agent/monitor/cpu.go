@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/mem"
+
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// per logical cpu gauges in percents,
+// named with 1-based cpu number, like CPUutilization1
+const (
+	CPUutilizationGauge = "CPUutilization"
+	CPUuserGauge        = "CPUuser"
+	CPUsystemGauge      = "CPUsystem"
+	CPUiowaitGauge      = "CPUiowait"
+)
+
+// memory and cpu utilization. cpu utilization is computed
+// from cpu times difference since previous call,
+// so the first call reports memory only
+func (m *SystemMonitor) GetGolangMetrics(ctx context.Context) (*metrics.Metrics, error) {
+	// virtual memory is RAM in gopsutil? why...
+	memstat, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("memory stat: %v", err)
+	}
+
+	times, err := cpu.TimesWithContext(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("cpu times: %v", err)
+	}
+
+	m.mu.Lock()
+	prev := m.cpuTimes
+	m.cpuTimes = times
+	m.mu.Unlock()
+
+	gauges := cpuGauges(prev, times)
+	gauges[TotalMemoryGauge] = models.GaugeValue(memstat.Total)
+	gauges[FreeMemoryGauge] = models.GaugeValue(memstat.Free)
+
+	return &metrics.Metrics{
+		Gauges: gauges,
+	}, nil
+}
+
+// utilization of every cpu between two measurements,
+// nothing if cpu count was changed
+func cpuGauges(prev, cur []cpu.TimesStat) models.GaugesMap {
+	gauges := make(models.GaugesMap, 4*len(cur)+2)
+	if len(prev) != len(cur) {
+		return gauges
+	}
+
+	for i := range cur {
+		total := cpuTotal(cur[i]) - cpuTotal(prev[i])
+		if total <= 0 {
+			// no ticks between polls
+			continue
+		}
+		percent := func(prev, cur float64) models.GaugeValue {
+			return models.GaugeValue(math.Min(100, math.Max(0, (cur-prev)/total*100)))
+		}
+		idle := percent(prev[i].Idle+prev[i].Iowait, cur[i].Idle+cur[i].Iowait)
+
+		n := i + 1
+		gauges[fmt.Sprintf("%s%d", CPUutilizationGauge, n)] = 100 - idle
+		gauges[fmt.Sprintf("%s%d", CPUuserGauge, n)] = percent(prev[i].User, cur[i].User)
+		gauges[fmt.Sprintf("%s%d", CPUsystemGauge, n)] = percent(prev[i].System, cur[i].System)
+		gauges[fmt.Sprintf("%s%d", CPUiowaitGauge, n)] = percent(prev[i].Iowait, cur[i].Iowait)
+	}
+	return gauges
+}
+
+func cpuTotal(t cpu.TimesStat) float64 {
+	total := t.Total()
+	if runtime.GOOS == "linux" {
+		// guest time is already included into user time
+		total -= t.Guest + t.GuestNice
+	}
+	return total
+}
@@ -5,10 +5,6 @@ import (
 	"math/rand/v2"
 	"reflect"
 	"runtime"
-	"time"
-
-	"github.com/shirou/gopsutil/v4/cpu"
-	"github.com/shirou/gopsutil/v4/mem"
 
 	"github.com/stepkareserva/obsermon/internal/agent/metrics"
 	"github.com/stepkareserva/obsermon/internal/models"
@@ -49,9 +45,8 @@ var (
 
 	PollCount = "PollCount"
 
-	TotalMemoryGauge     = "TotalMemory"
-	FreeMemoryGauge      = "FreeMemory"
-	CPUutilization1Gauge = "CPUutilization1"
+	TotalMemoryGauge = "TotalMemory"
+	FreeMemoryGauge  = "FreeMemory"
 )
 
 func GetRuntimeMetrics() (*metrics.Metrics, error) {
@@ -74,27 +69,6 @@ func GetRuntimeMetrics() (*metrics.Metrics, error) {
 	}, nil
 }
 
-func GetGolangMetrics() (*metrics.Metrics, error) {
-	// virtual memory is RAM in gopsutil? why...
-	memstat, err := mem.VirtualMemory()
-	if err != nil {
-		return nil, fmt.Errorf("memory stat: %v", err)
-	}
-
-	cpustat, err := cpu.Percent(100*time.Millisecond, false)
-	if err != nil {
-		return nil, fmt.Errorf("cpu utilization: %v", err)
-	}
-
-	return &metrics.Metrics{
-		Gauges: models.GaugesMap{
-			TotalMemoryGauge:     models.GaugeValue(memstat.Total),
-			FreeMemoryGauge:      models.GaugeValue(memstat.Free),
-			CPUutilization1Gauge: models.GaugeValue(cpustat[0]),
-		},
-	}, nil
-}
-
 func getRuntimeGauges() (models.GaugesMap, error) {
 	// get mem stats as map
 	var s runtime.MemStats
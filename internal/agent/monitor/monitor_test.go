@@ -1,9 +1,13 @@
 package monitor
 
 import (
+	"context"
 	"testing"
 
+	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
 )
 
 func TestGetRuntimeGauges(t *testing.T) {
@@ -17,3 +21,79 @@ func TestGetRuntimeMetrics(t *testing.T) {
 	require.Equal(t, 28, len(metrics.Gauges))
 	require.Equal(t, 1, len(metrics.Counters))
 }
+
+func TestDeltas(t *testing.T) {
+	d := deltas{prev: make(map[string]uint64)}
+
+	counters := models.CountersMap{}
+	d.update(counters, "a", 10)
+	require.Empty(t, counters)
+
+	d.update(counters, "a", 15)
+	require.Equal(t, models.CountersMap{"a": 5}, counters)
+
+	// counter reset
+	counters = models.CountersMap{}
+	d.update(counters, "a", 3)
+	require.Empty(t, counters)
+	d.update(counters, "a", 4)
+	require.Equal(t, models.CountersMap{"a": 1}, counters)
+}
+
+func TestGetSystemMetrics(t *testing.T) {
+	m := NewSystemMonitor()
+	_, err := m.GetNetMetrics(context.Background())
+	require.NoError(t, err)
+	metrics, err := m.GetNetMetrics(context.Background())
+	require.NoError(t, err)
+	for name := range metrics.Counters {
+		require.Contains(t, name, ".")
+	}
+
+	metrics, err = GetLoadMetrics(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, len(metrics.Gauges))
+}
+
+func TestCPUGauges(t *testing.T) {
+	prev := []cpu.TimesStat{
+		{CPU: "cpu0", User: 10, System: 5, Idle: 80, Iowait: 5},
+		{CPU: "cpu1", User: 10, System: 10, Idle: 80},
+	}
+	cur := []cpu.TimesStat{
+		{CPU: "cpu0", User: 30, System: 15, Idle: 140, Iowait: 15},
+		{CPU: "cpu1", User: 10, System: 10, Idle: 80},
+	}
+
+	require.Equal(t, models.GaugesMap{
+		"CPUutilization1": 30,
+		"CPUuser1":        20,
+		"CPUsystem1":      10,
+		"CPUiowait1":      10,
+	}, cpuGauges(prev, cur))
+
+	// cpu count changed
+	require.Empty(t, cpuGauges(prev[:1], cur))
+}
+
+func TestInstanceName(t *testing.T) {
+	tests := map[string]string{
+		"/":            "DiskFree._2F",
+		"/root":        "DiskFree._2Froot",
+		"/home":        "DiskFree._2Fhome",
+		"/var/lib":     "DiskFree._2Fvar_2Flib",
+		"/var_lib":     "DiskFree._2Fvar_5Flib",
+		"/mnt/my disk": "DiskFree._2Fmnt_2Fmy_20disk",
+		`C:\`:          "DiskFree.C_3A_5C",
+		"sda1":         "DiskFree.sda1",
+	}
+	names := make(map[string]struct{})
+	for instance, expected := range tests {
+		t.Run(instance, func(t *testing.T) {
+			require.Equal(t, expected, instanceName(DiskFreeGauge, instance))
+		})
+		names[instanceName(DiskFreeGauge, instance)] = struct{}{}
+	}
+	// different mountpoints never collide
+	require.Len(t, names, len(tests))
+}
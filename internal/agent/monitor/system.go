@@ -0,0 +1,172 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/net"
+
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// per-mountpoint, per-device and per-interface metrics
+// are named like DiskFree._2Fhome or NetBytesRecv.eth0
+const (
+	DiskTotalGauge       = "DiskTotal"
+	DiskFreeGauge        = "DiskFree"
+	DiskUsedGauge        = "DiskUsed"
+	DiskUsedPercentGauge = "DiskUsedPercent"
+
+	DiskReadBytesCounter  = "DiskReadBytes"
+	DiskWriteBytesCounter = "DiskWriteBytes"
+	DiskReadCountCounter  = "DiskReadCount"
+	DiskWriteCountCounter = "DiskWriteCount"
+
+	NetBytesSentCounter   = "NetBytesSent"
+	NetBytesRecvCounter   = "NetBytesRecv"
+	NetPacketsSentCounter = "NetPacketsSent"
+	NetPacketsRecvCounter = "NetPacketsRecv"
+
+	Load1Gauge  = "Load1"
+	Load5Gauge  = "Load5"
+	Load15Gauge = "Load15"
+)
+
+// instance is escaped to be usable in url path: bytes except of
+// ascii letters, digits, '.' and '-' become _XX hex codes, like
+// DiskFree._2Fvar_2Flib for /var/lib. the escaping is reversible,
+// so different instances never get the same name
+func instanceName(name, instance string) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+	sb.WriteByte('.')
+	for i := 0; i < len(instance); i++ {
+		c := instance[i]
+		if c == '.' || c == '-' || ('a' <= c && c <= 'z') ||
+			('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "_%02X", c)
+	}
+	return sb.String()
+}
+
+// system io counters and cpu times are cumulative,
+// but obsermon counters are increments, so monitor keeps
+// previous values and reports differences between polls
+type SystemMonitor struct {
+	mu       sync.Mutex
+	deltas   deltas
+	cpuTimes []cpu.TimesStat
+}
+
+func NewSystemMonitor() *SystemMonitor {
+	return &SystemMonitor{
+		deltas: deltas{prev: make(map[string]uint64)},
+	}
+}
+
+func (m *SystemMonitor) GetDiskMetrics(ctx context.Context) (*metrics.Metrics, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("disk partitions: %v", err)
+	}
+
+	result := metrics.New()
+	for _, p := range partitions {
+		// the same mountpoint may be listed several times
+		if _, exists := result.Gauges[instanceName(DiskTotalGauge, p.Mountpoint)]; exists {
+			continue
+		}
+		usage, err := disk.UsageWithContext(ctx, p.Mountpoint)
+		if err != nil {
+			// unaccessible mountpoint is not a reason
+			// to lose all the others
+			continue
+		}
+		result.Gauges[instanceName(DiskTotalGauge, p.Mountpoint)] = models.GaugeValue(usage.Total)
+		result.Gauges[instanceName(DiskFreeGauge, p.Mountpoint)] = models.GaugeValue(usage.Free)
+		result.Gauges[instanceName(DiskUsedGauge, p.Mountpoint)] = models.GaugeValue(usage.Used)
+		result.Gauges[instanceName(DiskUsedPercentGauge, p.Mountpoint)] = models.GaugeValue(usage.UsedPercent)
+	}
+
+	counters, err := disk.IOCountersWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("disk io counters: %v", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for device, c := range counters {
+		m.deltas.update(result.Counters, instanceName(DiskReadBytesCounter, device), c.ReadBytes)
+		m.deltas.update(result.Counters, instanceName(DiskWriteBytesCounter, device), c.WriteBytes)
+		m.deltas.update(result.Counters, instanceName(DiskReadCountCounter, device), c.ReadCount)
+		m.deltas.update(result.Counters, instanceName(DiskWriteCountCounter, device), c.WriteCount)
+	}
+
+	return &result, nil
+}
+
+func (m *SystemMonitor) GetNetMetrics(ctx context.Context) (*metrics.Metrics, error) {
+	counters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("net io counters: %v", err)
+	}
+
+	result := metrics.New()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range counters {
+		m.deltas.update(result.Counters, instanceName(NetBytesSentCounter, c.Name), c.BytesSent)
+		m.deltas.update(result.Counters, instanceName(NetBytesRecvCounter, c.Name), c.BytesRecv)
+		m.deltas.update(result.Counters, instanceName(NetPacketsSentCounter, c.Name), c.PacketsSent)
+		m.deltas.update(result.Counters, instanceName(NetPacketsRecvCounter, c.Name), c.PacketsRecv)
+	}
+
+	return &result, nil
+}
+
+func GetLoadMetrics(ctx context.Context) (*metrics.Metrics, error) {
+	avg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load average: %v", err)
+	}
+
+	return &metrics.Metrics{
+		Gauges: models.GaugesMap{
+			Load1Gauge:  models.GaugeValue(avg.Load1),
+			Load5Gauge:  models.GaugeValue(avg.Load5),
+			Load15Gauge: models.GaugeValue(avg.Load15),
+		},
+	}, nil
+}
+
+// previous values of cumulative counters
+type deltas struct {
+	prev map[string]uint64
+}
+
+// put increment since previous call into counters.
+// the first value is only remembered, and counter reset
+// (device reattached, overflow) starts counting again
+func (d *deltas) update(counters models.CountersMap, name string, value uint64) {
+	prev, exists := d.prev[name]
+	d.prev[name] = value
+	if !exists || value < prev {
+		return
+	}
+	delta := value - prev
+	if delta > math.MaxInt64 {
+		return
+	}
+	counters[name] = models.CounterValue(delta)
+}
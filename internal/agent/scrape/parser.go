@@ -0,0 +1,185 @@
+package scrape
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// prometheus text exposition format parser,
+// see https://prometheus.io/docs/instrumenting/exposition_formats/
+
+type SampleType string
+
+const (
+	SampleTypeCounter SampleType = "counter"
+	SampleTypeGauge   SampleType = "gauge"
+)
+
+type Sample struct {
+	Name   string
+	Type   SampleType
+	Value  float64
+	Labels models.Labels
+}
+
+// counters and gauges from exposition, untyped metrics are
+// treated as gauges, histograms and summaries are skipped
+func Parse(r io.Reader) ([]Sample, error) {
+	types := make(map[string]string)
+	var samples []Sample
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line[1:])
+			if len(fields) >= 3 && fields[0] == "TYPE" {
+				types[fields[1]] = fields[2]
+			}
+			continue
+		}
+
+		name, labels, value, err := parseSampleLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+
+		if isHistogramSeries(types, name) {
+			continue
+		}
+		var sampleType SampleType
+		switch types[name] {
+		case "counter":
+			sampleType = SampleTypeCounter
+		case "gauge", "untyped", "":
+			sampleType = SampleTypeGauge
+		default:
+			// summary quantiles
+			continue
+		}
+
+		samples = append(samples, Sample{
+			Name:   name,
+			Type:   sampleType,
+			Value:  value,
+			Labels: labels,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("exposition reading: %v", err)
+	}
+	return samples, nil
+}
+
+// histogram and summary series have suffixes,
+// so their names are not in types, check base
+func isHistogramSeries(types map[string]string, name string) bool {
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		base, found := strings.CutSuffix(name, suffix)
+		if !found {
+			continue
+		}
+		if t := types[base]; t == "histogram" || t == "summary" {
+			return true
+		}
+	}
+	return false
+}
+
+// name{label="value",...} value [timestamp]
+func parseSampleLine(line string) (string, models.Labels, float64, error) {
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return "", nil, 0, fmt.Errorf("invalid sample %q", line)
+	}
+	name, rest := line[:end], line[end:]
+
+	var labels models.Labels
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		labels, rest, err = parseLabels(rest[1:])
+		if err != nil {
+			return "", nil, 0, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 2 {
+		return "", nil, 0, fmt.Errorf("invalid sample %q", line)
+	}
+	value, err := parseValue(fields[0])
+	if err != nil {
+		return "", nil, 0, err
+	}
+	// timestamp is ignored, scrape time is used instead
+	return name, labels, value, nil
+}
+
+// labels after opening brace, returns rest after closing one
+func parseLabels(s string) (models.Labels, string, error) {
+	labels := make(models.Labels)
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("invalid labels")
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return nil, "", fmt.Errorf("label %s value is not quoted", key)
+		}
+
+		var sb strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] != '\\' || i+1 == len(s) {
+				sb.WriteByte(s[i])
+				continue
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			default:
+				sb.WriteByte(s[i])
+			}
+		}
+		if i == len(s) {
+			return nil, "", fmt.Errorf("label %s value is not terminated", key)
+		}
+		labels[key] = sb.String()
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		s = strings.TrimPrefix(s, ",")
+	}
+}
+
+func parseValue(s string) (float64, error) {
+	switch s {
+	case "+Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
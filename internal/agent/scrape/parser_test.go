@@ -0,0 +1,61 @@
+package scrape
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestParse(t *testing.T) {
+	exposition := `# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="400"}    3 1395066363000
+
+# TYPE temperature gauge
+temperature{room="a \"big\" one\\\n"} -1.5
+# untyped
+something_weird{problem="division by zero"} +Inf
+
+# TYPE latency histogram
+latency_bucket{le="0.1"} 5
+latency_bucket{le="+Inf"} 7
+latency_sum 1.5
+latency_count 7
+# TYPE rpc summary
+rpc{quantile="0.5"} 4773
+rpc_sum 1.7560473e+07
+rpc_count 2693
+`
+	samples, err := Parse(strings.NewReader(exposition))
+	require.NoError(t, err)
+	require.Len(t, samples, 4)
+
+	assert.Equal(t, Sample{Name: "http_requests_total", Type: SampleTypeCounter, Value: 1027,
+		Labels: models.Labels{"method": "post", "code": "200"}}, samples[0])
+	assert.Equal(t, Sample{Name: "http_requests_total", Type: SampleTypeCounter, Value: 3,
+		Labels: models.Labels{"method": "post", "code": "400"}}, samples[1])
+	assert.Equal(t, Sample{Name: "temperature", Type: SampleTypeGauge, Value: -1.5,
+		Labels: models.Labels{"room": "a \"big\" one\\\n"}}, samples[2])
+	assert.Equal(t, "something_weird", samples[3].Name)
+	assert.True(t, math.IsInf(samples[3].Value, 1))
+}
+
+func TestParseInvalid(t *testing.T) {
+	invalid := []string{
+		"metric",
+		"metric{label=\"value\" 1",
+		"metric{label=value} 1",
+		"metric one",
+		"metric 1 2 3",
+	}
+	for _, exposition := range invalid {
+		_, err := Parse(strings.NewReader(exposition))
+		assert.Error(t, err, exposition)
+	}
+}
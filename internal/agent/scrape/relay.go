@@ -0,0 +1,182 @@
+package scrape
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+const (
+	scrapeTimeout = 5 * time.Second
+	// added to every scraped series if absent, so the same
+	// metrics of different services are different series
+	instanceLabel = "instance"
+)
+
+type Updater interface {
+	BatchUpdate(counters models.CountersList, gauges models.GaugesList)
+}
+
+// scrapes local prometheus endpoints and relays
+// their counters and gauges to obsermon server
+type Relay struct {
+	targets  []string
+	interval time.Duration
+	updater  Updater
+	client   *http.Client
+
+	// previous counters values of every target, counters
+	// are cumulative in prometheus but additive in obsermon.
+	// replaced on every successful scrape of target, so series
+	// gone from target are forgotten, and kept while
+	// target is unavailable
+	prev map[string]map[string]int64
+}
+
+func New(targets []string, interval time.Duration, updater Updater) (*Relay, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid scrape interval %v", interval)
+	}
+	if updater == nil {
+		return nil, fmt.Errorf("updater not exists")
+	}
+	for _, target := range targets {
+		u, err := url.ParseRequestURI(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrape target: %v", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("invalid scrape target scheme %s", u.Scheme)
+		}
+	}
+	return &Relay{
+		targets:  targets,
+		interval: interval,
+		updater:  updater,
+		client:   &http.Client{Timeout: scrapeTimeout},
+		prev:     make(map[string]map[string]int64),
+	}, nil
+}
+
+func (r *Relay) Start(ctx context.Context) {
+	for {
+		timer := time.NewTimer(r.interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Println("Scrape relay stopped")
+			return
+		case <-timer.C:
+			r.Scrape(ctx)
+		}
+	}
+}
+
+// scrape all targets and send their metrics,
+// unavailable targets are skipped. not for concurrent use
+func (r *Relay) Scrape(ctx context.Context) {
+	var counters models.CountersList
+	var gauges models.GaugesList
+
+	for _, target := range r.targets {
+		samples, err := r.scrapeTarget(ctx, target)
+		if err != nil {
+			log.Printf("scrape %s: %v", target, err)
+			continue
+		}
+		c, g := r.convert(target, samples)
+		counters = append(counters, c...)
+		gauges = append(gauges, g...)
+	}
+
+	if len(counters) > 0 || len(gauges) > 0 {
+		r.updater.BatchUpdate(counters, gauges)
+	}
+}
+
+func (r *Relay) scrapeTarget(ctx context.Context, target string) ([]Sample, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status %d", resp.StatusCode)
+	}
+	return Parse(resp.Body)
+}
+
+func (r *Relay) convert(target string, samples []Sample) (models.CountersList, models.GaugesList) {
+	instance := target
+	if u, err := url.Parse(target); err == nil {
+		instance = u.Host
+	}
+	prev := r.prev[target]
+	cur := make(map[string]int64, len(prev))
+	r.prev[target] = cur
+
+	var counters models.CountersList
+	var gauges models.GaugesList
+	for _, s := range samples {
+		// not representable in json
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		labels := s.Labels
+		if labels == nil {
+			labels = make(models.Labels, 1)
+		}
+		if _, exists := labels[instanceLabel]; !exists {
+			labels[instanceLabel] = instance
+		}
+
+		switch s.Type {
+		case SampleTypeCounter:
+			if delta, ok := delta(prev, cur, models.SeriesKey(s.Name, labels), s.Value); ok {
+				counters = append(counters, models.Counter{
+					Name:   s.Name,
+					Value:  models.CounterValue(delta),
+					Labels: labels,
+				})
+			}
+		case SampleTypeGauge:
+			gauges = append(gauges, models.Gauge{
+				Name:   s.Name,
+				Value:  models.GaugeValue(s.Value),
+				Labels: labels,
+			})
+		}
+	}
+	return counters, gauges
+}
+
+// increment of counter since previous scrape, fractional
+// part is carried over to next scrapes. the first value
+// is only remembered into cur, and counter reset
+// starts counting again
+func delta(prev, cur map[string]int64, key string, value float64) (int64, bool) {
+	if value < 0 || value >= math.MaxInt64 {
+		return 0, false
+	}
+	v := int64(math.Floor(value))
+	p, exists := prev[key]
+	cur[key] = v
+	if !exists || v < p {
+		return 0, false
+	}
+	return v - p, true
+}
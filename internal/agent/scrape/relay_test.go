@@ -0,0 +1,83 @@
+package scrape
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+type updaterStub struct {
+	counters []models.CountersList
+	gauges   []models.GaugesList
+}
+
+func (u *updaterStub) BatchUpdate(counters models.CountersList, gauges models.GaugesList) {
+	u.counters = append(u.counters, counters)
+	u.gauges = append(u.gauges, gauges)
+}
+
+func TestRelay(t *testing.T) {
+	requests := 0.0
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests += 2.5
+		fmt.Fprintf(w, "# TYPE requests_total counter\nrequests_total %v\n", requests)
+		fmt.Fprintf(w, "# TYPE queue gauge\nqueue{instance=\"q\"} %v\n", requests*2)
+	}))
+	defer target.Close()
+	instance := strings.TrimPrefix(target.URL, "http://")
+
+	updater := &updaterStub{}
+	relay, err := New([]string{target.URL, "http://localhost:1/metrics"}, time.Second, updater)
+	require.NoError(t, err)
+
+	for range 3 {
+		relay.Scrape(context.Background())
+	}
+
+	// the first counter value is only remembered,
+	// then 5 - 2.5 and 7.5 - 5 with carried fractional part
+	require.Len(t, updater.counters, 3)
+	assert.Empty(t, updater.counters[0])
+	assert.Equal(t, models.CountersList{{Name: "requests_total", Value: 3,
+		Labels: models.Labels{"instance": instance}}}, updater.counters[1])
+	assert.Equal(t, models.CountersList{{Name: "requests_total", Value: 2,
+		Labels: models.Labels{"instance": instance}}}, updater.counters[2])
+	assert.Equal(t, models.GaugesList{{Name: "queue", Value: 15,
+		Labels: models.Labels{"instance": "q"}}}, updater.gauges[2])
+
+	_, err = New([]string{"ftp://localhost/metrics"}, time.Second, updater)
+	assert.Error(t, err)
+}
+
+func TestRelayForgetsGoneSeries(t *testing.T) {
+	scrapes := 0
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scrapes++
+		fmt.Fprintf(w, "# TYPE requests_total counter\n")
+		fmt.Fprintf(w, "requests_total{path=\"/%d\"} %d\n", scrapes, scrapes)
+	}))
+	defer target.Close()
+
+	updater := &updaterStub{}
+	relay, err := New([]string{target.URL}, time.Second, updater)
+	require.NoError(t, err)
+
+	for range 3 {
+		relay.Scrape(context.Background())
+	}
+
+	// every series is scraped once and only remembered
+	for _, counters := range updater.counters {
+		assert.Empty(t, counters)
+	}
+	require.Len(t, relay.prev[target.URL], 1)
+}
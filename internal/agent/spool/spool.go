@@ -0,0 +1,361 @@
+package spool
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// bounded on-disk fifo queue of undelivered metrics batches,
+// one file per batch, named by increasing sequence number.
+// counters of never sent batches are folded into the newest
+// batch, so oldest-first eviction mostly drops stale gauges.
+// batch gets idempotency key on the first sending attempt,
+// it's saved with batch and the batch is never changed then,
+// so server recognizes repeats after lost responses.
+type Spool struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	seq     uint64
+	batches []batchFile
+	// replay in progress, head batch is being sent without lock
+	replaying bool
+}
+
+type batchFile struct {
+	seq  uint64
+	size int64
+}
+
+// content of batch file
+type entry struct {
+	// idempotency key, empty if batch was never sent
+	Key     string         `json:"key,omitempty"`
+	Metrics models.Metrics `json:"metrics"`
+}
+
+const (
+	batchExt = ".json"
+	tempExt  = ".tmp"
+)
+
+var ErrBatchTooLarge = errors.New("batch is larger than spool max size")
+
+func Open(dir string, maxSize int64) (*Spool, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid spool max size %d", maxSize)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("spool dir creation: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("spool dir reading: %v", err)
+	}
+
+	s := Spool{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, tempExt) {
+			// unfinished write, batch is still in previous file
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return nil, fmt.Errorf("spool temp file removing: %v", err)
+			}
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, batchExt), 10, 64)
+		if err != nil || !strings.HasSuffix(name, batchExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("spool file info: %v", err)
+		}
+		s.batches = append(s.batches, batchFile{seq: seq, size: info.Size()})
+		s.seq = max(s.seq, seq)
+	}
+	sort.Slice(s.batches, func(i, j int) bool {
+		return s.batches[i].seq < s.batches[j].seq
+	})
+
+	return &s, nil
+}
+
+// count of spooled batches
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.batches)
+}
+
+// total size of spooled batches, in bytes
+func (s *Spool) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size()
+}
+
+// append batch to the end of queue and evict oldest batches
+// while spool is larger than max size. key is idempotency key
+// the batch was already sent with, empty for never sent batch.
+// counters of the previous batch are moved into never sent batch
+// if previous one was never sent too.
+// returns count of evicted batches.
+func (s *Spool) Push(key string, batch models.Metrics) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	var prev entry
+	var prevFile *batchFile
+	if len(s.batches) > 0 && key == "" {
+		prevFile = &s.batches[len(s.batches)-1]
+		var err error
+		if prev, err = s.read(prevFile.seq); err != nil || prev.Key != "" {
+			// previous batch is broken, keep it as is
+			// and let replay deal with it. sent batch
+			// may be already applied by server
+			prev, prevFile = entry{}, nil
+		}
+	}
+
+	rest, counters := splitCounters(prev.Metrics)
+	merged := mergeCounters(counters, batch)
+
+	// write new batch first: crash between two writes
+	// means double counting instead of losing counters
+	size, err := s.write(s.seq+1, entry{Key: key, Metrics: merged})
+	if err != nil {
+		return 0, err
+	}
+	if size > s.maxSize {
+		_ = os.Remove(s.path(s.seq + 1))
+		return 0, ErrBatchTooLarge
+	}
+	s.seq++
+	if prevFile != nil && len(counters) > 0 {
+		if err := s.rewrite(prevFile, rest); err != nil {
+			return 0, err
+		}
+	}
+	s.batches = append(s.batches, batchFile{seq: s.seq, size: size})
+
+	return s.evict()
+}
+
+// send spooled batches with their idempotency keys, oldest first,
+// removing every sent batch. stops on first send error and returns it,
+// unsent batches stay in spool. unreadable batches are dropped.
+// batches are sent without lock, so push is not blocked
+// by slow server; concurrent replay returns at once
+// and leaves new batches to the running one.
+func (s *Spool) Replay(send func(key string, batch models.Metrics) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.replaying {
+		return nil
+	}
+	s.replaying = true
+	defer func() { s.replaying = false }()
+
+	for len(s.batches) > 0 {
+		b := s.batches[0]
+		e, err := s.read(b.seq)
+		if err == nil && e.Key == "" {
+			if err := s.assignKey(&s.batches[0], &e); err != nil {
+				return err
+			}
+		}
+		if err == nil {
+			s.mu.Unlock()
+			err = send(e.Key, e.Metrics)
+			s.mu.Lock()
+			if err != nil {
+				return err
+			}
+		}
+		// head may be evicted while sending
+		if len(s.batches) == 0 || s.batches[0].seq != b.seq {
+			continue
+		}
+		if err := os.Remove(s.path(b.seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("spool file removing: %v", err)
+		}
+		s.batches = s.batches[1:]
+	}
+	return nil
+}
+
+// key is saved before the first sending attempt,
+// so the same key is used after agent restart
+func (s *Spool) assignKey(b *batchFile, e *entry) error {
+	key, err := newKey()
+	if err != nil {
+		return fmt.Errorf("idempotency key generation: %v", err)
+	}
+	e.Key = key
+	size, err := s.write(b.seq, *e)
+	if err != nil {
+		return err
+	}
+	b.size = size
+	return nil
+}
+
+func newKey() (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+func (s *Spool) evict() (int, error) {
+	evicted := 0
+	for s.size() > s.maxSize && len(s.batches) > 0 {
+		if err := os.Remove(s.path(s.batches[0].seq)); err != nil && !os.IsNotExist(err) {
+			return evicted, fmt.Errorf("spool file removing: %v", err)
+		}
+		s.batches = s.batches[1:]
+		evicted++
+	}
+	return evicted, nil
+}
+
+func (s *Spool) size() int64 {
+	var size int64
+	for _, b := range s.batches {
+		size += b.size
+	}
+	return size
+}
+
+func (s *Spool) rewrite(b *batchFile, batch models.Metrics) error {
+	if len(batch) == 0 {
+		if err := os.Remove(s.path(b.seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("spool file removing: %v", err)
+		}
+		s.batches = s.batches[:len(s.batches)-1]
+		return nil
+	}
+	size, err := s.write(b.seq, entry{Metrics: batch})
+	if err != nil {
+		return err
+	}
+	b.size = size
+	return nil
+}
+
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, batchExt))
+}
+
+func (s *Spool) read(seq uint64) (entry, error) {
+	data, err := os.ReadFile(s.path(seq))
+	if err != nil {
+		return entry{}, fmt.Errorf("spool file reading: %v", err)
+	}
+	var e entry
+	// files of previous versions are bare metrics lists
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err = json.Unmarshal(data, &e.Metrics)
+	} else {
+		err = json.Unmarshal(data, &e)
+	}
+	if err != nil {
+		return entry{}, fmt.Errorf("spool file decoding: %v", err)
+	}
+	return e, nil
+}
+
+// write via temp file and rename, so batch file
+// is either old or new but never half-written
+func (s *Spool) write(seq uint64, e entry) (int64, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return 0, fmt.Errorf("spool batch encoding: %v", err)
+	}
+	path := s.path(seq)
+	tmp := path + tempExt
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, fmt.Errorf("spool file creation: %v", err)
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return 0, fmt.Errorf("spool file writing: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return 0, fmt.Errorf("spool file renaming: %v", err)
+	}
+	return int64(len(data)), nil
+}
+
+func splitCounters(batch models.Metrics) (rest models.Metrics, counters models.Metrics) {
+	for _, m := range batch {
+		if m.MType == models.MetricTypeCounter && m.Delta != nil {
+			counters = append(counters, m)
+		} else {
+			rest = append(rest, m)
+		}
+	}
+	return rest, counters
+}
+
+// sum counters with the same series into one metric,
+// counters on overflow stay separate
+func mergeCounters(counters models.Metrics, batch models.Metrics) models.Metrics {
+	merged := make(models.Metrics, 0, len(counters)+len(batch))
+	index := make(map[string]int)
+	for _, m := range append(counters, batch...) {
+		if m.MType != models.MetricTypeCounter || m.Delta == nil {
+			merged = append(merged, m)
+			continue
+		}
+		key := models.SeriesKey(m.ID, m.Labels)
+		if i, exists := index[key]; exists {
+			sum := *merged[i].Delta
+			if err := sum.Update(*m.Delta); err == nil {
+				merged[i].Delta = &sum
+				continue
+			}
+		}
+		delta := *m.Delta
+		m.Delta = &delta
+		index[key] = len(merged)
+		merged = append(merged, m)
+	}
+	return merged
+}
@@ -0,0 +1,222 @@
+package spool
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func counter(name string, v models.CounterValue) models.Metric {
+	return models.CounterMetric(models.Counter{Name: name, Value: v})
+}
+
+func gauge(name string, v models.GaugeValue) models.Metric {
+	return models.GaugeMetric(models.Gauge{Name: name, Value: v})
+}
+
+func replayAll(t *testing.T, s *Spool) []models.Metrics {
+	var batches []models.Metrics
+	require.NoError(t, s.Replay(func(_ string, batch models.Metrics) error {
+		batches = append(batches, batch)
+		return nil
+	}))
+	return batches
+}
+
+func TestSpoolMergesCounters(t *testing.T) {
+	s, err := Open(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+
+	_, err = s.Push("", models.Metrics{counter("PollCount", 1), gauge("Alloc", 1)})
+	require.NoError(t, err)
+	_, err = s.Push("", models.Metrics{counter("PollCount", 2), counter("Other", 5)})
+	require.NoError(t, err)
+	_, err = s.Push("", models.Metrics{counter("PollCount", 3), gauge("Alloc", 3)})
+	require.NoError(t, err)
+
+	// second batch consists of counters only and is merged away
+	assert.Equal(t, 2, s.Len())
+
+	batches := replayAll(t, s)
+	require.Len(t, batches, 2)
+	assert.Equal(t, models.Metrics{gauge("Alloc", 1)}, batches[0])
+	assert.Equal(t, models.Metrics{counter("PollCount", 6), counter("Other", 5),
+		gauge("Alloc", 3)}, batches[1])
+	assert.Equal(t, 0, s.Len())
+	assert.Zero(t, s.Size())
+}
+
+func TestSpoolReplayStopsOnError(t *testing.T) {
+	s, err := Open(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+
+	for i := range 3 {
+		_, err = s.Push("", models.Metrics{gauge("Alloc", models.GaugeValue(i))})
+		require.NoError(t, err)
+	}
+
+	sent := 0
+	errUnavailable := errors.New("unavailable")
+	err = s.Replay(func(_ string, batch models.Metrics) error {
+		if sent == 1 {
+			return errUnavailable
+		}
+		sent++
+		return nil
+	})
+	require.ErrorIs(t, err, errUnavailable)
+	assert.Equal(t, 2, s.Len())
+
+	batches := replayAll(t, s)
+	assert.Equal(t, []models.Metrics{
+		{gauge("Alloc", 1)},
+		{gauge("Alloc", 2)},
+	}, batches)
+}
+
+func TestSpoolEvictsOldest(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 1<<20)
+	require.NoError(t, err)
+	_, err = s.Push("", models.Metrics{gauge("Alloc", 0)})
+	require.NoError(t, err)
+	batchSize := s.Size()
+
+	// room for three batches only
+	s, err = Open(dir, 3*batchSize)
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Len())
+
+	evictedTotal := 0
+	for i := 1; i < 5; i++ {
+		evicted, err := s.Push("", models.Metrics{gauge("Alloc", models.GaugeValue(i))})
+		require.NoError(t, err)
+		evictedTotal += evicted
+	}
+	assert.Equal(t, 2, evictedTotal)
+	assert.Equal(t, 3, s.Len())
+	assert.LessOrEqual(t, s.Size(), 3*batchSize)
+
+	batches := replayAll(t, s)
+	assert.Equal(t, []models.Metrics{
+		{gauge("Alloc", 2)},
+		{gauge("Alloc", 3)},
+		{gauge("Alloc", 4)},
+	}, batches)
+
+	_, err = s.Push("", models.Metrics{gauge("Alloc", 1), gauge("HeapAlloc", 1),
+		gauge("StackInuse", 1), gauge("Sys", 1)})
+	require.ErrorIs(t, err, ErrBatchTooLarge)
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestSpoolReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 1<<20)
+	require.NoError(t, err)
+	_, err = s.Push("", models.Metrics{gauge("Alloc", 1)})
+	require.NoError(t, err)
+	_, err = s.Push("", models.Metrics{gauge("Alloc", 2)})
+	require.NoError(t, err)
+
+	// leftover of interrupted write
+	require.NoError(t, os.WriteFile(s.path(3)+tempExt, []byte("[{"), 0o644))
+
+	s, err = Open(dir, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Len())
+	_, err = s.Push("", models.Metrics{gauge("Alloc", 3)})
+	require.NoError(t, err)
+
+	batches := replayAll(t, s)
+	assert.Equal(t, []models.Metrics{
+		{gauge("Alloc", 1)},
+		{gauge("Alloc", 2)},
+		{gauge("Alloc", 3)},
+	}, batches)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSpoolPushDuringReplay(t *testing.T) {
+	s, err := Open(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+
+	_, err = s.Push("", models.Metrics{counter("PollCount", 1)})
+	require.NoError(t, err)
+
+	var sent []models.Metrics
+	require.NoError(t, s.Replay(func(_ string, batch models.Metrics) error {
+		if len(sent) == 0 {
+			// spool is not locked while sending, and
+			// counters of sending batch are not moved
+			_, err := s.Push("", models.Metrics{counter("PollCount", 2)})
+			require.NoError(t, err)
+			assert.NoError(t, s.Replay(func(string, models.Metrics) error {
+				t.Error("concurrent replay sends batch")
+				return nil
+			}))
+		}
+		sent = append(sent, batch)
+		return nil
+	}))
+
+	assert.Equal(t, []models.Metrics{
+		{counter("PollCount", 1)},
+		{counter("PollCount", 2)},
+	}, sent)
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestSpoolKeepsKeys(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 1<<20)
+	require.NoError(t, err)
+
+	// the first batch was already sent, its counters stay in it
+	_, err = s.Push("sent", models.Metrics{counter("PollCount", 1)})
+	require.NoError(t, err)
+	_, err = s.Push("", models.Metrics{counter("PollCount", 2)})
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Len())
+
+	errUnavailable := errors.New("unavailable")
+	var keys []string
+	send := func(key string, batch models.Metrics) error {
+		keys = append(keys, key)
+		if len(keys) == 2 {
+			return errUnavailable
+		}
+		return nil
+	}
+	require.ErrorIs(t, s.Replay(send), errUnavailable)
+
+	// key of failed batch is saved and it is not merged with new ones
+	s, err = Open(dir, 1<<20)
+	require.NoError(t, err)
+	_, err = s.Push("", models.Metrics{counter("PollCount", 3)})
+	require.NoError(t, err)
+	var batches []models.Metrics
+	require.NoError(t, s.Replay(func(key string, batch models.Metrics) error {
+		keys = append(keys, key)
+		batches = append(batches, batch)
+		return nil
+	}))
+
+	require.Len(t, keys, 4)
+	assert.Equal(t, "sent", keys[0])
+	assert.NotEmpty(t, keys[1])
+	assert.Equal(t, keys[1], keys[2])
+	assert.NotEqual(t, keys[2], keys[3])
+	assert.Equal(t, []models.Metrics{
+		{counter("PollCount", 2)},
+		{counter("PollCount", 3)},
+	}, batches)
+}
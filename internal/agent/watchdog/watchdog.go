@@ -7,19 +7,34 @@ import (
 	"sync"
 	"time"
 
-	"github.com/stepkareserva/obsermon/internal/agent/client"
+	"github.com/stepkareserva/obsermon/internal/agent/collector"
 	"github.com/stepkareserva/obsermon/internal/agent/metrics"
-	"github.com/stepkareserva/obsermon/internal/agent/monitor"
+	"github.com/stepkareserva/obsermon/internal/models"
 )
 
+type MetricsUpdater interface {
+	BatchUpdate(counters models.CountersList, gauges models.GaugesList)
+}
+
 type WatchdogParams struct {
-	MetricsServerClient *client.MetricsClient
-	PollInterval        time.Duration
-	ReportInterval      time.Duration
+	// usually *client.MetricsClient or client.MetricsClients
+	MetricsServerClient MetricsUpdater
+	// enabled collectors are polled, every one
+	// in its own goroutine with its own interval
+	Collectors *collector.Registry
+	// poll interval of collectors without own one
+	PollInterval   time.Duration
+	ReportInterval time.Duration
+	// gauges aggregated over report window, optional.
+	// last value is reported without suffix anyway
+	Aggregations metrics.Aggregations
+	// metrics not passing filter are not reported, optional
+	Filter metrics.Filter
 }
 
 type Watchdog struct {
-	params WatchdogParams
+	params     WatchdogParams
+	collectors []collector.Entry
 
 	metrics chan metrics.Metrics
 }
@@ -31,13 +46,25 @@ func New(params WatchdogParams) (*Watchdog, error) {
 	if params.ReportInterval <= 0 {
 		return nil, fmt.Errorf("invalid metrics report interval")
 	}
+	if params.Collectors == nil {
+		return nil, fmt.Errorf("collectors registry not exists")
+	}
 
-	chanCapacity := params.ReportInterval/params.PollInterval + 1
+	// enough to keep all polled metrics between reports
+	collectors := params.Collectors.Enabled()
+	var chanCapacity time.Duration
+	for i, c := range collectors {
+		if c.Interval <= 0 {
+			collectors[i].Interval = params.PollInterval
+		}
+		chanCapacity += params.ReportInterval/collectors[i].Interval + 1
+	}
 	metrics := make(chan metrics.Metrics, chanCapacity)
 
 	watchdog := Watchdog{
-		params:  params,
-		metrics: metrics,
+		params:     params,
+		collectors: collectors,
+		metrics:    metrics,
 	}
 
 	return &watchdog, nil
@@ -52,17 +79,14 @@ func (w *Watchdog) Close() {
 
 func (w *Watchdog) Start(ctx context.Context) {
 	var wg sync.WaitGroup
-	wg.Add(3)
-
-	go func() {
-		defer wg.Done()
-		w.runtimeMetricsPoller(ctx)
-	}()
+	wg.Add(len(w.collectors) + 1)
 
-	go func() {
-		defer wg.Done()
-		w.golangMetricsPoller(ctx)
-	}()
+	for _, c := range w.collectors {
+		go func() {
+			defer wg.Done()
+			w.metricsPoller(ctx, c)
+		}()
+	}
 
 	go func() {
 		defer wg.Done()
@@ -72,43 +96,26 @@ func (w *Watchdog) Start(ctx context.Context) {
 	wg.Wait()
 }
 
-func (w *Watchdog) runtimeMetricsPoller(ctx context.Context) {
+func (w *Watchdog) metricsPoller(ctx context.Context, c collector.Entry) {
+	name := c.Collector.Name()
 	for {
-		timer := time.NewTimer(w.params.PollInterval)
+		timer := time.NewTimer(c.Interval)
 		defer timer.Stop()
 
 		select {
 		case <-ctx.Done():
-			log.Println("Metrics updater stopped")
+			log.Printf("Metrics collector %s stopped", name)
 			return
 		case <-timer.C:
-			m, err := monitor.GetRuntimeMetrics()
+			m, err := c.Collector.Collect(ctx)
 			if err != nil {
 				// just skip because of what else shall we do
-				log.Printf("Get Runtime Metrics error: %v", err)
-			} else {
-				w.metrics <- *m
+				log.Printf("Collector %s error: %v", name, err)
+				continue
 			}
-		}
-	}
-}
-
-func (w *Watchdog) golangMetricsPoller(ctx context.Context) {
-	for {
-		timer := time.NewTimer(w.params.PollInterval)
-		defer timer.Stop()
-
-		select {
-		case <-ctx.Done():
-			log.Println("Metrics updater stopped")
-			return
-		case <-timer.C:
-			m, err := monitor.GetGolangMetrics()
-			if err != nil {
-				// just skip because of what else shall we do
-				log.Printf("Get Golang Metrics error: %v", err)
-			} else {
-				w.metrics <- *m
+			select {
+			case w.metrics <- *m:
+			case <-ctx.Done():
 			}
 		}
 	}
@@ -132,6 +139,7 @@ func (w *Watchdog) metricsReporter(ctx context.Context) {
 
 func (w *Watchdog) processPolledMetrics() metrics.Metrics {
 	metrics := metrics.New()
+	samples := make(map[string][]models.GaugeValue)
 
 	for {
 		select {
@@ -140,13 +148,20 @@ func (w *Watchdog) processPolledMetrics() metrics.Metrics {
 				// just skip because of what else shall we do
 				log.Printf("metrics.Update error: %v", err)
 			}
+			for name, value := range m.Gauges {
+				if len(w.params.Aggregations.For(name)) > 0 {
+					samples[name] = append(samples[name], value)
+				}
+			}
 		default:
+			metrics.Gauges.Update(w.params.Aggregations.Apply(samples))
 			return metrics
 		}
 	}
 }
 
 func (w *Watchdog) sendMetrics(metrics metrics.Metrics) {
+	w.params.Filter.Apply(&metrics)
 	w.params.MetricsServerClient.BatchUpdate(
 		metrics.Counters.List(),
 		metrics.Gauges.List())
@@ -8,6 +8,9 @@ import (
 	"time"
 
 	"github.com/stepkareserva/obsermon/internal/agent/client"
+	"github.com/stepkareserva/obsermon/internal/agent/collector"
+	"github.com/stepkareserva/obsermon/internal/agent/metrics"
+	"github.com/stepkareserva/obsermon/internal/models"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,7 +29,7 @@ func TestWatchdog(t *testing.T) {
 	defer mockServer.Close()
 
 	// mock server client
-	metricsClient, err := client.New(mockServer.URL, "", 1)
+	metricsClient, err := client.New(mockServer.URL, "", 1, client.TLSConfig{})
 	require.NoError(t, err)
 	defer metricsClient.Close()
 
@@ -35,6 +38,7 @@ func TestWatchdog(t *testing.T) {
 		PollInterval:        pollInterval,
 		ReportInterval:      reportInterval,
 		MetricsServerClient: metricsClient,
+		Collectors:          collector.Builtin(),
 	}
 
 	runningTime := reportInterval + 100*time.Millisecond
@@ -50,3 +54,32 @@ func TestWatchdog(t *testing.T) {
 	_, exists := incomingRequests[expectedURLPath]
 	require.True(t, exists)
 }
+
+func TestProcessPolledMetricsAggregation(t *testing.T) {
+	watchdog, err := New(WatchdogParams{
+		PollInterval:   time.Second,
+		ReportInterval: 10 * time.Second,
+		Collectors:     collector.NewRegistry(),
+		Aggregations: metrics.Aggregations{
+			"HeapAlloc": {metrics.AggregationMax, metrics.AggregationMean},
+		},
+	})
+	require.NoError(t, err)
+	watchdog.metrics = make(chan metrics.Metrics, 3)
+
+	for _, v := range []models.GaugeValue{2, 10, 3} {
+		watchdog.metrics <- metrics.Metrics{
+			Gauges:   models.GaugesMap{"HeapAlloc": v, "Alloc": v},
+			Counters: models.CountersMap{"PollCount": 1},
+		}
+	}
+
+	polled := watchdog.processPolledMetrics()
+	require.Equal(t, models.GaugesMap{
+		"HeapAlloc":      3,
+		"HeapAlloc.max":  10,
+		"HeapAlloc.mean": 5,
+		"Alloc":          3,
+	}, polled.Gauges)
+	require.Equal(t, models.CountersMap{"PollCount": 3}, polled.Counters)
+}
@@ -0,0 +1,160 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// request header with scheme of encrypted body
+const (
+	Header = "X-Encryption"
+	Scheme = "rsa-oaep-aes256-gcm"
+)
+
+// random AES key is encrypted by RSA-OAEP with SHA-256,
+// body is encrypted by the AES key in GCM mode, so data
+// of any size may be encrypted by public key. message is
+// key length (2 bytes, big endian), encrypted key, nonce
+// and sealed data.
+const aesKeySize = 32
+
+var oaepLabel = []byte("obsermon")
+
+var ErrInvalidMessage = errors.New("invalid encrypted message")
+
+func Encrypt(key *rsa.PublicKey, data []byte) ([]byte, error) {
+	aesKey := make([]byte, aesKeySize)
+	if _, err := rand.Read(aesKey); err != nil {
+		return nil, fmt.Errorf("aes key generation: %v", err)
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, aesKey, oaepLabel)
+	if err != nil {
+		return nil, fmt.Errorf("aes key encryption: %v", err)
+	}
+
+	gcm, err := newGCM(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("nonce generation: %v", err)
+	}
+
+	msg := make([]byte, 2, 2+len(encryptedKey)+len(nonce)+len(data)+gcm.Overhead())
+	binary.BigEndian.PutUint16(msg, uint16(len(encryptedKey)))
+	msg = append(msg, encryptedKey...)
+	msg = append(msg, nonce...)
+	return gcm.Seal(msg, nonce, data, nil), nil
+}
+
+func Decrypt(key *rsa.PrivateKey, msg []byte) ([]byte, error) {
+	if len(msg) < 2 {
+		return nil, ErrInvalidMessage
+	}
+	keyLen := int(binary.BigEndian.Uint16(msg))
+	msg = msg[2:]
+	if len(msg) < keyLen {
+		return nil, ErrInvalidMessage
+	}
+
+	aesKey, err := rsa.DecryptOAEP(sha256.New(), nil, key, msg[:keyLen], oaepLabel)
+	if err != nil {
+		return nil, fmt.Errorf("%w: aes key decryption: %v", ErrInvalidMessage, err)
+	}
+	msg = msg[keyLen:]
+
+	gcm, err := newGCM(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg) < gcm.NonceSize() {
+		return nil, ErrInvalidMessage
+	}
+	data, err := gcm.Open(nil, msg[:gcm.NonceSize()], msg[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	return data, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes cipher creation: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("gcm creation: %v", err)
+	}
+	return gcm, nil
+}
+
+// PEM encoded PKIX ("PUBLIC KEY") or PKCS#1 ("RSA PUBLIC KEY") key
+func LoadPublicKey(path string) (*rsa.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not RSA public key", path)
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("%s: unexpected PEM block %q", path, block.Type)
+	}
+}
+
+// PEM encoded PKCS#8 ("PRIVATE KEY") or PKCS#1 ("RSA PRIVATE KEY") key
+func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not RSA private key", path)
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("%s: unexpected PEM block %q", path, block.Type)
+	}
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("key file reading: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: PEM block not found", path)
+	}
+	return block, nil
+}
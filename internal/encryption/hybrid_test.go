@@ -0,0 +1,86 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, blockType string, data []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	content := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data})
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	// data is much larger than RSA key
+	data := make([]byte, 64<<10)
+	_, err = rand.Read(data)
+	require.NoError(t, err)
+
+	msg, err := Encrypt(&key.PublicKey, data)
+	require.NoError(t, err)
+	decrypted, err := Decrypt(key, msg)
+	require.NoError(t, err)
+	assert.Equal(t, data, decrypted)
+
+	t.Run("tampered message", func(t *testing.T) {
+		tampered := append([]byte(nil), msg...)
+		tampered[len(tampered)-1] ^= 1
+		_, err := Decrypt(key, tampered)
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+	})
+
+	t.Run("truncated message", func(t *testing.T) {
+		_, err := Decrypt(key, msg[:100])
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+	})
+
+	t.Run("another key", func(t *testing.T) {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		_, err = Decrypt(other, msg)
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+	})
+}
+
+func TestLoadKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	for _, path := range []string{
+		writePEM(t, "PRIVATE KEY", pkcs8),
+		writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)),
+	} {
+		loaded, err := LoadPrivateKey(path)
+		require.NoError(t, err)
+		assert.True(t, key.Equal(loaded))
+	}
+	for _, path := range []string{
+		writePEM(t, "PUBLIC KEY", pkix),
+		writePEM(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&key.PublicKey)),
+	} {
+		loaded, err := LoadPublicKey(path)
+		require.NoError(t, err)
+		assert.True(t, key.PublicKey.Equal(loaded))
+	}
+
+	_, err = LoadPublicKey(writePEM(t, "PRIVATE KEY", pkcs8))
+	assert.Error(t, err)
+}
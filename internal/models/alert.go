@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type AlertState string
+
+const (
+	// condition is false
+	AlertInactive AlertState = "inactive"
+	// condition is true, but not long enough
+	AlertPending AlertState = "pending"
+	// condition is true for rule's duration
+	AlertFiring AlertState = "firing"
+	// condition became false after firing
+	AlertResolved AlertState = "resolved"
+)
+
+// current state of alerting rule
+type Alert struct {
+	Rule      string     `json:"rule"`
+	Metric    string     `json:"metric"`
+	Labels    Labels     `json:"labels,omitempty"`
+	Condition string     `json:"condition"`
+	State     AlertState `json:"state"`
+	// last evaluated metric value, nil if metric not exists
+	Value *float64 `json:"value,omitempty"`
+	// when condition became true
+	ActiveSince *time.Time `json:"active_since,omitempty"`
+	FiredAt     *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+}
+
+type AlertsList []Alert
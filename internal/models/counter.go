@@ -9,8 +9,13 @@ import (
 type CounterValue int64
 
 type Counter struct {
-	Name  string
-	Value CounterValue
+	Name   string
+	Value  CounterValue
+	Labels Labels `json:",omitempty"`
+}
+
+func (c *Counter) Key() string {
+	return SeriesKey(c.Name, c.Labels)
 }
 
 // Q: maybe implement encoding.TextMarshaler/Unmarshaler?
@@ -9,8 +9,13 @@ import (
 type GaugeValue float64
 
 type Gauge struct {
-	Name  string
-	Value GaugeValue
+	Name   string
+	Value  GaugeValue
+	Labels Labels `json:",omitempty"`
+}
+
+func (g *Gauge) Key() string {
+	return SeriesKey(g.Name, g.Labels)
 }
 
 // Q: maybe implement encoding.TextMarshaler/Unmarshaler?
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type HistogramValue struct {
+	// upper bounds of buckets, strictly increasing,
+	// the last +Inf bucket is implicit
+	Bounds []float64 `json:"bounds"`
+	// count of observations in every bucket,
+	// not cumulative, len(Counts) == len(Bounds)+1
+	Counts []uint64 `json:"counts"`
+	// sum of all observations
+	Sum float64 `json:"sum"`
+	// count of all observations
+	Count uint64 `json:"count"`
+}
+
+type Histogram struct {
+	Name   string
+	Value  HistogramValue
+	Labels Labels `json:",omitempty"`
+}
+
+func (h *Histogram) Key() string {
+	return SeriesKey(h.Name, h.Labels)
+}
+
+func (h *HistogramValue) Validate() error {
+	for i, bound := range h.Bounds {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) {
+			return fmt.Errorf("invalid histogram bound %v", bound)
+		}
+		if i > 0 && h.Bounds[i-1] >= bound {
+			return fmt.Errorf("histogram bounds are not strictly increasing")
+		}
+	}
+	if len(h.Counts) != len(h.Bounds)+1 {
+		return fmt.Errorf("histogram has %d counts for %d bounds, expected %d",
+			len(h.Counts), len(h.Bounds), len(h.Bounds)+1)
+	}
+	var count uint64
+	for _, c := range h.Counts {
+		count += c
+	}
+	if count != h.Count {
+		return fmt.Errorf("histogram count %d is not equal to sum of bucket counts %d",
+			h.Count, count)
+	}
+	return nil
+}
+
+// merge observations of other histogram with the same bounds
+func (h *HistogramValue) Update(v HistogramValue) error {
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	if !slices.Equal(h.Bounds, v.Bounds) {
+		return HistogramBoundsError{a: h.Bounds, b: v.Bounds}
+	}
+	if h.Count > math.MaxUint64-v.Count {
+		return fmt.Errorf("histogram count overflow")
+	}
+
+	// don't modify slices in place, they may be shared
+	counts := make([]uint64, len(h.Counts))
+	for i := range counts {
+		counts[i] = h.Counts[i] + v.Counts[i]
+	}
+	h.Bounds = slices.Clone(h.Bounds)
+	h.Counts = counts
+	h.Sum += v.Sum
+	h.Count += v.Count
+	return nil
+}
+
+// ? maybe not here?
+func (h *HistogramValue) PrettyString() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "count %d, sum %s", h.Count, strconv.FormatFloat(h.Sum, 'g', 6, 64))
+	for i, count := range h.Counts {
+		if i < len(h.Bounds) {
+			fmt.Fprintf(&sb, ", ≤%s: %d", strconv.FormatFloat(h.Bounds[i], 'g', 6, 64), count)
+		} else {
+			fmt.Fprintf(&sb, ", +Inf: %d", count)
+		}
+	}
+	return sb.String()
+}
+
+type HistogramBoundsError struct {
+	a, b []float64
+}
+
+func (e HistogramBoundsError) Error() string {
+	return fmt.Sprintf("HistogramBoundsError: bounds %v and %v are different", e.a, e.b)
+}
+
+type HistogramsList []Histogram
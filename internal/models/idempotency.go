@@ -0,0 +1,40 @@
+package models
+
+import "context"
+
+// response remembered by Idempotency-Key, returned
+// for repeated requests instead of processing them again
+type IdempotentResponse struct {
+	// hash of request the response was made for,
+	// to detect key reusing for another request
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// response of request which was applied, but its response
+// wasn't saved, like on server crash between them
+func (r IdempotentResponse) Lost() bool {
+	return r.StatusCode == 0
+}
+
+// Idempotency-Key of request being processed. storages which
+// apply updates in transactions save it in the same transaction,
+// so repeat of applied request is recognized even if its
+// response is lost
+type IdempotencyKey struct {
+	Key         string
+	RequestHash string
+}
+
+type idempotencyKeyCtx struct{}
+
+func ContextWithIdempotencyKey(ctx context.Context, key IdempotencyKey) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+func IdempotencyKeyFromContext(ctx context.Context) (IdempotencyKey, bool) {
+	key, ok := ctx.Value(idempotencyKeyCtx{}).(IdempotencyKey)
+	return key, ok
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// optional metric's labels, like {"host":"a","env":"prod"}.
+// metrics with the same name but different labels
+// are different series.
+type Labels map[string]string
+
+// labels as {k1="v1",k2="v2"}, sorted by keys,
+// empty string for no labels. keys which are not
+// identifiers are quoted like values.
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, k := range l.Keys() {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		if isIdentifier(k) {
+			sb.WriteString(k)
+		} else {
+			sb.WriteString(strconv.Quote(k))
+		}
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(l[k]))
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func (l Labels) Keys() []string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// unique series identifier. name, labels keys and values
+// are quoted, so names with braces, quotes or commas
+// can't be confused with labels of other series.
+func SeriesKey(name string, labels Labels) string {
+	var sb strings.Builder
+	sb.WriteString(strconv.Quote(name))
+	for _, k := range labels.Keys() {
+		sb.WriteByte(',')
+		sb.WriteString(strconv.Quote(k))
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(labels[k]))
+	}
+	return sb.String()
+}
+
+// [a-zA-Z_][a-zA-Z0-9_]*
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesKey(t *testing.T) {
+	labeled := SeriesKey("x", Labels{"a": "1"})
+
+	// names and labels which look like labels of other series
+	assert.NotEqual(t, labeled, SeriesKey(`x{a="1"}`, nil))
+	assert.NotEqual(t, labeled, SeriesKey("x", Labels{`a="1",b`: "2"}))
+	assert.NotEqual(t, SeriesKey("x", Labels{"a": "1", "b": "2"}), SeriesKey("x", Labels{"a": `1",b="2`}))
+
+	// labels order doesn't matter
+	assert.Equal(t, SeriesKey("x", Labels{"a": "1", "b": "2"}), SeriesKey("x", Labels{"b": "2", "a": "1"}))
+	assert.Equal(t, SeriesKey("x", nil), SeriesKey("x", Labels{}))
+}
+
+func TestLabelsString(t *testing.T) {
+	assert.Equal(t, "", Labels{}.String())
+	assert.Equal(t, `{a="1",b="x\"y"}`, Labels{"b": `x"y`, "a": "1"}.String())
+	assert.Equal(t, `{"a=b"="1"}`, Labels{"a=b": "1"}.String())
+}
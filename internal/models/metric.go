@@ -5,34 +5,50 @@ import "fmt"
 type MetricType string
 
 const (
-	MetricTypeGauge   MetricType = "gauge"
-	MetricTypeCounter MetricType = "counter"
+	MetricTypeGauge     MetricType = "gauge"
+	MetricTypeCounter   MetricType = "counter"
+	MetricTypeHistogram MetricType = "histogram"
 )
 
 type Metric struct {
 	// metric's name
 	ID string `json:"id" validate:"required"`
-	// "gauge" or "counter"
-	MType MetricType `json:"type" validate:"required,oneof=gauge counter"`
+	// "gauge", "counter" or "histogram"
+	MType MetricType `json:"type" validate:"required,oneof=gauge counter histogram"`
 	// value if counter
 	Delta *CounterValue `json:"delta,omitempty"`
 	// value if gauge
 	Value *GaugeValue `json:"value,omitempty"`
+	// value if histogram
+	Histogram *HistogramValue `json:"histogram,omitempty"`
+	// optional labels, part of series identity
+	Labels Labels `json:"labels,omitempty"`
 }
 
 func CounterMetric(counter Counter) Metric {
 	return Metric{
-		ID:    counter.Name,
-		MType: MetricTypeCounter,
-		Delta: &counter.Value,
+		ID:     counter.Name,
+		MType:  MetricTypeCounter,
+		Delta:  &counter.Value,
+		Labels: counter.Labels,
 	}
 }
 
 func GaugeMetric(gauge Gauge) Metric {
 	return Metric{
-		ID:    gauge.Name,
-		MType: MetricTypeGauge,
-		Value: &gauge.Value,
+		ID:     gauge.Name,
+		MType:  MetricTypeGauge,
+		Value:  &gauge.Value,
+		Labels: gauge.Labels,
+	}
+}
+
+func HistogramMetric(histogram Histogram) Metric {
+	return Metric{
+		ID:        histogram.Name,
+		MType:     MetricTypeHistogram,
+		Histogram: &histogram.Value,
+		Labels:    histogram.Labels,
 	}
 }
 
@@ -44,8 +60,9 @@ func (m *Metric) Counter() (*Counter, error) {
 		return nil, fmt.Errorf("invalid metric value")
 	}
 	return &Counter{
-		Name:  m.ID,
-		Value: *m.Delta,
+		Name:   m.ID,
+		Value:  *m.Delta,
+		Labels: m.Labels,
 	}, nil
 }
 
@@ -57,7 +74,26 @@ func (m *Metric) Gauge() (*Gauge, error) {
 		return nil, fmt.Errorf("invalid metric value")
 	}
 	return &Gauge{
-		Name:  m.ID,
-		Value: *m.Value,
+		Name:   m.ID,
+		Value:  *m.Value,
+		Labels: m.Labels,
+	}, nil
+}
+
+// Histogram() is not possible, name is taken by field
+func (m *Metric) AsHistogram() (*Histogram, error) {
+	if m.MType != MetricTypeHistogram {
+		return nil, fmt.Errorf("invalid metric type")
+	}
+	if m.Histogram == nil {
+		return nil, fmt.Errorf("invalid metric value")
+	}
+	if err := m.Histogram.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid metric value: %v", err)
+	}
+	return &Histogram{
+		Name:   m.ID,
+		Value:  *m.Histogram,
+		Labels: m.Labels,
 	}, nil
 }
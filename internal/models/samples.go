@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// timestamped metrics values for metrics history
+
+type GaugeSample struct {
+	Time  time.Time  `json:"time"`
+	Value GaugeValue `json:"value"`
+}
+
+type GaugeSamples []GaugeSample
+
+type CounterSample struct {
+	Time  time.Time    `json:"time"`
+	Value CounterValue `json:"value"`
+}
+
+type CounterSamples []CounterSample
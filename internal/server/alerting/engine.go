@@ -0,0 +1,214 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"go.uber.org/zap"
+)
+
+// part of service.Storage used by rules
+type Storage interface {
+	FindGauge(ctx context.Context, name string, labels models.Labels) (*models.Gauge, bool, error)
+	FindCounter(ctx context.Context, name string, labels models.Labels) (*models.Counter, bool, error)
+}
+
+type Notifier interface {
+	Notify(n Notification)
+}
+
+// periodically evaluates rules against storage
+// and notifies about firing and resolved alerts
+type Engine struct {
+	storage  Storage
+	notifier Notifier
+	interval time.Duration
+	rules    []Rule
+	log      *zap.Logger
+	now      func() time.Time
+
+	mu     sync.RWMutex
+	alerts map[string]*models.Alert
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(cfg Config, storage Storage, notifier Notifier, log *zap.Logger) (*Engine, error) {
+	if storage == nil {
+		return nil, fmt.Errorf("storage not exists")
+	}
+	if notifier == nil {
+		return nil, fmt.Errorf("notifier not exists")
+	}
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+
+	alerts := make(map[string]*models.Alert, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		alerts[rule.Name] = &models.Alert{
+			Rule:      rule.Name,
+			Metric:    rule.Metric,
+			Labels:    rule.Labels,
+			Condition: rule.Condition(),
+			State:     models.AlertInactive,
+		}
+	}
+
+	return &Engine{
+		storage:  storage,
+		notifier: notifier,
+		interval: cfg.Interval(),
+		rules:    cfg.Rules,
+		log:      log,
+		now:      time.Now,
+		alerts:   alerts,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+func (e *Engine) Start() {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.done:
+				return
+			case <-ticker.C:
+				e.Evaluate(context.Background())
+			}
+		}
+	}()
+}
+
+func (e *Engine) Shutdown(ctx context.Context) error {
+	close(e.done)
+
+	stopped := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// take states of alerts from engine with previous rules,
+// so reloading doesn't reset pending and firing alerts.
+// alerts of changed rules start from scratch.
+// must be called before start, prev must be stopped
+func (e *Engine) Restore(prev *Engine) {
+	prevAlerts := prev.Alerts()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, old := range prevAlerts {
+		alert, exists := e.alerts[old.Rule]
+		// condition includes metric and labels
+		if !exists || alert.Condition != old.Condition {
+			continue
+		}
+		*alert = old
+	}
+}
+
+// current alerts state, sorted by rule name
+func (e *Engine) Alerts() models.AlertsList {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	alerts := make(models.AlertsList, 0, len(e.alerts))
+	for _, alert := range e.alerts {
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Rule < alerts[j].Rule
+	})
+	return alerts
+}
+
+// evaluate all rules once
+func (e *Engine) Evaluate(ctx context.Context) {
+	for _, rule := range e.rules {
+		value, exists, err := e.value(ctx, rule)
+		if err != nil {
+			// keep state, storage may be temporary unavailable
+			e.log.Error("alert rule evaluation", zap.String("rule", rule.Name), zap.Error(err))
+			continue
+		}
+		if n := e.update(rule, value, exists); n != nil {
+			e.notifier.Notify(*n)
+		}
+	}
+}
+
+func (e *Engine) value(ctx context.Context, rule Rule) (float64, bool, error) {
+	switch rule.Type {
+	case models.MetricTypeCounter:
+		counter, exists, err := e.storage.FindCounter(ctx, rule.Metric, rule.Labels)
+		if err != nil || !exists {
+			return 0, false, err
+		}
+		return float64(counter.Value), true, nil
+	default:
+		gauge, exists, err := e.storage.FindGauge(ctx, rule.Metric, rule.Labels)
+		if err != nil || !exists {
+			return 0, false, err
+		}
+		return float64(gauge.Value), true, nil
+	}
+}
+
+// update rule's alert state, returns notification if alert fired or resolved.
+// missing metric doesn't satisfy any condition.
+func (e *Engine) update(rule Rule, value float64, exists bool) *Notification {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	alert := e.alerts[rule.Name]
+	alert.Value = nil
+	if exists {
+		alert.Value = &value
+	}
+	active := exists && rule.Check(value)
+
+	switch {
+	case active && alert.ActiveSince == nil:
+		alert.State = models.AlertPending
+		alert.ActiveSince = &now
+		alert.FiredAt = nil
+		alert.ResolvedAt = nil
+	case !active && alert.State == models.AlertFiring:
+		alert.State = models.AlertResolved
+		alert.ActiveSince = nil
+		alert.ResolvedAt = &now
+		return newNotification(*alert)
+	case !active && alert.State == models.AlertPending:
+		alert.State = models.AlertInactive
+		alert.ActiveSince = nil
+	}
+
+	if alert.State == models.AlertPending && now.Sub(*alert.ActiveSince) >= rule.For() {
+		alert.State = models.AlertFiring
+		alert.FiredAt = &now
+		return newNotification(*alert)
+	}
+
+	return nil
+}
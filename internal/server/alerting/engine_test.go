@@ -0,0 +1,133 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/mocks"
+)
+
+type testNotifier struct {
+	notifications []Notification
+}
+
+func (n *testNotifier) Notify(notification Notification) {
+	n.notifications = append(n.notifications, notification)
+}
+
+func TestEngine(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mocks.NewMockStorage(ctrl)
+	notifier := &testNotifier{}
+	cfg := Config{
+		IntervalS: 1,
+		Rules: []Rule{{
+			Name:      "high cpu",
+			Metric:    "CPUutilization1",
+			Type:      models.MetricTypeGauge,
+			Op:        ">",
+			Threshold: 90,
+			ForS:      10,
+		}},
+	}
+	engine, err := New(cfg, mockStorage, notifier, nil)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+
+	evaluate := func(value float64, state models.AlertState) {
+		mockStorage.
+			EXPECT().
+			FindGauge(gomock.Any(), "CPUutilization1", gomock.Nil()).
+			Return(&models.Gauge{Name: "CPUutilization1", Value: models.GaugeValue(value)}, true, nil)
+		engine.Evaluate(context.TODO())
+		alerts := engine.Alerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, state, alerts[0].State)
+	}
+
+	evaluate(50, models.AlertInactive)
+	evaluate(95, models.AlertPending)
+	now = now.Add(5 * time.Second)
+	evaluate(95, models.AlertPending)
+	assert.Empty(t, notifier.notifications)
+	now = now.Add(5 * time.Second)
+	evaluate(95, models.AlertFiring)
+	require.Len(t, notifier.notifications, 1)
+	assert.Equal(t, models.AlertFiring, notifier.notifications[0].Status)
+	now = now.Add(5 * time.Second)
+	evaluate(95, models.AlertFiring)
+	evaluate(80, models.AlertResolved)
+	require.Len(t, notifier.notifications, 2)
+	assert.Equal(t, models.AlertResolved, notifier.notifications[1].Status)
+	evaluate(80, models.AlertResolved)
+	evaluate(95, models.AlertPending)
+	evaluate(80, models.AlertInactive)
+	assert.Len(t, notifier.notifications, 2)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	cfg := Config{
+		IntervalS: 0,
+		Webhooks:  []string{"ftp://localhost"},
+		Rules: []Rule{
+			{Name: "a", Metric: "m", Type: models.MetricTypeGauge, Op: ">"},
+			{Name: "a", Type: "histogram", Op: "=>", ForS: -1},
+		},
+	}
+	err := Validate(cfg)
+	require.Error(t, err)
+	for _, msg := range []string{"interval", "webhooks[0]", "rules[1]: duplicated name",
+		"rules[1]: empty metric", "rules[1]: invalid metric type",
+		"rules[1]: invalid op", "rules[1]: invalid for"} {
+		assert.Contains(t, err.Error(), msg)
+	}
+}
+
+func TestEngineRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mocks.NewMockStorage(ctrl)
+	rule := Rule{
+		Name:      "high cpu",
+		Metric:    "CPUutilization1",
+		Type:      models.MetricTypeGauge,
+		Op:        ">",
+		Threshold: 90,
+	}
+	changed := rule
+	changed.Name = "high memory"
+	changed.Metric = "HeapAlloc"
+	cfg := Config{IntervalS: 1, Rules: []Rule{rule, changed}}
+
+	prev, err := New(cfg, mockStorage, &testNotifier{}, nil)
+	require.NoError(t, err)
+	mockStorage.
+		EXPECT().
+		FindGauge(gomock.Any(), gomock.Any(), gomock.Nil()).
+		Return(&models.Gauge{Value: 95}, true, nil).
+		Times(2)
+	prev.Evaluate(context.TODO())
+
+	// the second rule is changed, its state is reset
+	changed.Threshold = 99
+	engine, err := New(Config{IntervalS: 1, Rules: []Rule{rule, changed}},
+		mockStorage, &testNotifier{}, nil)
+	require.NoError(t, err)
+	engine.Restore(prev)
+
+	alerts := engine.Alerts()
+	require.Len(t, alerts, 2)
+	assert.Equal(t, models.AlertFiring, alerts[0].State)
+	assert.Equal(t, models.AlertInactive, alerts[1].State)
+}
@@ -0,0 +1,136 @@
+package alerting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// default rules evaluation interval, s
+const defaultIntervalS = 10
+
+// rules file content, like
+//
+//	{
+//	  "interval": 10,
+//	  "webhooks": ["http://localhost:9000/alerts"],
+//	  "rules": [
+//	    {"name": "high cpu", "metric": "CPUutilization1", "op": ">", "threshold": 90, "for": 30},
+//	    {"name": "low memory", "metric": "FreeMemory", "op": "<", "threshold": 1e9}
+//	  ]
+//	}
+type Config struct {
+	// rules evaluation interval, s
+	IntervalS int `json:"interval"`
+	// urls to post notifications about firing and resolved alerts
+	Webhooks []string `json:"webhooks"`
+	Rules    []Rule   `json:"rules"`
+}
+
+type Rule struct {
+	Name   string            `json:"name"`
+	Metric string            `json:"metric"`
+	Type   models.MetricType `json:"type"`
+	Labels models.Labels     `json:"labels"`
+	// comparison operator, >, >=, <, <=, == or !=
+	Op        string  `json:"op"`
+	Threshold float64 `json:"threshold"`
+	// how long condition must be true to fire alert, s
+	ForS int `json:"for"`
+}
+
+func (c *Config) Interval() time.Duration {
+	return time.Duration(c.IntervalS) * time.Second
+}
+
+func (r *Rule) For() time.Duration {
+	return time.Duration(r.ForS) * time.Second
+}
+
+// condition as string, like CPUutilization1 > 90
+func (r *Rule) Condition() string {
+	return fmt.Sprintf("%s %s %v", r.Metric+r.Labels.String(), r.Op, r.Threshold)
+}
+
+func (r *Rule) Check(value float64) bool {
+	switch r.Op {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	case "<=":
+		return value <= r.Threshold
+	case "==":
+		return value == r.Threshold
+	case "!=":
+		return value != r.Threshold
+	default:
+		return false
+	}
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules file: %v", err)
+	}
+	cfg := Config{IntervalS: defaultIntervalS}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse rules file: %v", err)
+	}
+	for i := range cfg.Rules {
+		if cfg.Rules[i].Type == "" {
+			cfg.Rules[i].Type = models.MetricTypeGauge
+		}
+	}
+	if err := Validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid rules file: %v", err)
+	}
+	return &cfg, nil
+}
+
+func Validate(c Config) error {
+	var errs []error
+	if c.IntervalS <= 0 {
+		errs = append(errs, fmt.Errorf("invalid interval %d", c.IntervalS))
+	}
+	for i, webhook := range c.Webhooks {
+		u, err := url.ParseRequestURI(webhook)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhooks[%d]: %v", i, err))
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			errs = append(errs, fmt.Errorf("webhooks[%d]: invalid scheme %s", i, u.Scheme))
+		}
+	}
+	names := make(map[string]struct{}, len(c.Rules))
+	for i, rule := range c.Rules {
+		if rule.Name == "" {
+			errs = append(errs, fmt.Errorf("rules[%d]: empty name", i))
+		} else if _, exists := names[rule.Name]; exists {
+			errs = append(errs, fmt.Errorf("rules[%d]: duplicated name %q", i, rule.Name))
+		}
+		names[rule.Name] = struct{}{}
+		if rule.Metric == "" {
+			errs = append(errs, fmt.Errorf("rules[%d]: empty metric", i))
+		}
+		if rule.Type != models.MetricTypeGauge && rule.Type != models.MetricTypeCounter {
+			errs = append(errs, fmt.Errorf("rules[%d]: invalid metric type %q", i, rule.Type))
+		}
+		switch rule.Op {
+		case ">", ">=", "<", "<=", "==", "!=":
+		default:
+			errs = append(errs, fmt.Errorf("rules[%d]: invalid op %q", i, rule.Op))
+		}
+		if rule.ForS < 0 {
+			errs = append(errs, fmt.Errorf("rules[%d]: invalid for %d", i, rule.ForS))
+		}
+	}
+	return errors.Join(errs...)
+}
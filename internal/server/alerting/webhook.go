@@ -0,0 +1,149 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	webhookTimeout = 5 * time.Second
+	// pending notifications are delivered on close,
+	// but not longer than this
+	webhookCloseTimeout = 15 * time.Second
+)
+
+// webhook request body
+type Notification struct {
+	Status models.AlertState `json:"status"`
+	Alert  models.Alert      `json:"alert"`
+}
+
+func newNotification(alert models.Alert) *Notification {
+	return &Notification{
+		Status: alert.State,
+		Alert:  alert,
+	}
+}
+
+// posts notifications to webhooks in background with retries
+type WebhookNotifier struct {
+	urls   []string
+	client *http.Client
+	log    *zap.Logger
+	// delays before attempts
+	retries      []time.Duration
+	closeTimeout time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ Notifier = (*WebhookNotifier)(nil)
+
+func NewWebhookNotifier(urls []string, log *zap.Logger) *WebhookNotifier {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookNotifier{
+		urls:   urls,
+		client: &http.Client{Timeout: webhookTimeout},
+		log:    log,
+		retries: []time.Duration{
+			0 * time.Second,
+			1 * time.Second,
+			3 * time.Second,
+			5 * time.Second,
+		},
+		closeTimeout: webhookCloseTimeout,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+func (w *WebhookNotifier) Notify(n Notification) {
+	body, err := json.Marshal(n)
+	if err != nil {
+		w.log.Error("notification marshal", zap.Error(err))
+		return
+	}
+	for _, url := range w.urls {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			if err := w.post(url, body); err != nil {
+				w.log.Error("webhook notification",
+					zap.String("url", url),
+					zap.String("rule", n.Alert.Rule),
+					zap.Error(err))
+			}
+		}()
+	}
+}
+
+// wait for pending notifications, so resolved ones are not
+// lost on reload or shutdown. retries left after close
+// timeout are cancelled.
+func (w *WebhookNotifier) Close() {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(w.closeTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		w.log.Warn("pending webhook notifications are cancelled")
+	}
+	w.cancel()
+	<-done
+}
+
+func (w *WebhookNotifier) post(url string, body []byte) error {
+	var err error
+	for _, wait := range w.retries {
+		select {
+		case <-w.ctx.Done():
+			return fmt.Errorf("cancelled, last error: %v", err)
+		case <-time.After(wait):
+		}
+
+		err = w.postOnce(url, body)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (w *WebhookNotifier) postOnce(url string, body []byte) error {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %v", err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		return fmt.Errorf("close response body: %v", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("post status %d", resp.StatusCode)
+	}
+	return nil
+}
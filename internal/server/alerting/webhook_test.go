@@ -0,0 +1,73 @@
+package alerting
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan Notification, 1)
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// first attempt fails to check retry
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var n Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- n
+	}))
+	defer ts.Close()
+
+	notifier := NewWebhookNotifier([]string{ts.URL}, nil)
+	notifier.retries = []time.Duration{0, 10 * time.Millisecond}
+	defer notifier.Close()
+
+	notifier.Notify(*newNotification(models.Alert{Rule: "high cpu", State: models.AlertFiring}))
+
+	select {
+	case n := <-received:
+		assert.Equal(t, models.AlertFiring, n.Status)
+		assert.Equal(t, "high cpu", n.Alert.Rule)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "notification is not received")
+	}
+}
+
+func TestWebhookNotifierClose(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		// the first attempt fails, notification is pending on close
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	notifier := NewWebhookNotifier([]string{ts.URL}, nil)
+	notifier.retries = []time.Duration{0, 100 * time.Millisecond}
+	notifier.Notify(*newNotification(models.Alert{Rule: "high cpu", State: models.AlertResolved}))
+	notifier.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, attempts)
+}
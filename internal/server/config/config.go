@@ -1,19 +1,59 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"strings"
 	"time"
 )
 
+// json names are used in config file
 type Config struct {
-	Endpoint        string  `env:"ADDRESS"`
-	StoreIntervalS  int     `env:"STORE_INTERVAL"`
-	FileStoragePath string  `env:"FILE_STORAGE_PATH"`
-	Restore         bool    `env:"RESTORE"`
-	DBConnection    string  `env:"DATABASE_DSN"`
-	ReportSignKey   string  `env:"KEY"`
-	Mode            AppMode `env:"MODE"`
+	// path to json or yaml config file, optional
+	ConfigPath      string  `env:"CONFIG" json:"-"`
+	Endpoint        string  `env:"ADDRESS" json:"address"`
+	StatsDEndpoint  string  `env:"STATSD_ADDRESS" json:"statsd_address"`
+	StoreIntervalS  int     `env:"STORE_INTERVAL" json:"store_interval"`
+	FileStoragePath string  `env:"FILE_STORAGE_PATH" json:"file_storage_path"`
+	Restore         bool    `env:"RESTORE" json:"restore"`
+	UseWAL          bool    `env:"WAL" json:"wal"`
+	DBConnection    string  `env:"DATABASE_DSN" json:"database_dsn"`
+	ReportSignKey   string  `env:"KEY" json:"key"`
+	SignMaxSkewS    int     `env:"SIGN_MAX_SKEW" json:"sign_max_skew"`
+	SignLegacy      bool    `env:"SIGN_LEGACY" json:"sign_legacy"`
+	SignStrict      bool    `env:"SIGN_STRICT" json:"sign_strict"`
+	SignKeysPath    string  `env:"SIGN_KEYS" json:"sign_keys"`
+	CryptoKeyPath   string  `env:"CRYPTO_KEY" json:"crypto_key"`
+	TLSCertPath     string  `env:"TLS_CERT" json:"tls_cert"`
+	TLSKeyPath      string  `env:"TLS_KEY" json:"tls_key"`
+	TLSClientCAPath string  `env:"TLS_CLIENT_CA" json:"tls_client_ca"`
+	TrustedSubnet   string  `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
+	Mode            AppMode `env:"MODE" json:"mode"`
+	AlertRulesPath  string  `env:"ALERT_RULES" json:"alert_rules"`
 }
 
 func (c *Config) StoreInterval() time.Duration {
 	return time.Duration(c.StoreIntervalS) * time.Second
 }
+
+func (c *Config) SignMaxSkew() time.Duration {
+	return time.Duration(c.SignMaxSkewS) * time.Second
+}
+
+// comma-separated CIDRs, like "10.0.0.0/8,192.168.1.0/24",
+// empty to accept updates from any agent
+func (c *Config) TrustedSubnets() ([]*net.IPNet, error) {
+	var subnets []*net.IPNet
+	for _, cidr := range strings.Split(c.TrustedSubnet, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted subnet %s: %v", cidr, err)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
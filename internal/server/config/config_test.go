@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestReadFileParams(t *testing.T) {
+	yamlPath := writeFile(t, "server.yaml", `
+address: :9090
+store_interval: 60
+wal: true
+mode: dev
+alert_rules: /etc/obsermon/rules.json
+`)
+	jsonPath := writeFile(t, "server.json", `{
+		"address": ":9090",
+		"store_interval": 60,
+		"wal": true,
+		"mode": "dev",
+		"alert_rules": "/etc/obsermon/rules.json"
+	}`)
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			cfg := defaultConfig()
+			require.NoError(t, readFileParams(cfg, path))
+
+			assert.Equal(t, ":9090", cfg.Endpoint)
+			assert.Equal(t, time.Minute, cfg.StoreInterval())
+			assert.True(t, cfg.UseWAL)
+			assert.False(t, cfg.Restore)
+			assert.Equal(t, Dev, cfg.Mode)
+			assert.Equal(t, "/etc/obsermon/rules.json", cfg.AlertRulesPath)
+			require.NoError(t, Validate(*cfg))
+		})
+	}
+
+	cfg := defaultConfig()
+	assert.Error(t, readFileParams(cfg, writeFile(t, "server.json", `{"adress": "typo"}`)))
+	assert.Error(t, readFileParams(cfg, writeFile(t, "server.yaml", "store_interval: often")))
+	assert.Error(t, readFileParams(cfg, writeFile(t, "server.toml", "")))
+}
+
+func TestPrecedence(t *testing.T) {
+	path := writeFile(t, "server.json", `{
+		"address": ":9090",
+		"store_interval": 60,
+		"key": "file",
+		"mode": "dev"
+	}`)
+	t.Setenv("STORE_INTERVAL", "30")
+	t.Setenv("KEY", "env")
+	t.Setenv("CONFIG", "ignored.json")
+	args := []string{"-config", path, "-k", "flag"}
+
+	configFile := configPath(args)
+	require.Equal(t, path, configFile)
+
+	cfg := defaultConfig()
+	require.NoError(t, readFileParams(cfg, configFile))
+	require.NoError(t, readEnvParams(cfg))
+	require.NoError(t, readCLIParams(cfg, args))
+
+	assert.Equal(t, ":9090", cfg.Endpoint)
+	assert.Equal(t, 30, cfg.StoreIntervalS)
+	assert.Equal(t, "flag", cfg.ReportSignKey)
+	assert.Equal(t, Dev, cfg.Mode)
+}
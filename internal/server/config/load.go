@@ -1,34 +1,59 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/caarlos0/env/v6"
+	"gopkg.in/yaml.v3"
 )
 
+// precedence is defaults < config file < env < flags
 func LoadConfig() (*Config, error) {
 	cfg := defaultConfig()
-	if err := readCLIParams(cfg); err != nil {
-		return nil, err
+
+	if path := configPath(os.Args[1:]); path != "" {
+		if err := readFileParams(cfg, path); err != nil {
+			return nil, err
+		}
 	}
 	if err := readEnvParams(cfg); err != nil {
 		return nil, err
 	}
+	if err := readCLIParams(cfg, os.Args[1:]); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
 func defaultConfig() *Config {
 	return &Config{
 		Endpoint:        "localhost:8080",
+		StatsDEndpoint:  "",
 		StoreIntervalS:  300,
 		FileStoragePath: defaultStoragePath(),
 		Restore:         false,
+		UseWAL:          false,
 		DBConnection:    "",
 		ReportSignKey:   "",
+		SignMaxSkewS:    300,
+		SignLegacy:      false,
+		SignStrict:      false,
+		SignKeysPath:    "",
+		CryptoKeyPath:   "",
+		TLSCertPath:     "",
+		TLSKeyPath:      "",
+		TLSClientCAPath: "",
+		TrustedSubnet:   "",
 		Mode:            Prod,
+		AlertRulesPath:  "",
 	}
 }
 
@@ -55,12 +80,68 @@ func defaultStoragePath() string {
 	return path.Join(appDataDir, "storage.json")
 }
 
-func readCLIParams(c *Config) error {
-	fs := flag.NewFlagSet("", flag.ExitOnError)
+// config file path from flags or env
+func configPath(args []string) string {
+	var c Config
+	fs := newFlagSet(&c, flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	// errors will be reported on real flags parsing
+	if err := fs.Parse(args); err == nil && c.ConfigPath != "" {
+		return c.ConfigPath
+	}
+	return os.Getenv("CONFIG")
+}
+
+// json or yaml file depending on extension
+func readFileParams(c *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file reading: %v", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// yaml is converted to json to share
+		// unmarshalling and unknown fields checks
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("config file %s decoding: %v", path, err)
+		}
+		if v == nil {
+			return nil
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return fmt.Errorf("config file %s decoding: %v", path, err)
+		}
+	case ".json":
+	default:
+		return fmt.Errorf("config file %s: unsupported format, json or yaml expected", path)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("config file %s decoding: %v", path, err)
+	}
+	return nil
+}
+
+func readCLIParams(c *Config, args []string) error {
+	return newFlagSet(c, flag.ExitOnError).Parse(args)
+}
+
+func newFlagSet(c *Config, errorHandling flag.ErrorHandling) *flag.FlagSet {
+	fs := flag.NewFlagSet("", errorHandling)
+
+	fs.StringVar(&c.ConfigPath, "config", c.ConfigPath,
+		"path to json or yaml config file, flags and env take precedence over it")
 
 	fs.StringVar(&c.Endpoint, "a", c.Endpoint,
 		"server endpoint tcp address, like :8080, 127.0.0.1:80, localhost:22")
 
+	fs.StringVar(&c.StatsDEndpoint, "s", c.StatsDEndpoint,
+		"statsd udp listener address, like :8125, empty to disable")
+
 	fs.IntVar(&c.StoreIntervalS, "i", c.StoreIntervalS,
 		"server state storing interval, s, 0 for sync storing")
 
@@ -70,20 +151,49 @@ func readCLIParams(c *Config) error {
 	fs.BoolVar(&c.Restore, "r", c.Restore,
 		"restore server state from storage file")
 
+	fs.BoolVar(&c.UseWAL, "wal", c.UseWAL,
+		"use write-ahead log next to storage file, state file is stored as checkpoint")
+
 	fs.StringVar(&c.DBConnection, "d", c.DBConnection,
 		"database connection string")
 
 	fs.StringVar(&c.ReportSignKey, "k", c.ReportSignKey,
 		"reports and requests sing key, string")
 
+	fs.IntVar(&c.SignMaxSkewS, "sign-skew", c.SignMaxSkewS,
+		"max clock skew of signed requests timestamps, s")
+
+	fs.BoolVar(&c.SignLegacy, "sign-legacy", c.SignLegacy,
+		"accept legacy replayable signatures of body only")
+
+	fs.BoolVar(&c.SignStrict, "sign-strict", c.SignStrict,
+		"reject not signed modifying requests")
+
+	fs.StringVar(&c.SignKeysPath, "sign-keys", c.SignKeysPath,
+		"path to json file with per-agent sign keys, empty to use common key only")
+
+	fs.StringVar(&c.CryptoKeyPath, "crypto-key", c.CryptoKeyPath,
+		"path to PEM private RSA key to decrypt requests, empty to reject encrypted requests")
+
+	fs.StringVar(&c.TLSCertPath, "tls-cert", c.TLSCertPath,
+		"path to PEM tls certificate, empty to serve http")
+
+	fs.StringVar(&c.TLSKeyPath, "tls-key", c.TLSKeyPath,
+		"path to PEM tls certificate private key")
+
+	fs.StringVar(&c.TLSClientCAPath, "tls-client-ca", c.TLSClientCAPath,
+		"path to PEM CA certificates, clients certificates signed by them are required (mtls)")
+
+	fs.StringVar(&c.TrustedSubnet, "t", c.TrustedSubnet,
+		"comma-separated CIDRs of agents allowed to update metrics, empty to allow all")
+
 	fs.Var(&c.Mode, "m",
 		"app mode, quiet/dev/prod")
 
-	if err := fs.Parse(os.Args[1:]); err != nil {
-		return err
-	}
+	fs.StringVar(&c.AlertRulesPath, "alerts", c.AlertRulesPath,
+		"path to alerting rules json file, empty to disable alerting")
 
-	return nil
+	return fs
 }
 
 func readEnvParams(c *Config) error {
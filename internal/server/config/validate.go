@@ -9,9 +9,29 @@ func Validate(c Config) error {
 	if _, err := net.ResolveTCPAddr("tcp", c.Endpoint); err != nil {
 		return fmt.Errorf("invalid endpoint: %v", err)
 	}
+	if c.StatsDEndpoint != "" {
+		if _, err := net.ResolveUDPAddr("udp", c.StatsDEndpoint); err != nil {
+			return fmt.Errorf("invalid statsd endpoint: %v", err)
+		}
+	}
 	if c.StoreInterval() < 0 {
 		return fmt.Errorf("invalid poll interval %v", c.StoreInterval())
 	}
+	if c.SignMaxSkew() <= 0 {
+		return fmt.Errorf("invalid sign max skew %v", c.SignMaxSkew())
+	}
+	if c.SignStrict && c.ReportSignKey == "" && c.SignKeysPath == "" {
+		return fmt.Errorf("strict signing requires sign key or sign keys file")
+	}
+	if (c.TLSCertPath == "") != (c.TLSKeyPath == "") {
+		return fmt.Errorf("tls certificate and key must be passed together")
+	}
+	if c.TLSClientCAPath != "" && c.TLSCertPath == "" {
+		return fmt.Errorf("tls client ca requires tls certificate")
+	}
+	if _, err := c.TrustedSubnets(); err != nil {
+		return err
+	}
 	if !c.Mode.IsValid() {
 		return fmt.Errorf("invalid app mode %v", c.Mode)
 	}
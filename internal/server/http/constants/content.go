@@ -8,8 +8,19 @@ const (
 	ContentTypeHTMLU = "text/html; charset=utf-8"
 	ContentTypeJSON  = "application/json"
 	ContentTypeJSONU = "application/json; charset=utf-8"
+	ContentTypeSSE   = "text/event-stream"
+
+	// prometheus text exposition format
+	ContentTypePrometheus = "text/plain; version=0.0.4; charset=utf-8"
 
 	ContentEncoding = "Content-Encoding"
 	AcceptEncoding  = "Accept-Encoding"
 	GZipEncoding    = "gzip"
+
+	// repeated requests with the same key get the original response
+	IdempotencyKey     = "Idempotency-Key"
+	IdempotentReplayed = "Idempotent-Replayed"
+
+	// agent's ip, checked against trusted subnets
+	RealIP = "X-Real-IP"
 )
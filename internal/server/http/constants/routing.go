@@ -9,4 +9,11 @@ const (
 	ChiMetric = "metric"
 	ChiName   = "name"
 	ChiValue  = "value"
+
+	// names of url query params
+	QueryFrom = "from"
+	QueryTo   = "to"
+	QueryName = "name"
+	// json object like {"host":"a"}
+	QueryLabels = "labels"
 )
@@ -50,8 +50,90 @@ var (
 		Message:    "Database unavailable",
 	}
 
+	ErrInvalidTimeRange = HandlerError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Invalid time range",
+	}
+
+	ErrInvalidLabels = HandlerError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Invalid labels",
+	}
+
+	ErrInvalidLineProtocol = HandlerError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Invalid line protocol content",
+	}
+
 	ErrInvalidRequestSign = HandlerError{
 		StatusCode: http.StatusBadRequest,
 		Message:    "Invalid request sign",
 	}
+
+	ErrMissingRequestSign = HandlerError{
+		StatusCode: http.StatusUnauthorized,
+		Message:    "Request sign is required",
+	}
+
+	ErrUnknownSignKey = HandlerError{
+		StatusCode: http.StatusUnauthorized,
+		Message:    "Unknown or revoked request sign key",
+	}
+
+	ErrForeignSignKey = HandlerError{
+		StatusCode: http.StatusForbidden,
+		Message:    "Request sign key belongs to another agent",
+	}
+
+	ErrLegacyRequestSign = HandlerError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Request sign without timestamp and nonce is not accepted",
+	}
+
+	ErrStaleRequestSign = HandlerError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Request sign timestamp is out of allowed clock skew",
+	}
+
+	ErrReplayedRequest = HandlerError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Request sign nonce was already used",
+	}
+
+	// nonces are remembered for max skew, so server
+	// can't accept more signed requests for a while
+	ErrTooManySignedRequests = HandlerError{
+		StatusCode: http.StatusServiceUnavailable,
+		Message:    "Too many signed requests, retry later",
+	}
+
+	ErrUntrustedSubnet = HandlerError{
+		StatusCode: http.StatusForbidden,
+		Message:    "Request from untrusted subnet",
+	}
+
+	ErrUnsupportedEncryption = HandlerError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Request encryption scheme is not supported",
+	}
+
+	ErrRequestDecryption = HandlerError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Request decryption failed",
+	}
+
+	ErrInvalidIdempotencyKey = HandlerError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Invalid Idempotency-Key header",
+	}
+
+	ErrIdempotencyKeyInUse = HandlerError{
+		StatusCode: http.StatusConflict,
+		Message:    "Request with the same Idempotency-Key is in progress",
+	}
+
+	ErrIdempotencyKeyReused = HandlerError{
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    "Idempotency-Key was used for another request",
+	}
 )
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/stepkareserva/obsermon/internal/server/http/constants"
+	"github.com/stepkareserva/obsermon/internal/server/http/errors"
+	"go.uber.org/zap"
+)
+
+type AlertsHandler struct {
+	service Service
+	errors.ErrorsWriter
+}
+
+func NewAlertsHandler(s Service, log *zap.Logger) (*AlertsHandler, error) {
+	if s == nil {
+		return nil, fmt.Errorf("service not exists")
+	}
+	return &AlertsHandler{
+		service:      s,
+		ErrorsWriter: errors.NewErrorsWriter(log),
+	}, nil
+}
+
+func (h *AlertsHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		alerts, err := h.service.ListAlerts(r.Context())
+		if err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set(constants.ContentType, constants.ContentTypeJSON)
+		if err = json.NewEncoder(w).Encode(alerts); err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/http/constants"
+	"github.com/stepkareserva/obsermon/internal/server/http/errors"
+)
+
+type HistoryHandler struct {
+	service Service
+	errors.ErrorsWriter
+}
+
+func NewHistoryHandler(s Service, log *zap.Logger) (*HistoryHandler, error) {
+	if s == nil {
+		return nil, fmt.Errorf("service not exists")
+	}
+	return &HistoryHandler{
+		service:      s,
+		ErrorsWriter: errors.NewErrorsWriter(log),
+	}, nil
+}
+
+func (h *HistoryHandler) GaugeHistoryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			h.WriteError(w, errors.ErrInvalidTimeRange, err.Error())
+			return
+		}
+		labels, err := parseLabels(r)
+		if err != nil {
+			h.WriteError(w, errors.ErrInvalidLabels, err.Error())
+			return
+		}
+		name := chi.URLParam(r, constants.ChiName)
+		samples, err := h.service.GaugeHistory(r.Context(), name, labels, from, to)
+		if err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set(constants.ContentType, constants.ContentTypeJSON)
+		if err = json.NewEncoder(w).Encode(samples); err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+	}
+}
+
+func (h *HistoryHandler) CounterHistoryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, to, err := parseTimeRange(r)
+		if err != nil {
+			h.WriteError(w, errors.ErrInvalidTimeRange, err.Error())
+			return
+		}
+		labels, err := parseLabels(r)
+		if err != nil {
+			h.WriteError(w, errors.ErrInvalidLabels, err.Error())
+			return
+		}
+		name := chi.URLParam(r, constants.ChiName)
+		samples, err := h.service.CounterHistory(r.Context(), name, labels, from, to)
+		if err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set(constants.ContentType, constants.ContentTypeJSON)
+		if err = json.NewEncoder(w).Encode(samples); err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+	}
+}
+
+func (h *HistoryHandler) UnknownMetricHistoryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.WriteError(w, errors.ErrInvalidMetricType, chi.URLParam(r, constants.ChiMetric))
+	}
+}
+
+// from and to are optional, whole history by default
+func parseTimeRange(r *http.Request) (from, to time.Time, err error) {
+	from = time.Unix(0, 0)
+	to = time.Now()
+
+	query := r.URL.Query()
+	if s := query.Get(constants.QueryFrom); s != "" {
+		if from, err = parseTime(s); err != nil {
+			return from, to, fmt.Errorf("invalid from: %v", err)
+		}
+	}
+	if s := query.Get(constants.QueryTo); s != "" {
+		if to, err = parseTime(s); err != nil {
+			return from, to, fmt.Errorf("invalid to: %v", err)
+		}
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("to is before from")
+	}
+	return from, to, nil
+}
+
+// labels of series, optional, series without labels by default
+func parseLabels(r *http.Request) (models.Labels, error) {
+	s := r.URL.Query().Get(constants.QueryLabels)
+	if s == "" {
+		return nil, nil
+	}
+	var labels models.Labels
+	if err := json.Unmarshal([]byte(s), &labels); err != nil {
+		return nil, fmt.Errorf("labels decoding: %v", err)
+	}
+	return labels, nil
+}
+
+// time as RFC3339 or unix timestamp in seconds
+func parseTime(s string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
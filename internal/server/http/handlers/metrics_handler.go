@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/http/constants"
+	"github.com/stepkareserva/obsermon/internal/server/http/errors"
+	"go.uber.org/zap"
+)
+
+// handler for prometheus scrapping,
+// see https://prometheus.io/docs/instrumenting/exposition_formats/
+type MetricsHandler struct {
+	service Service
+	errors.ErrorsWriter
+}
+
+func NewMetricsHandler(s Service, log *zap.Logger) (*MetricsHandler, error) {
+	if s == nil {
+		return nil, fmt.Errorf("service not exists")
+	}
+	return &MetricsHandler{
+		service:      s,
+		ErrorsWriter: errors.NewErrorsWriter(log),
+	}, nil
+}
+
+func (h *MetricsHandler) PrometheusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gauges, err := h.service.ListGauges(r.Context())
+		if err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+
+		counters, err := h.service.ListCounters(r.Context())
+		if err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+
+		histograms, err := h.service.ListHistograms(r.Context())
+		if err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+
+		var buf bytes.Buffer
+		writePrometheusMetrics(&buf, gauges, counters, histograms)
+
+		w.Header().Set(constants.ContentType, constants.ContentTypePrometheus)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+	}
+}
+
+func writePrometheusMetrics(buf *bytes.Buffer, gauges models.GaugesList,
+	counters models.CountersList, histograms models.HistogramsList) {
+	// prometheus forbids the same metric family with different types,
+	// but our gauges and counters have separate namespaces
+	// and sanitizing may also glue different names together,
+	// so first come first served.
+	families := make(map[string]string, len(gauges)+len(counters)+len(histograms))
+	writeType := func(name, mtype string) bool {
+		if family, exists := families[name]; exists {
+			return family == mtype
+		}
+		families[name] = mtype
+		fmt.Fprintf(buf, "# TYPE %s %s\n", name, mtype)
+		return true
+	}
+
+	for _, gauge := range gauges {
+		name := prometheusName(gauge.Name, true)
+		if writeType(name, "gauge") {
+			fmt.Fprintf(buf, "%s%s %s\n", name, prometheusLabels(gauge.Labels),
+				prometheusFloat(float64(gauge.Value)))
+		}
+	}
+	for _, counter := range counters {
+		name := prometheusName(counter.Name, true)
+		if writeType(name, "counter") {
+			fmt.Fprintf(buf, "%s%s %d\n", name, prometheusLabels(counter.Labels),
+				counter.Value)
+		}
+	}
+	for _, histogram := range histograms {
+		name := prometheusName(histogram.Name, true)
+		if writeType(name, "histogram") {
+			writePrometheusHistogram(buf, name, histogram)
+		}
+	}
+}
+
+// prometheus histogram buckets are cumulative
+// and has the same labels plus "le" upper bound
+func writePrometheusHistogram(buf *bytes.Buffer, name string, histogram models.Histogram) {
+	bucketLabels := make(models.Labels, len(histogram.Labels)+1)
+	for k, v := range histogram.Labels {
+		bucketLabels[k] = v
+	}
+
+	var cumulative uint64
+	for i, count := range histogram.Value.Counts {
+		cumulative += count
+		if i < len(histogram.Value.Bounds) {
+			bucketLabels["le"] = prometheusFloat(histogram.Value.Bounds[i])
+		} else {
+			bucketLabels["le"] = "+Inf"
+		}
+		fmt.Fprintf(buf, "%s_bucket%s %d\n", name, prometheusLabels(bucketLabels), cumulative)
+	}
+
+	labels := prometheusLabels(histogram.Labels)
+	fmt.Fprintf(buf, "%s_sum%s %s\n", name, labels, prometheusFloat(histogram.Value.Sum))
+	fmt.Fprintf(buf, "%s_count%s %d\n", name, labels, histogram.Value.Count)
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func prometheusLabels(labels models.Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, k := range labels.Keys() {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(prometheusName(k, false))
+		sb.WriteString(`="`)
+		sb.WriteString(labelValueReplacer.Replace(labels[k]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// metric name must match [a-zA-Z_:][a-zA-Z0-9_:]*,
+// label name must match [a-zA-Z_][a-zA-Z0-9_]*,
+// replace all other symbols to underscore
+func prometheusName(name string, colonAllowed bool) string {
+	var sb strings.Builder
+	sb.Grow(len(name) + 1)
+	for i, c := range name {
+		switch {
+		case c == '_' || (c == ':' && colonAllowed),
+			'a' <= c && c <= 'z',
+			'A' <= c && c <= 'Z':
+			sb.WriteRune(c)
+		case '0' <= c && c <= '9':
+			if i == 0 {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(c)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	if sb.Len() == 0 {
+		return "_"
+	}
+	return sb.String()
+}
+
+func prometheusFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"time"
 
 	"github.com/stepkareserva/obsermon/internal/models"
 )
@@ -18,12 +19,39 @@ type CountersService interface {
 	ListCounters(ctx context.Context) (models.CountersList, error)
 }
 
+type HistogramsService interface {
+	UpdateHistogram(ctx context.Context, val models.Histogram) (*models.Histogram, error)
+	FindHistogram(ctx context.Context, name string) (*models.Histogram, bool, error)
+	ListHistograms(ctx context.Context) (models.HistogramsList, error)
+}
+
 type MetricsService interface {
 	UpdateMetric(ctx context.Context, val models.Metric) (*models.Metric, error)
-	FindMetric(ctx context.Context, t models.MetricType, name string) (*models.Metric, bool, error)
+	FindMetric(ctx context.Context, t models.MetricType, name string, labels models.Labels) (*models.Metric, bool, error)
 	UpdateMetrics(ctx context.Context, vals models.Metrics) (models.Metrics, error)
 }
 
+type HistoryService interface {
+	GaugeHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.GaugeSamples, error)
+	CounterHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.CounterSamples, error)
+}
+
+type StreamService interface {
+	// updated metrics with passed names (all if empty) until ctx
+	// is done, channel is closed if subscriber is too slow
+	Subscribe(ctx context.Context, names []string) (<-chan models.Metric, error)
+}
+
+type AlertsService interface {
+	ListAlerts(ctx context.Context) (models.AlertsList, error)
+}
+
+// responses of requests with Idempotency-Key
+type IdempotencyService interface {
+	FindResponse(ctx context.Context, key string) (*models.IdempotentResponse, bool, error)
+	SaveResponse(ctx context.Context, key string, resp models.IdempotentResponse) error
+}
+
 type PingableService interface {
 	Ping(ctx context.Context) error
 }
@@ -33,6 +61,11 @@ type PingableService interface {
 type Service interface {
 	GaugesService
 	CountersService
+	HistogramsService
 	MetricsService
+	HistoryService
+	StreamService
+	AlertsService
+	IdempotencyService
 	PingableService
 }
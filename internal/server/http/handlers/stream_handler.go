@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/server/http/constants"
+	"github.com/stepkareserva/obsermon/internal/server/http/errors"
+	"go.uber.org/zap"
+)
+
+// comment line to keep idle connection alive
+// through proxies, browsers ignore it
+const streamKeepAlive = 15 * time.Second
+
+// server-sent events stream of updated metrics,
+// see https://html.spec.whatwg.org/multipage/server-sent-events.html
+type StreamHandler struct {
+	service Service
+	log     *zap.Logger
+	errors.ErrorsWriter
+}
+
+func NewStreamHandler(s Service, log *zap.Logger) (*StreamHandler, error) {
+	if s == nil {
+		return nil, fmt.Errorf("service not exists")
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &StreamHandler{
+		service:      s,
+		log:          log,
+		ErrorsWriter: errors.NewErrorsWriter(log),
+	}, nil
+}
+
+func (h *StreamHandler) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			h.WriteError(w, errors.ErrInternalServerError, "streaming is not supported")
+			return
+		}
+
+		names := r.URL.Query()[constants.QueryName]
+		updates, err := h.service.Subscribe(r.Context(), names)
+		if err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set(constants.ContentType, constants.ContentTypeSSE)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case m, ok := <-updates:
+				if !ok {
+					// dropped as slow subscriber, client may reconnect
+					return
+				}
+				data, err := json.Marshal(m)
+				if err != nil {
+					h.log.Error("stream metric marshal", zap.Error(err))
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", m.MType, data); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
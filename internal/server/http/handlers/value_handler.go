@@ -94,7 +94,7 @@ func (h *ValueHandler) ValueMetricJSONHandler() http.HandlerFunc {
 			h.WriteError(w, errors.ErrInvalidRequestJSON, err.Error())
 			return
 		}
-		m, exists, err := h.service.FindMetric(r.Context(), request.MType, request.ID)
+		m, exists, err := h.service.FindMetric(r.Context(), request.MType, request.ID, request.Labels)
 		if err != nil {
 			h.WriteError(w, errors.ErrInternalServerError, err.Error())
 			return
@@ -52,24 +52,79 @@ func (h *ValuesHandler) MetricValuesHandler() http.HandlerFunc {
 	</head>
 	<body>
 		<h1>Gauges:</h1>
-		<table>
+		<table id="gauge">
 		{{range .Gauges}}
 		<tr>
-			<td>{{.Name}}</td>
+			<td>{{.Name}}{{.Labels}}</td>
 			<td>{{.Value.PrettyString}}</td>
 		</tr>
 		{{end}}
 		</table>
 
 		<h1>Counters:</h1>
-		<table>
+		<table id="counter">
 		{{range .Counters}}
 		<tr>
-			<td>{{.Name}}</td>
+			<td>{{.Name}}{{.Labels}}</td>
 			<td>{{.Value}}</td>
 		</tr>
 		{{end}}
 		</table>
+
+		<h1>Histograms:</h1>
+		<table id="histogram">
+		{{range .Histograms}}
+		<tr>
+			<td>{{.Name}}{{.Labels}}</td>
+			<td>{{.Value.PrettyString}}</td>
+		</tr>
+		{{end}}
+		</table>
+	
+		<script>
+			// live updates from /stream, rows are found by
+			// series name, which is the same as on server
+			function seriesKey(m) {
+				const labels = m.labels || {};
+				const keys = Object.keys(labels).sort();
+				if (keys.length === 0) {
+					return m.id;
+				}
+				return m.id + "{" + keys.map(k => k + "=" + JSON.stringify(labels[k])).join(",") + "}";
+			}
+
+			function prettyValue(m) {
+				switch (m.type) {
+				case "gauge":
+					return String(parseFloat(m.value.toPrecision(6)));
+				case "counter":
+					return String(m.delta);
+				case "histogram":
+					const h = m.histogram;
+					let s = "count " + h.count + ", sum " + parseFloat(h.sum.toPrecision(6));
+					h.counts.forEach((c, i) => {
+						s += i < h.bounds.length ? ", ≤" + h.bounds[i] + ": " + c : ", +Inf: " + c;
+					});
+					return s;
+				}
+			}
+
+			function update(e) {
+				const m = JSON.parse(e.data);
+				const table = document.getElementById(m.type);
+				const key = seriesKey(m);
+				let row = Array.from(table.rows).find(r => r.cells[0].textContent === key);
+				if (!row) {
+					row = table.insertRow();
+					row.insertCell().textContent = key;
+					row.insertCell();
+				}
+				row.cells[1].textContent = prettyValue(m);
+			}
+
+			const stream = new EventSource("/stream");
+			["gauge", "counter", "histogram"].forEach(t => stream.addEventListener(t, update));
+		</script>
 	</body>
 	</html>`))
 
@@ -86,12 +141,20 @@ func (h *ValuesHandler) MetricValuesHandler() http.HandlerFunc {
 			return
 		}
 
+		histograms, err := h.service.ListHistograms(r.Context())
+		if err != nil {
+			h.WriteError(w, errors.ErrInternalServerError, err.Error())
+			return
+		}
+
 		templateData := struct {
-			Gauges   []models.Gauge
-			Counters []models.Counter
+			Gauges     []models.Gauge
+			Counters   []models.Counter
+			Histograms []models.Histogram
 		}{
-			Gauges:   gauges,
-			Counters: counters,
+			Gauges:     gauges,
+			Counters:   counters,
+			Histograms: histograms,
 		}
 
 		w.Header().Set(constants.ContentType, constants.ContentTypeHTML)
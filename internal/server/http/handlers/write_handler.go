@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/stepkareserva/obsermon/internal/server/http/errors"
+	"github.com/stepkareserva/obsermon/internal/server/influx"
+)
+
+// influxdb line protocol write, for telegraf and friends.
+// all lines are applied as one batch, like /updates
+func (h *UpdateHandler) InfluxWriteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics, err := influx.Parse(r.Body)
+		if err != nil {
+			h.WriteError(w, errors.ErrInvalidLineProtocol, err.Error())
+			return
+		}
+		if len(metrics) > 0 {
+			if _, err := h.service.UpdateMetrics(r.Context(), metrics); err != nil {
+				h.WriteError(w, errors.ErrInternalServerError, err.Error())
+				return
+			}
+		}
+		// influxdb responds 204 on successful write
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
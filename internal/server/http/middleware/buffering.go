@@ -34,10 +34,14 @@ type bufferingWriter struct {
 	http.ResponseWriter
 	buf    buffer.Buffer
 	status int
-	log    *zap.Logger
+	// header was sent by explicit Flush,
+	// response is streamed and can't be changed
+	headerSent bool
+	log        *zap.Logger
 }
 
 var _ http.ResponseWriter = (*bufferingWriter)(nil)
+var _ http.Flusher = (*bufferingWriter)(nil)
 
 func (w *bufferingWriter) Write(data []byte) (int, error) {
 	// part of http.ResponseWriter's interface contract:
@@ -50,6 +54,9 @@ func (w *bufferingWriter) Write(data []byte) (int, error) {
 }
 
 func (w *bufferingWriter) WriteHeader(status int) {
+	if w.headerSent {
+		return
+	}
 	w.status = status
 	// clean buffer if error occurs to sending
 	// only upcoming error content to client
@@ -64,10 +71,25 @@ func (w *bufferingWriter) isErrorStatus(status int) bool {
 }
 
 func (w *bufferingWriter) FlushToClient() {
-	w.ResponseWriter.WriteHeader(w.status)
+	if !w.headerSent {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
 	if _, err := w.ResponseWriter.Write(w.buf.Bytes()); err != nil {
 		// write error to log? pass logger here throughtout context?
 		w.log.Error("response sending", zap.Error(err))
 	}
 	w.buf.Reset()
 }
+
+// explicit flush for streaming handlers: send buffered content now,
+// response status can't be changed after it.
+func (w *bufferingWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.FlushToClient()
+	w.headerSent = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"io"
+	"net/http"
+
+	"github.com/stepkareserva/obsermon/internal/encryption"
+	"github.com/stepkareserva/obsermon/internal/server/http/errors"
+	"go.uber.org/zap"
+)
+
+// create middleware for decryption of requests bodies
+// encrypted by server's public key, not encrypted
+// requests are passed as is. without key encrypted
+// requests are rejected.
+func Decryption(key *rsa.PrivateKey, log *zap.Logger) Middleware {
+	ev := errors.NewErrorsWriter(log)
+	return func(next http.Handler) http.Handler {
+		decryption := func(w http.ResponseWriter, r *http.Request) {
+			scheme := r.Header.Get(encryption.Header)
+			if scheme == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if key == nil || scheme != encryption.Scheme {
+				ev.WriteError(w, errors.ErrUnsupportedEncryption)
+				return
+			}
+
+			msg, err := io.ReadAll(r.Body)
+			if err != nil {
+				ev.WriteError(w, errors.ErrInternalServerError, err.Error())
+				return
+			}
+			body, err := encryption.Decrypt(key, msg)
+			if err != nil {
+				ev.WriteError(w, errors.ErrRequestDecryption)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Del(encryption.Header)
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(decryption)
+	}
+}
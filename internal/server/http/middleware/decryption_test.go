@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/stepkareserva/obsermon/internal/encryption"
+)
+
+func TestRequestDecryption(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	body := []byte(`[{"id":"PollCount","type":"counter","delta":1}]`)
+
+	var received []byte
+	mockHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		received, err = io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	encryptedRequest := func(data []byte) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/updates", bytes.NewReader(data))
+		r.Header.Set(encryption.Header, encryption.Scheme)
+		return r
+	}
+	serve := func(handler http.Handler, r *http.Request) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	handler := Decryption(key, zap.NewNop())(mockHandler)
+
+	t.Run("encrypted request", func(t *testing.T) {
+		msg, err := encryption.Encrypt(&key.PublicKey, body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, serve(handler, encryptedRequest(msg)))
+		assert.Equal(t, body, received)
+	})
+
+	t.Run("not encrypted request", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/updates", bytes.NewReader(body))
+		require.Equal(t, http.StatusOK, serve(handler, r))
+		assert.Equal(t, body, received)
+	})
+
+	t.Run("invalid message", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve(handler, encryptedRequest(body)))
+	})
+
+	t.Run("server without key", func(t *testing.T) {
+		msg, err := encryption.Encrypt(&key.PublicKey, body)
+		require.NoError(t, err)
+		handler := Decryption(nil, zap.NewNop())(mockHandler)
+		assert.Equal(t, http.StatusBadRequest, serve(handler, encryptedRequest(msg)))
+	})
+}
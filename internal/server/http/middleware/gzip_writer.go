@@ -17,6 +17,7 @@ type gzipWriter struct {
 }
 
 var _ http.ResponseWriter = (*gzipWriter)(nil)
+var _ http.Flusher = (*gzipWriter)(nil)
 
 func newGZipWriter(w http.ResponseWriter) (*gzipWriter, error) {
 	return &gzipWriter{
@@ -57,6 +58,19 @@ func (g *gzipWriter) WriteHeader(status int) {
 	g.ResponseWriter.WriteHeader(status)
 }
 
+func (g *gzipWriter) Flush() {
+	if g.status == 0 {
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.compressor != nil {
+		// error will be returned on next Write
+		_ = g.compressor.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func (g *gzipWriter) isErrorStatus(status int) bool {
 	return status >= http.StatusBadRequest
 }
@@ -77,6 +91,7 @@ func (g *gzipWriter) supportContentCompress(contentType string) bool {
 		constants.ContentTypeJSONU,
 		constants.ContentTypeHTML,
 		constants.ContentTypeHTMLU,
+		constants.ContentTypePrometheus,
 	}
 	for _, g := range compressableContent {
 		if contentType == g {
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/http/constants"
+	"github.com/stepkareserva/obsermon/internal/server/http/errors"
+	"go.uber.org/zap"
+)
+
+const maxIdempotencyKeyLen = 255
+
+type ResponseStore interface {
+	FindResponse(ctx context.Context, key string) (*models.IdempotentResponse, bool, error)
+	SaveResponse(ctx context.Context, key string, resp models.IdempotentResponse) error
+}
+
+// keys of requests in progress on this server.
+// may be shared by idempotency middlewares, so requests
+// are not processed twice while router is rebuilt
+type KeysInProgress struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+func NewKeysInProgress() *KeysInProgress {
+	return &KeysInProgress{keys: make(map[string]struct{})}
+}
+
+// false if key is already in progress
+func (k *KeysInProgress) Acquire(key string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, busy := k.keys[key]; busy {
+		return false
+	}
+	k.keys[key] = struct{}{}
+	return true
+}
+
+func (k *KeysInProgress) Release(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.keys, key)
+}
+
+// create middleware which processes request with Idempotency-Key
+// only once and returns the original response for repeats.
+// with its own keys in progress if inProgress is nil
+func Idempotency(store ResponseStore, inProgress *KeysInProgress, log *zap.Logger) Middleware {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	ev := errors.NewErrorsWriter(log)
+	if inProgress == nil {
+		inProgress = NewKeysInProgress()
+	}
+
+	return func(next http.Handler) http.Handler {
+		idempotency := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(constants.IdempotencyKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				ev.WriteError(w, errors.ErrInvalidIdempotencyKey)
+				return
+			}
+
+			hash, err := requestHash(r)
+			if err != nil {
+				ev.WriteError(w, errors.ErrInternalServerError, err.Error())
+				return
+			}
+
+			if !inProgress.Acquire(key) {
+				ev.WriteError(w, errors.ErrIdempotencyKeyInUse)
+				return
+			}
+			defer inProgress.Release(key)
+
+			resp, found, err := store.FindResponse(r.Context(), key)
+			if err != nil {
+				ev.WriteError(w, errors.ErrInternalServerError, err.Error())
+				return
+			}
+			if found {
+				if resp.RequestHash != hash {
+					ev.WriteError(w, errors.ErrIdempotencyKeyReused)
+					return
+				}
+				replayResponse(w, resp, log)
+				return
+			}
+
+			// storage may save key together with updates
+			ctx := models.ContextWithIdempotencyKey(r.Context(),
+				models.IdempotencyKey{Key: key, RequestHash: hash})
+			rw := &recordingWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r.WithContext(ctx))
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+
+			// server errors are not remembered, request may be retried
+			if rw.status >= http.StatusInternalServerError {
+				return
+			}
+			resp = &models.IdempotentResponse{
+				RequestHash: hash,
+				StatusCode:  rw.status,
+				ContentType: w.Header().Get(constants.ContentType),
+				Body:        rw.body.Bytes(),
+			}
+			if err := store.SaveResponse(r.Context(), key, *resp); err != nil {
+				log.Error("idempotent response saving", zap.Error(err))
+			}
+		}
+		return http.HandlerFunc(idempotency)
+	}
+}
+
+// the same key with another method, path or body is a client's error
+func requestHash(r *http.Request) (string, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// request with lost response was applied, so it's just ok
+func replayResponse(w http.ResponseWriter, resp *models.IdempotentResponse, log *zap.Logger) {
+	if resp.Lost() {
+		w.Header().Set(constants.IdempotentReplayed, "true")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if resp.ContentType != "" {
+		w.Header().Set(constants.ContentType, resp.ContentType)
+	}
+	w.Header().Set(constants.IdempotentReplayed, "true")
+	w.WriteHeader(resp.StatusCode)
+	if _, err := w.Write(resp.Body); err != nil {
+		log.Error("response sending", zap.Error(err))
+	}
+}
+
+// writer which keeps copy of response
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+var _ http.ResponseWriter = (*recordingWriter)(nil)
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// like bufferingWriter, content written before error is dropped
+func (w *recordingWriter) WriteHeader(status int) {
+	w.status = status
+	if status >= http.StatusBadRequest {
+		w.body.Reset()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
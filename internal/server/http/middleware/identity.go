@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+type identityKey struct{}
+
+// common name of verified client certificate (mTLS),
+// empty for connections without it
+func ClientCertCN(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
+}
+
+// create middleware which passes agent identity
+// from client certificate to handlers
+func Identity() Middleware {
+	return func(next http.Handler) http.Handler {
+		identity := func(w http.ResponseWriter, r *http.Request) {
+			if cn := ClientCertCN(r); cn != "" {
+				r = r.WithContext(context.WithValue(r.Context(), identityKey{}, cn))
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(identity)
+	}
+}
+
+// agent identity from client certificate, if any
+func AgentIdentity(ctx context.Context) (string, bool) {
+	cn, ok := ctx.Value(identityKey{}).(string)
+	return cn, ok
+}
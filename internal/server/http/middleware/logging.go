@@ -19,8 +19,14 @@ func Logger(logger *zap.Logger) Middleware {
 
 			duration := time.Since(start)
 
+			// agent identity from client certificate, if mtls is used
+			log := logger
+			if cn := ClientCertCN(r); cn != "" {
+				log = log.With(zap.String("agent", cn))
+			}
+
 			if responseInfo.err == nil {
-				logger.Info("request",
+				log.Info("request",
 					zap.String("uri", r.RequestURI),
 					zap.String("method", r.Method),
 					zap.Int("status", responseInfo.status),
@@ -28,7 +34,7 @@ func Logger(logger *zap.Logger) Middleware {
 					zap.Int("size", responseInfo.size),
 				)
 			} else {
-				logger.Error("request",
+				log.Error("request",
 					zap.String("uri", r.RequestURI),
 					zap.String("method", r.Method),
 					zap.Error(responseInfo.err),
@@ -59,6 +65,7 @@ type responseMiddleware struct {
 }
 
 var _ http.ResponseWriter = (*responseMiddleware)(nil)
+var _ http.Flusher = (*responseMiddleware)(nil)
 
 func (m *responseMiddleware) Write(data []byte) (int, error) {
 	// part of http.ResponseWriter's interface contract:
@@ -79,3 +86,9 @@ func (m *responseMiddleware) WriteHeader(status int) {
 	m.ResponseWriter.WriteHeader(status)
 	m.info.status = status
 }
+
+func (m *responseMiddleware) Flush() {
+	if f, ok := m.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
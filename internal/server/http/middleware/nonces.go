@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	errNonceUsed      = errors.New("nonce was already used")
+	errNonceCacheFull = errors.New("nonce cache is full")
+)
+
+// recently used request nonces, bounded by size.
+// nonces are never forgotten before expiration,
+// otherwise requests could be replayed.
+// may be shared by signing middlewares, so nonces
+// are kept when router is rebuilt
+type NonceCache struct {
+	mu      sync.Mutex
+	size    int
+	order   nonceHeap
+	entries map[string]struct{}
+}
+
+type nonceEntry struct {
+	nonce   string
+	expires time.Time
+}
+
+func NewNonceCache() *NonceCache {
+	return newNonceCache(nonceCacheSize)
+}
+
+func newNonceCache(size int) *NonceCache {
+	return &NonceCache{
+		size:    size,
+		entries: make(map[string]struct{}),
+	}
+}
+
+// errNonceUsed if nonce was already used and not expired yet,
+// errNonceCacheFull if there is no room for nonce until
+// the oldest ones expire
+func (c *NonceCache) Add(nonce string, now, expires time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// nonces expire with their timestamps, not in order
+	// of adding, so the earliest expiration is on top
+	for len(c.order) > 0 && !c.order[0].expires.After(now) {
+		entry := heap.Pop(&c.order).(nonceEntry)
+		delete(c.entries, entry.nonce)
+	}
+
+	if _, used := c.entries[nonce]; used {
+		return errNonceUsed
+	}
+	if len(c.order) >= c.size {
+		return errNonceCacheFull
+	}
+	c.entries[nonce] = struct{}{}
+	heap.Push(&c.order, nonceEntry{nonce: nonce, expires: expires})
+	return nil
+}
+
+// min-heap of nonces by expiration, for container/heap
+type nonceHeap []nonceEntry
+
+func (h nonceHeap) Len() int           { return len(h) }
+func (h nonceHeap) Less(i, j int) bool { return h[i].expires.Before(h[j].expires) }
+func (h nonceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *nonceHeap) Push(x any) {
+	*h = append(*h, x.(nonceEntry))
+}
+
+func (h *nonceHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
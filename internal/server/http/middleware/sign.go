@@ -9,6 +9,8 @@ import (
 	"hash"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/stepkareserva/obsermon/internal/server/http/errors"
 	"go.uber.org/zap"
@@ -17,30 +19,91 @@ import (
 // header HashSHA256 is forbidden by checker
 var signHeader = http.CanonicalHeaderKey("HashSHA256")
 
-// create middleware for check request signature
-func Sign(secretkey string, log *zap.Logger) Middleware {
+// timestamp and nonce are signed together with body,
+// so captured request can't be replayed
+var (
+	signTimestampHeader = http.CanonicalHeaderKey("X-Sign-Timestamp")
+	signNonceHeader     = http.CanonicalHeaderKey("X-Sign-Nonce")
+)
+
+// id of agent's own key, common key is used without it
+var signKeyIDHeader = http.CanonicalHeaderKey("X-Sign-Key-Id")
+
+const (
+	// nonces are remembered for max skew, signed requests
+	// are rejected if there are too many of them
+	nonceCacheSize = 1 << 16
+	maxNonceLen    = 64
+)
+
+// secrets of per-agent keys, like signkeys.Registry
+type KeyStore interface {
+	Secret(id string) (string, bool)
+}
+
+type SignConfig struct {
+	// common key, optional if per-agent keys are used
+	Key string
+	// per-agent keys, optional
+	Keys KeyStore
+	// reject not signed requests, except of read only ones
+	Strict bool
+	// max difference between request timestamp and server time
+	MaxSkew time.Duration
+	// accept body only signatures without timestamp and nonce
+	AllowLegacy bool
+}
+
+func (c SignConfig) Enabled() bool {
+	return c.Key != "" || c.Keys != nil || c.Strict
+}
+
+// create middleware for check request signature,
+// with its own nonces cache if nonces is nil
+func Sign(cfg SignConfig, nonces *NonceCache, log *zap.Logger) Middleware {
 	ev := errors.NewErrorsWriter(log)
+	if nonces == nil {
+		nonces = NewNonceCache()
+	}
 	return func(next http.Handler) http.Handler {
 		singing := func(w http.ResponseWriter, r *http.Request) {
-			// skip non signed messages
+			// skip non signed messages if allowed
 			if _, ok := r.Header[signHeader]; !ok {
+				if cfg.Strict && !isReadOnly(r) {
+					ev.WriteError(w, errors.ErrMissingRequestSign)
+					return
+				}
 				next.ServeHTTP(w, r)
 				return
 			}
 
+			// agent with client certificate may use only its own key
+			if id := r.Header.Get(signKeyIDHeader); id != "" {
+				if cn, ok := AgentIdentity(r.Context()); ok && cn != id {
+					ev.WriteError(w, errors.ErrForeignSignKey)
+					return
+				}
+			}
+
+			secret, ok := signSecret(r, cfg)
+			if !ok {
+				ev.WriteError(w, errors.ErrUnknownSignKey)
+				return
+			}
+
 			// check request sign
-			signOK, err := checkSign(r, secretkey)
+			herr, err := checkRequestSign(r, secret, cfg, nonces, time.Now())
 			if err != nil {
 				ev.WriteError(w, errors.ErrInternalServerError, err.Error())
 				return
 			}
-			if !signOK {
-				ev.WriteError(w, errors.ErrInvalidRequestSign)
+			if herr != nil {
+				ev.WriteError(w, *herr)
 				return
 			}
 
 			// set responce sing
-			bw := withSigning(w, secretkey, log)
+			bw := withSigning(w, secret, log)
 			next.ServeHTTP(bw, r)
 			bw.FlushToClient()
 
@@ -49,7 +112,80 @@ func Sign(secretkey string, log *zap.Logger) Middleware {
 	}
 }
 
-func checkSign(r *http.Request, secretkey string) (bool, error) {
+// GET requests don't modify metrics, so
+// monitoring page stays available in strict mode
+func isReadOnly(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// agent's own key if key id is passed, common key otherwise.
+// unknown and revoked keys are not found.
+func signSecret(r *http.Request, cfg SignConfig) (string, bool) {
+	id := r.Header.Get(signKeyIDHeader)
+	if id == "" {
+		return cfg.Key, cfg.Key != ""
+	}
+	if cfg.Keys == nil {
+		return "", false
+	}
+	return cfg.Keys.Secret(id)
+}
+
+// handler error if request is rejected
+func checkRequestSign(r *http.Request, secret string, cfg SignConfig, nonces *NonceCache, now time.Time) (*errors.HandlerError, error) {
+	timestamp := r.Header.Get(signTimestampHeader)
+	nonce := r.Header.Get(signNonceHeader)
+
+	if timestamp == "" && nonce == "" {
+		if !cfg.AllowLegacy {
+			return &errors.ErrLegacyRequestSign, nil
+		}
+		signOK, err := checkSign(r, secret, nil)
+		if err != nil || signOK {
+			return nil, err
+		}
+		return &errors.ErrInvalidRequestSign, nil
+	}
+
+	if nonce == "" || len(nonce) > maxNonceLen {
+		return &errors.ErrInvalidRequestSign, nil
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return &errors.ErrInvalidRequestSign, nil
+	}
+
+	signOK, err := checkSign(r, secret, signPrefix(timestamp, nonce))
+	if err != nil {
+		return nil, err
+	}
+	if !signOK {
+		return &errors.ErrInvalidRequestSign, nil
+	}
+
+	// checked after sign, so forged requests don't fill nonces cache
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-cfg.MaxSkew)) || signedAt.After(now.Add(cfg.MaxSkew)) {
+		return &errors.ErrStaleRequestSign, nil
+	}
+	// nonce is remembered while its timestamp is accepted,
+	// request signed in the future is valid longer
+	switch err := nonces.Add(nonce, now, signedAt.Add(cfg.MaxSkew)); err {
+	case nil:
+		return nil, nil
+	case errNonceCacheFull:
+		return &errors.ErrTooManySignedRequests, nil
+	default:
+		return &errors.ErrReplayedRequest, nil
+	}
+}
+
+// signed data is prefix followed by request body
+func signPrefix(timestamp, nonce string) []byte {
+	return []byte(timestamp + "\n" + nonce + "\n")
+}
+
+func checkSign(r *http.Request, secretkey string, prefix []byte) (bool, error) {
 	headerSign, err := hex.DecodeString(r.Header.Get(signHeader))
 	if err != nil {
 		return false, fmt.Errorf("decoding sign: %v", err)
@@ -63,6 +199,9 @@ func checkSign(r *http.Request, secretkey string) (bool, error) {
 	r.Body = io.NopCloser(bytes.NewReader(body))
 
 	hash := hmac.New(sha256.New, []byte(secretkey))
+	if _, err := hash.Write(prefix); err != nil {
+		return false, fmt.Errorf("hash write: %v", err)
+	}
 	if _, err := hash.Write(body); err != nil {
 		return false, fmt.Errorf("hash write: %v", err)
 	}
@@ -86,10 +225,14 @@ func withSigning(w http.ResponseWriter, secretkey string, log *zap.Logger) *sign
 type signingWriter struct {
 	http.ResponseWriter
 	hash hash.Hash
-	log  *zap.Logger
+	// response was flushed by streaming handler,
+	// whole body can't be signed in header
+	streamed bool
+	log      *zap.Logger
 }
 
 var _ http.ResponseWriter = (*signingWriter)(nil)
+var _ http.Flusher = (*signingWriter)(nil)
 
 func (w *signingWriter) Write(data []byte) (int, error) {
 	if _, err := w.hash.Write(data); err != nil {
@@ -98,7 +241,18 @@ func (w *signingWriter) Write(data []byte) (int, error) {
 	return w.ResponseWriter.Write(data)
 }
 
+// streamed responses are sent without sign
+func (w *signingWriter) Flush() {
+	w.streamed = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func (w *signingWriter) FlushToClient() {
+	if w.streamed {
+		return
+	}
 	hash := w.hash.Sum(nil)
 	hashString := hex.EncodeToString(hash)
 	w.ResponseWriter.Header().Set(signHeader, hashString)
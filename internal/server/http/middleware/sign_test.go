@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/stepkareserva/obsermon/internal/server/http/errors"
+)
+
+func TestRequestSign(t *testing.T) {
+	const key = "secret"
+	const body = `[{"id":"PollCount","type":"counter","delta":1}]`
+
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	signedRequest := func(signKey string, timestamp time.Time, nonce string) *http.Request {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		h := hmac.New(sha256.New, []byte(signKey))
+		h.Write([]byte(ts + "\n" + nonce + "\n" + body))
+
+		r := httptest.NewRequest(http.MethodPost, "/updates", strings.NewReader(body))
+		r.Header.Set("HashSHA256", hex.EncodeToString(h.Sum(nil)))
+		r.Header.Set("X-Sign-Timestamp", ts)
+		r.Header.Set("X-Sign-Nonce", nonce)
+		return r
+	}
+	legacyRequest := func() *http.Request {
+		h := hmac.New(sha256.New, []byte(key))
+		h.Write([]byte(body))
+		r := httptest.NewRequest(http.MethodPost, "/updates", strings.NewReader(body))
+		r.Header.Set("HashSHA256", hex.EncodeToString(h.Sum(nil)))
+		return r
+	}
+	serve := func(handler http.Handler, r *http.Request) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	cfg := SignConfig{Key: key, MaxSkew: time.Minute}
+	handler := Sign(cfg, nil, zap.NewNop())(okHandler)
+	now := time.Now()
+
+	t.Run("valid sign", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(handler, signedRequest(key, now, "nonce-1")))
+	})
+
+	t.Run("replayed request", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, serve(handler, signedRequest(key, now, "nonce-2")))
+		assert.Equal(t, http.StatusBadRequest, serve(handler, signedRequest(key, now, "nonce-2")))
+	})
+
+	t.Run("invalid sign", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve(handler, signedRequest("other", now, "nonce-3")))
+	})
+
+	t.Run("timestamp out of skew", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve(handler, signedRequest(key, now.Add(-2*time.Minute), "nonce-4")))
+		assert.Equal(t, http.StatusBadRequest, serve(handler, signedRequest(key, now.Add(2*time.Minute), "nonce-5")))
+	})
+
+	t.Run("replayed request with future timestamp", func(t *testing.T) {
+		nonces := newNonceCache(nonceCacheSize)
+		signedAt := now.Add(50 * time.Second)
+		check := func(at time.Time) *errors.HandlerError {
+			herr, err := checkRequestSign(signedRequest(key, signedAt, "nonce-6"), key, cfg, nonces, at)
+			require.NoError(t, err)
+			return herr
+		}
+		assert.Nil(t, check(now))
+		assert.Equal(t, &errors.ErrReplayedRequest, check(now.Add(30*time.Second)))
+		// timestamp is still in skew, so nonce is remembered
+		assert.Equal(t, &errors.ErrReplayedRequest, check(now.Add(70*time.Second)))
+		assert.Equal(t, &errors.ErrStaleRequestSign, check(now.Add(2*time.Minute)))
+	})
+
+	t.Run("legacy sign", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve(handler, legacyRequest()))
+
+		cfg := cfg
+		cfg.AllowLegacy = true
+		legacyHandler := Sign(cfg, nil, zap.NewNop())(okHandler)
+		assert.Equal(t, http.StatusOK, serve(legacyHandler, legacyRequest()))
+	})
+
+	t.Run("not signed request", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/updates", strings.NewReader(body))
+		assert.Equal(t, http.StatusOK, serve(handler, r))
+	})
+}
+
+func TestNonceCache(t *testing.T) {
+	now := time.Now()
+	cache := newNonceCache(2)
+
+	assert.NoError(t, cache.Add("a", now, now.Add(time.Minute)))
+	assert.ErrorIs(t, cache.Add("a", now, now.Add(time.Minute)), errNonceUsed)
+
+	// expired nonces are forgotten
+	later := now.Add(2 * time.Minute)
+	assert.NoError(t, cache.Add("a", later, later.Add(time.Minute)))
+
+	// not expired nonces are kept when cache is full
+	assert.NoError(t, cache.Add("b", later, later.Add(time.Minute)))
+	assert.ErrorIs(t, cache.Add("c", later, later.Add(time.Minute)), errNonceCacheFull)
+	assert.ErrorIs(t, cache.Add("a", later, later.Add(time.Minute)), errNonceUsed)
+
+	// room is freed by expiration
+	latest := later.Add(2 * time.Minute)
+	assert.NoError(t, cache.Add("c", latest, latest.Add(time.Minute)))
+}
+
+type testKeys map[string]string
+
+func (k testKeys) Secret(id string) (string, bool) {
+	secret, ok := k[id]
+	return secret, ok
+}
+
+func TestStrictSignWithAgentKeys(t *testing.T) {
+	const body = `[]`
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	cfg := SignConfig{Keys: testKeys{"agent-1": "one"}, Strict: true, MaxSkew: time.Minute}
+	handler := Sign(cfg, nil, zap.NewNop())(okHandler)
+
+	request := func(keyID, secret, nonce string) *http.Request {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		h := hmac.New(sha256.New, []byte(secret))
+		h.Write([]byte(ts + "\n" + nonce + "\n" + body))
+
+		r := httptest.NewRequest(http.MethodPost, "/updates", strings.NewReader(body))
+		r.Header.Set("HashSHA256", hex.EncodeToString(h.Sum(nil)))
+		r.Header.Set("X-Sign-Timestamp", ts)
+		r.Header.Set("X-Sign-Nonce", nonce)
+		if keyID != "" {
+			r.Header.Set("X-Sign-Key-Id", keyID)
+		}
+		return r
+	}
+	serve := func(r *http.Request) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(request("agent-1", "one", "nonce-1")))
+	assert.Equal(t, http.StatusBadRequest, serve(request("agent-1", "two", "nonce-2")))
+	assert.Equal(t, http.StatusUnauthorized, serve(request("agent-2", "two", "nonce-3")))
+	// no common key
+	assert.Equal(t, http.StatusUnauthorized, serve(request("", "", "nonce-4")))
+
+	// agent with client certificate can't use key of another one
+	serveWithCert := func(r *http.Request, cn string) int {
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{
+			{{Subject: pkix.Name{CommonName: cn}}},
+		}}
+		w := httptest.NewRecorder()
+		Identity()(handler).ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, serveWithCert(request("agent-1", "one", "nonce-5"), "agent-1"))
+	assert.Equal(t, http.StatusForbidden, serveWithCert(request("agent-1", "one", "nonce-6"), "agent-2"))
+
+	// not signed modifying request is rejected, reading one is not
+	assert.Equal(t, http.StatusUnauthorized,
+		serve(httptest.NewRequest(http.MethodPost, "/updates", strings.NewReader(body))))
+	assert.Equal(t, http.StatusOK, serve(httptest.NewRequest(http.MethodGet, "/", nil)))
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/stepkareserva/obsermon/internal/server/http/constants"
+	"github.com/stepkareserva/obsermon/internal/server/http/errors"
+	"go.uber.org/zap"
+)
+
+// create middleware which rejects requests without
+// X-Real-IP header from one of trusted subnets
+func TrustedSubnet(subnets []*net.IPNet, log *zap.Logger) Middleware {
+	ev := errors.NewErrorsWriter(log)
+	return func(next http.Handler) http.Handler {
+		trusted := func(w http.ResponseWriter, r *http.Request) {
+			ip := net.ParseIP(r.Header.Get(constants.RealIP))
+			if ip == nil || !containsIP(subnets, ip) {
+				ev.WriteError(w, errors.ErrUntrustedSubnet)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(trusted)
+	}
+}
+
+func containsIP(subnets []*net.IPNet, ip net.IP) bool {
+	for _, subnet := range subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
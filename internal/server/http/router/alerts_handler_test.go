@@ -0,0 +1,41 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestAlertsHandler(t *testing.T) {
+	ctrl, mockService, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	t.Run("test /alerts", func(t *testing.T) {
+		value := 95.0
+		mockService.
+			EXPECT().
+			ListAlerts(gomock.Any()).
+			Return(models.AlertsList{{
+				Rule:      "high cpu",
+				Metric:    "CPUutilization1",
+				Condition: "CPUutilization1 > 90",
+				State:     models.AlertPending,
+				Value:     &value,
+			}}, nil)
+
+		res := testingGetURL(t, ts.URL+"/alerts")
+		defer safeCloseRes(t, res)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"rule":"high cpu","metric":"CPUutilization1",
+			"condition":"CPUutilization1 > 90","state":"pending","value":95}]`, string(body))
+	})
+}
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestValidGaugeHistoryHandler(t *testing.T) {
+	ctrl, mockService, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	t.Run("test /history/gauge/name?from=&to=", func(t *testing.T) {
+		from := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		to := from.Add(5 * time.Minute)
+
+		mockService.
+			EXPECT().
+			GaugeHistory(gomock.Any(), "name", models.Labels(nil), gomock.Eq(from), gomock.Eq(to)).
+			Return(models.GaugeSamples{
+				{Time: from.Add(time.Minute), Value: 1.5},
+				{Time: from.Add(2 * time.Minute), Value: 2.5},
+			}, nil)
+
+		url := fmt.Sprintf("%s/history/gauge/name?from=%s&to=%d",
+			ts.URL, from.Format(time.RFC3339), to.Unix())
+		res := testingGetURL(t, url)
+		defer safeCloseRes(t, res)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[
+			{"time":"2025-06-01T12:01:00Z","value":1.5},
+			{"time":"2025-06-01T12:02:00Z","value":2.5}
+		]`, string(body))
+	})
+}
+
+func TestValidCounterHistoryHandler(t *testing.T) {
+	ctrl, mockService, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	t.Run("test /history/counter/name", func(t *testing.T) {
+		mockService.
+			EXPECT().
+			CounterHistory(gomock.Any(), "name", models.Labels(nil), gomock.Any(), gomock.Any()).
+			Return(models.CounterSamples{}, nil)
+
+		res := testingGetURL(t, ts.URL+"/history/counter/name")
+		defer safeCloseRes(t, res)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[]`, string(body))
+	})
+
+	t.Run("test /history/counter/name?labels=", func(t *testing.T) {
+		mockService.
+			EXPECT().
+			CounterHistory(gomock.Any(), "name", models.Labels{"host": "a"}, gomock.Any(), gomock.Any()).
+			Return(models.CounterSamples{}, nil)
+
+		res := testingGetURL(t, ts.URL+"/history/counter/name?labels="+url.QueryEscape(`{"host":"a"}`))
+		defer safeCloseRes(t, res)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+}
+
+func TestInvalidHistoryHandler(t *testing.T) {
+	ctrl, _, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	invalidRequests := []string{
+		"/history/unknown/name",
+		"/history/gauge/name?from=yesterday",
+		"/history/counter/name?from=200&to=100",
+		"/history/gauge/name?labels=host",
+	}
+	for _, req := range invalidRequests {
+		t.Run(fmt.Sprintf("test %s", req), func(t *testing.T) {
+			res := testingGetURL(t, ts.URL+req)
+			defer safeCloseRes(t, res)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
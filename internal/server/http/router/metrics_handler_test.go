@@ -0,0 +1,114 @@
+package router
+
+import (
+	"compress/gzip"
+	"io"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	ctrl, mockService, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	t.Run("test /metrics", func(t *testing.T) {
+
+		mockService.
+			EXPECT().
+			ListGauges(gomock.Any()).
+			Return(models.GaugesList{
+				{Name: "HeapAlloc", Value: 1.5},
+				{Name: "cpu.load-1", Value: 1e21},
+				{Name: "cpu.load-1", Value: 2, Labels: models.Labels{"host": "a\"b", "env:x": "prod"}},
+				{Name: "1st", Value: models.GaugeValue(math.Inf(1))},
+			}, nil)
+
+		mockService.
+			EXPECT().
+			ListCounters(gomock.Any()).
+			Return(models.CountersList{
+				{Name: "PollCount", Value: 5},
+				{Name: "HeapAlloc", Value: 1},
+			}, nil)
+
+		mockService.
+			EXPECT().
+			ListHistograms(gomock.Any()).
+			Return(models.HistogramsList{
+				{Name: "latency", Labels: models.Labels{"host": "a"}, Value: models.HistogramValue{
+					Bounds: []float64{0.1, 1},
+					Counts: []uint64{1, 2, 3},
+					Sum:    7.5,
+					Count:  6,
+				}},
+			}, nil)
+
+		res := testingGetURL(t, ts.URL+"/metrics")
+		defer safeCloseRes(t, res)
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", res.Header.Get("Content-Type"))
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		// name collision is resolved in favor of first metric
+		assert.Equal(t, "# TYPE HeapAlloc gauge\nHeapAlloc 1.5\n"+
+			"# TYPE cpu_load_1 gauge\ncpu_load_1 1e+21\n"+
+			"cpu_load_1{env_x=\"prod\",host=\"a\\\"b\"} 2\n"+
+			"# TYPE _1st gauge\n_1st +Inf\n"+
+			"# TYPE PollCount counter\nPollCount 5\n"+
+			"# TYPE latency histogram\n"+
+			"latency_bucket{host=\"a\",le=\"0.1\"} 1\n"+
+			"latency_bucket{host=\"a\",le=\"1\"} 3\n"+
+			"latency_bucket{host=\"a\",le=\"+Inf\"} 6\n"+
+			"latency_sum{host=\"a\"} 7.5\n"+
+			"latency_count{host=\"a\"} 6\n",
+			string(body))
+	})
+}
+
+func TestMetricsHandlerCompression(t *testing.T) {
+	ctrl, mockService, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	t.Run("test gzipped /metrics", func(t *testing.T) {
+
+		mockService.
+			EXPECT().
+			ListGauges(gomock.Any()).
+			Return(models.GaugesList{{Name: "name", Value: 2.5}}, nil)
+
+		mockService.
+			EXPECT().
+			ListCounters(gomock.Any()).
+			Return(models.CountersList{}, nil)
+
+		mockService.
+			EXPECT().
+			ListHistograms(gomock.Any()).
+			Return(models.HistogramsList{}, nil)
+
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/metrics", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", "gzip")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer safeCloseRes(t, res)
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+		z, err := gzip.NewReader(res.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(z)
+		require.NoError(t, err)
+		assert.Equal(t, "# TYPE name gauge\nname 2.5\n", string(body))
+	})
+}
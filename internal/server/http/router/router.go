@@ -1,7 +1,9 @@
 package router
 
 import (
+	"crypto/rsa"
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -12,7 +14,26 @@ import (
 	"go.uber.org/zap"
 )
 
-func New(log *zap.Logger, secretkey string, s handlers.Service) (http.Handler, error) {
+// state of middlewares which must survive router rebuild
+// on config reload, nil fields are created by router
+type Caches struct {
+	// nonces of signed requests, so they can't be replayed
+	Nonces *middleware.NonceCache
+	// idempotency keys of requests in progress
+	KeysInProgress *middleware.KeysInProgress
+}
+
+func NewCaches() Caches {
+	return Caches{
+		Nonces:         middleware.NewNonceCache(),
+		KeysInProgress: middleware.NewKeysInProgress(),
+	}
+}
+
+// cryptoKey is private key for requests decryption, optional.
+// if trusted subnets are passed, updates are accepted only from them.
+func New(log *zap.Logger, sign middleware.SignConfig, cryptoKey *rsa.PrivateKey, trusted []*net.IPNet,
+	caches Caches, s handlers.Service) (http.Handler, error) {
 	if log == nil {
 		log = zap.NewNop()
 	}
@@ -20,14 +41,17 @@ func New(log *zap.Logger, secretkey string, s handlers.Service) (http.Handler, e
 	// add middleware
 	r := chi.NewRouter()
 	r.Use(middleware.Logger(log))
+	r.Use(middleware.Identity())
+	// body is encrypted after compression and signing
+	r.Use(middleware.Decryption(cryptoKey, log))
 	r.Use(middleware.Compression(log))
 	r.Use(middleware.Buffering(log))
-	if len(secretkey) > 0 {
-		r.Use(middleware.Sign(secretkey, log))
+	if sign.Enabled() {
+		r.Use(middleware.Sign(sign, caches.Nonces, log))
 	}
 
 	// register routes
-	if err := addUpdateHandlers(r, s, log); err != nil {
+	if err := addUpdateHandlers(r, s, trusted, caches.KeysInProgress, log); err != nil {
 		return nil, fmt.Errorf("update handlers: %v", err)
 	}
 	if err := addValueHandlers(r, s, log); err != nil {
@@ -39,16 +63,39 @@ func New(log *zap.Logger, secretkey string, s handlers.Service) (http.Handler, e
 	if err := addPingHandlers(r, s, log); err != nil {
 		return nil, fmt.Errorf("ping handlers: %v", err)
 	}
+	if err := addMetricsHandlers(r, s, log); err != nil {
+		return nil, fmt.Errorf("metrics handlers: %v", err)
+	}
+	if err := addHistoryHandlers(r, s, log); err != nil {
+		return nil, fmt.Errorf("history handlers: %v", err)
+	}
+	if err := addStreamHandlers(r, s, log); err != nil {
+		return nil, fmt.Errorf("stream handlers: %v", err)
+	}
+	if err := addAlertsHandlers(r, s, log); err != nil {
+		return nil, fmt.Errorf("alerts handlers: %v", err)
+	}
 
 	return r, nil
 }
 
-func addUpdateHandlers(r chi.Router, s handlers.Service, log *zap.Logger) error {
+func addUpdateHandlers(r chi.Router, s handlers.Service, trusted []*net.IPNet,
+	inProgress *middleware.KeysInProgress, log *zap.Logger) error {
 	updHandler, err := handlers.NewUpdateHandler(s, log)
 	if err != nil {
 		return fmt.Errorf("update handler creation: %v", err)
 	}
+	// updates are not idempotent, repeats with the
+	// same Idempotency-Key get the original response.
+	// requests from untrusted subnets are rejected first.
+	var updates []middleware.Middleware
+	if len(trusted) > 0 {
+		updates = append(updates, middleware.TrustedSubnet(trusted, log))
+	}
+	updates = append(updates, middleware.Idempotency(s, inProgress, log))
+
 	r.Route("/update", func(r chi.Router) {
+		r.Use(updates...)
 		r.Post(fmt.Sprintf("/%s/{%s}/{%s}", constants.MetricGauge, constants.ChiName, constants.ChiValue),
 			updHandler.UpdateGaugeURLHandler())
 		r.Post(fmt.Sprintf("/%s/{%s}/{%s}", constants.MetricCounter, constants.ChiName, constants.ChiValue),
@@ -59,9 +106,11 @@ func addUpdateHandlers(r chi.Router, s handlers.Service, log *zap.Logger) error
 			updHandler.UpdateMetricJSONHandler())
 	})
 	r.Route("/updates", func(r chi.Router) {
+		r.Use(updates...)
 		r.Post("/",
 			updHandler.UpdateMetricsJSONHandler())
 	})
+	r.With(updates...).Post("/write", updHandler.InfluxWriteHandler())
 
 	return nil
 }
@@ -104,3 +153,50 @@ func addPingHandlers(r chi.Router, s handlers.Service, log *zap.Logger) error {
 
 	return nil
 }
+
+func addMetricsHandlers(r chi.Router, s handlers.Service, log *zap.Logger) error {
+	metricsHandler, err := handlers.NewMetricsHandler(s, log)
+	if err != nil {
+		return fmt.Errorf("metrics handler creation: %v", err)
+	}
+	r.Get("/metrics", metricsHandler.PrometheusHandler())
+
+	return nil
+}
+
+func addHistoryHandlers(r chi.Router, s handlers.Service, log *zap.Logger) error {
+	historyHandler, err := handlers.NewHistoryHandler(s, log)
+	if err != nil {
+		return fmt.Errorf("history handler creation: %v", err)
+	}
+	r.Route("/history", func(r chi.Router) {
+		r.Get(fmt.Sprintf("/%s/{%s}", constants.MetricGauge, constants.ChiName),
+			historyHandler.GaugeHistoryHandler())
+		r.Get(fmt.Sprintf("/%s/{%s}", constants.MetricCounter, constants.ChiName),
+			historyHandler.CounterHistoryHandler())
+		r.Get(fmt.Sprintf("/{%s}/{%s}", constants.ChiMetric, constants.ChiName),
+			historyHandler.UnknownMetricHistoryHandler())
+	})
+
+	return nil
+}
+
+func addStreamHandlers(r chi.Router, s handlers.Service, log *zap.Logger) error {
+	streamHandler, err := handlers.NewStreamHandler(s, log)
+	if err != nil {
+		return fmt.Errorf("stream handler creation: %v", err)
+	}
+	r.Get("/stream", streamHandler.Handler())
+
+	return nil
+}
+
+func addAlertsHandlers(r chi.Router, s handlers.Service, log *zap.Logger) error {
+	alertsHandler, err := handlers.NewAlertsHandler(s, log)
+	if err != nil {
+		return fmt.Errorf("alerts handler creation: %v", err)
+	}
+	r.Get("/alerts", alertsHandler.Handler())
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package router
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/http/middleware"
+	"github.com/stepkareserva/obsermon/internal/server/mocks"
+)
+
+func TestStreamHandler(t *testing.T) {
+	ctrl, mockService, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	t.Run("test /stream?name=a&name=b", func(t *testing.T) {
+		// closed channel ends stream like dropped subscriber
+		updates := make(chan models.Metric, 2)
+		updates <- models.GaugeMetric(models.Gauge{Name: "a", Value: 1.5})
+		updates <- models.CounterMetric(models.Counter{Name: "b", Value: 2})
+		close(updates)
+
+		mockService.
+			EXPECT().
+			Subscribe(gomock.Any(), []string{"a", "b"}).
+			Return((<-chan models.Metric)(updates), nil)
+
+		res := testingGetURL(t, ts.URL+"/stream?name=a&name=b")
+		defer safeCloseRes(t, res)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "event: gauge\ndata: {\"id\":\"a\",\"type\":\"gauge\",\"value\":1.5}\n\n"+
+			"event: counter\ndata: {\"id\":\"b\",\"type\":\"counter\",\"delta\":2}\n\n",
+			string(body))
+	})
+}
+
+func TestSignedStreamHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService := mocks.NewMockService(ctrl)
+
+	const key = "secret"
+	sign := middleware.SignConfig{Key: key, MaxSkew: time.Minute}
+	handler, err := New(zap.NewNop(), sign, nil, nil, Caches{}, mockService)
+	require.NoError(t, err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	updates := make(chan models.Metric, 1)
+	updates <- models.GaugeMetric(models.Gauge{Name: "a", Value: 1.5})
+	close(updates)
+	mockService.
+		EXPECT().
+		Subscribe(gomock.Any(), []string{"a"}).
+		Return((<-chan models.Metric)(updates), nil)
+
+	// request with empty body is signed too
+	ts64 := strconv.FormatInt(time.Now().Unix(), 10)
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(ts64 + "\nnonce\n"))
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/stream?name=a", nil)
+	require.NoError(t, err)
+	req.Header.Set("HashSHA256", hex.EncodeToString(h.Sum(nil)))
+	req.Header.Set("X-Sign-Timestamp", ts64)
+	req.Header.Set("X-Sign-Nonce", "nonce")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer safeCloseRes(t, res)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "event: gauge\ndata: {\"id\":\"a\",\"type\":\"gauge\",\"value\":1.5}\n\n", string(body))
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/stepkareserva/obsermon/internal/server/http/middleware"
 	"github.com/stepkareserva/obsermon/internal/server/mocks"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -16,7 +17,7 @@ func getTestObjects(t *testing.T) (*gomock.Controller, *mocks.MockService, *http
 	ctrl := gomock.NewController(t)
 	mockService := mocks.NewMockService(ctrl)
 
-	handlers, err := New(zap.NewNop(), "", mockService)
+	handlers, err := New(zap.NewNop(), middleware.SignConfig{}, nil, nil, Caches{}, mockService)
 	require.NoError(t, err, "handlers initialization error")
 
 	ts := httptest.NewServer(handlers)
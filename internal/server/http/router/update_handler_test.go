@@ -183,6 +183,40 @@ func TestValidUpdateGaugeJSONHandler(t *testing.T) {
 	})
 }
 
+func TestValidUpdateHistogramJSONHandler(t *testing.T) {
+	ctrl, mockService, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	t.Run("update histogram: { name, [1, 2] }", func(t *testing.T) {
+		histogramJSON := `{"id":"name","type":"histogram","histogram":
+			{"bounds":[0.5],"counts":[1,2],"sum":2.5,"count":3}}`
+
+		histogram := models.Metric{
+			MType: models.MetricTypeHistogram,
+			ID:    "name",
+			Histogram: &models.HistogramValue{
+				Bounds: []float64{0.5},
+				Counts: []uint64{1, 2},
+				Sum:    2.5,
+				Count:  3,
+			},
+		}
+
+		mockService.
+			EXPECT().
+			UpdateMetric(gomock.Any(), histogram).
+			Return(&histogram, nil)
+
+		res := testingPostJSON(t, ts.URL+"/update", histogramJSON)
+		defer safeCloseRes(t, res)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, histogramJSON, string(body))
+	})
+}
+
 func TestInvalidUpdateJSONHandler(t *testing.T) {
 	ctrl, _, ts := getTestObjects(t)
 	defer ctrl.Finish()
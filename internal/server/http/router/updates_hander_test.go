@@ -1,15 +1,27 @@
 package router
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
 
 	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/http/middleware"
+	"github.com/stepkareserva/obsermon/internal/server/mocks"
 )
 
 // test for counter value handler
@@ -68,3 +80,170 @@ func TestInvalidUpdatesHandler(t *testing.T) {
 		require.Equal(t, http.StatusBadRequest, res.StatusCode)
 	})
 }
+
+func TestIdempotentUpdatesHandler(t *testing.T) {
+	ctrl, mockService, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	post := func(key, data string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/updates", strings.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return res
+	}
+
+	metricsJSON := `[{"id":"PollCount", "type":"counter", "delta":1}]`
+	counterValue := models.CounterValue(1)
+	metrics := models.Metrics{{MType: models.MetricTypeCounter, ID: "PollCount", Delta: &counterValue}}
+
+	var saved *models.IdempotentResponse
+	mockService.
+		EXPECT().
+		FindResponse(gomock.Any(), "key").
+		DoAndReturn(func(context.Context, string) (*models.IdempotentResponse, bool, error) {
+			return saved, saved != nil, nil
+		}).
+		Times(3)
+	mockService.
+		EXPECT().
+		SaveResponse(gomock.Any(), "key", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, resp models.IdempotentResponse) error {
+			saved = &resp
+			return nil
+		})
+	// metrics are updated only once
+	mockService.
+		EXPECT().
+		UpdateMetrics(gomock.Any(), metrics).
+		Return(metrics, nil)
+
+	t.Run("first request", func(t *testing.T) {
+		res := post("key", metricsJSON)
+		defer safeCloseRes(t, res)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Empty(t, res.Header.Get("Idempotent-Replayed"))
+	})
+
+	t.Run("repeated request", func(t *testing.T) {
+		res := post("key", metricsJSON)
+		defer safeCloseRes(t, res)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "true", res.Header.Get("Idempotent-Replayed"))
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, metricsJSON, string(body))
+	})
+
+	t.Run("key reused for another request", func(t *testing.T) {
+		res := post("key", `[{"id":"PollCount", "type":"counter", "delta":2}]`)
+		defer safeCloseRes(t, res)
+		require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+}
+
+func TestTrustedSubnetUpdatesHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService := mocks.NewMockService(ctrl)
+
+	_, subnet, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	handler, err := New(zap.NewNop(), middleware.SignConfig{}, nil, []*net.IPNet{subnet}, Caches{}, mockService)
+	require.NoError(t, err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	metricsJSON := `[{"id":"PollCount", "type":"counter", "delta":1}]`
+	post := func(realIP string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/updates", strings.NewReader(metricsJSON))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if realIP != "" {
+			req.Header.Set("X-Real-IP", realIP)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("trusted agent", func(t *testing.T) {
+		counterValue := models.CounterValue(1)
+		metrics := models.Metrics{{MType: models.MetricTypeCounter, ID: "PollCount", Delta: &counterValue}}
+		mockService.
+			EXPECT().
+			UpdateMetrics(gomock.Any(), metrics).
+			Return(metrics, nil)
+
+		res := post("10.1.2.3")
+		defer safeCloseRes(t, res)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("untrusted agent", func(t *testing.T) {
+		res := post("192.168.1.1")
+		defer safeCloseRes(t, res)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("agent without ip", func(t *testing.T) {
+		res := post("")
+		defer safeCloseRes(t, res)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("values are open", func(t *testing.T) {
+		mockService.
+			EXPECT().
+			FindGauge(gomock.Any(), gomock.Eq("name")).
+			Return(&models.Gauge{Name: "name", Value: 1.2}, true, nil)
+
+		res := testingGetURL(t, ts.URL+"/value/gauge/name")
+		defer safeCloseRes(t, res)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+}
+
+func TestNoncesKeptOnRebuild(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockService := mocks.NewMockService(ctrl)
+
+	const key = "secret"
+	const body = `[{"id":"PollCount", "type":"counter", "delta":1}]`
+	sign := middleware.SignConfig{Key: key, MaxSkew: time.Minute}
+	caches := NewCaches()
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(ts + "\nnonce\n" + body))
+	post := func(handler http.Handler) int {
+		req := httptest.NewRequest(http.MethodPost, "/updates", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("HashSHA256", hex.EncodeToString(h.Sum(nil)))
+		req.Header.Set("X-Sign-Timestamp", ts)
+		req.Header.Set("X-Sign-Nonce", "nonce")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	counterValue := models.CounterValue(1)
+	metrics := models.Metrics{{MType: models.MetricTypeCounter, ID: "PollCount", Delta: &counterValue}}
+	mockService.
+		EXPECT().
+		UpdateMetrics(gomock.Any(), metrics).
+		Return(metrics, nil)
+
+	handler, err := New(zap.NewNop(), sign, nil, nil, caches, mockService)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, post(handler))
+
+	// router rebuilt on reload still rejects replayed request
+	handler, err = New(zap.NewNop(), sign, nil, nil, caches, mockService)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, post(handler))
+}
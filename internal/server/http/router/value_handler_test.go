@@ -115,7 +115,7 @@ func TestValidValueCounterJSONHandler(t *testing.T) {
 
 		mockService.
 			EXPECT().
-			FindMetric(gomock.Any(), models.MetricTypeCounter, "name").
+			FindMetric(gomock.Any(), models.MetricTypeCounter, "name", gomock.Nil()).
 			Return(&counter, true, nil)
 
 		res := testingPostJSON(t, ts.URL+"/value", counterJSON)
@@ -144,7 +144,38 @@ func TestValidValueGaugeJSONHandler(t *testing.T) {
 
 		mockService.
 			EXPECT().
-			FindMetric(gomock.Any(), models.MetricTypeGauge, "name").
+			FindMetric(gomock.Any(), models.MetricTypeGauge, "name", gomock.Nil()).
+			Return(&gauge, true, nil)
+
+		res := testingPostJSON(t, ts.URL+"/value", gaugeJSON)
+		defer safeCloseRes(t, res)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, gaugeJSON, string(body))
+	})
+}
+
+func TestValidValueLabeledGaugeJSONHandler(t *testing.T) {
+	ctrl, mockService, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	t.Run("value gauge: { name, labels }", func(t *testing.T) {
+		gaugeJSON := `{"id":"name","type":"gauge","value":1.5,"labels":{"host":"a","env":"prod"}}`
+
+		value := models.GaugeValue(1.5)
+		labels := models.Labels{"host": "a", "env": "prod"}
+		gauge := models.Metric{
+			MType:  models.MetricTypeGauge,
+			ID:     "name",
+			Value:  &value,
+			Labels: labels,
+		}
+
+		mockService.
+			EXPECT().
+			FindMetric(gomock.Any(), models.MetricTypeGauge, "name", gomock.Eq(labels)).
 			Return(&gauge, true, nil)
 
 		res := testingPostJSON(t, ts.URL+"/value", gaugeJSON)
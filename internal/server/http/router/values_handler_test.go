@@ -29,6 +29,11 @@ func TestValuesHandler(t *testing.T) {
 			ListCounters(gomock.Any()).
 			Return(models.CountersList{}, nil)
 
+		mockService.
+			EXPECT().
+			ListHistograms(gomock.Any()).
+			Return(models.HistogramsList{}, nil)
+
 		// get values
 		res := testingGetURL(t, ts.URL+"/")
 		defer safeCloseRes(t, res)
@@ -0,0 +1,64 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestValidWriteHandler(t *testing.T) {
+	ctrl, mockService, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	t.Run("write cpu,host=a usage=0.5,count=3i", func(t *testing.T) {
+		body := "cpu,host=a usage=0.5,count=3i,state=\"ok\" 1700000000000000000\n" +
+			"mem free=1024\n"
+
+		labels := models.Labels{"host": "a"}
+		metrics := models.Metrics{
+			models.GaugeMetric(models.Gauge{Name: "cpu.usage", Value: 0.5, Labels: labels}),
+			models.CounterMetric(models.Counter{Name: "cpu.count", Value: 3, Labels: labels}),
+			models.GaugeMetric(models.Gauge{Name: "mem.free", Value: 1024}),
+		}
+
+		mockService.
+			EXPECT().
+			UpdateMetrics(gomock.Any(), metrics).
+			Return(metrics, nil)
+
+		res, err := http.Post(ts.URL+"/write", "text/plain", strings.NewReader(body))
+		require.NoError(t, err)
+		defer safeCloseRes(t, res)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
+
+func TestInvalidWriteHandler(t *testing.T) {
+	ctrl, _, ts := getTestObjects(t)
+	defer ctrl.Finish()
+	defer ts.Close()
+
+	invalidBodies := []string{
+		"cpu",
+		"cpu usage",
+		"cpu usage=abc",
+		"cpu usage=1 yesterday",
+		"cpu,host usage=1",
+		"cpu usage=1\nmem",
+	}
+	for _, body := range invalidBodies {
+		t.Run(body, func(t *testing.T) {
+			res, err := http.Post(ts.URL+"/write", "text/plain", strings.NewReader(body))
+			require.NoError(t, err)
+			defer safeCloseRes(t, res)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
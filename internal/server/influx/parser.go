@@ -0,0 +1,192 @@
+package influx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// parse influxdb line protocol body,
+// see https://docs.influxdata.com/influxdb/v2/reference/syntax/line-protocol/
+// every field of line becomes metric named measurement.field,
+// floats are gauges, integers (1i) and unsigned (1u) are counters,
+// tags become labels. string and boolean fields are skipped,
+// timestamps are validated but ignored, server stamps its own time.
+func Parse(r io.Reader) (models.Metrics, error) {
+	var metrics models.Metrics
+	reader := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("read line %d: %v", lineNum, err)
+		}
+		lineMetrics, parseErr := ParseLine(line)
+		if parseErr != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, parseErr)
+		}
+		metrics = append(metrics, lineMetrics...)
+		if errors.Is(err, io.EOF) {
+			return metrics, nil
+		}
+	}
+}
+
+// parse one line, empty lines and comments give no metrics
+func ParseLine(line string) (models.Metrics, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	sections := split(line, ' ', true)
+	if len(sections) < 2 || len(sections) > 3 {
+		return nil, fmt.Errorf("expected measurement, fields and optional timestamp")
+	}
+	if len(sections) == 3 {
+		if _, err := strconv.ParseInt(sections[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", sections[2])
+		}
+	}
+
+	// measurement and tags
+	keys := split(sections[0], ',', false)
+	measurement := unescape(keys[0])
+	if measurement == "" {
+		return nil, fmt.Errorf("empty measurement")
+	}
+	var labels models.Labels
+	for _, tag := range keys[1:] {
+		k, v, err := keyValue(tag, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag: %v", err)
+		}
+		if labels == nil {
+			labels = make(models.Labels)
+		}
+		labels[k] = v
+	}
+
+	// fields
+	var metrics models.Metrics
+	for _, field := range split(sections[1], ',', true) {
+		k, v, err := keyValue(field, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field: %v", err)
+		}
+		m, err := fieldMetric(measurement+"."+k, v, labels)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %v", k, err)
+		}
+		if m != nil {
+			metrics = append(metrics, *m)
+		}
+	}
+	return metrics, nil
+}
+
+// nil metric without error for skipped field types
+func fieldMetric(name, value string, labels models.Labels) (*models.Metric, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		// string field
+		return nil, nil
+	case value == "t" || value == "T" || value == "true" || value == "True" || value == "TRUE",
+		value == "f" || value == "F" || value == "false" || value == "False" || value == "FALSE":
+		// boolean field
+		return nil, nil
+	case strings.HasSuffix(value, "i"):
+		v, err := strconv.ParseInt(strings.TrimSuffix(value, "i"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", value)
+		}
+		m := models.CounterMetric(models.Counter{
+			Name:   name,
+			Value:  models.CounterValue(v),
+			Labels: labels,
+		})
+		return &m, nil
+	case strings.HasSuffix(value, "u"):
+		v, err := strconv.ParseUint(strings.TrimSuffix(value, "u"), 10, 64)
+		if err != nil || v > math.MaxInt64 {
+			return nil, fmt.Errorf("invalid unsigned integer %q", value)
+		}
+		m := models.CounterMetric(models.Counter{
+			Name:   name,
+			Value:  models.CounterValue(v),
+			Labels: labels,
+		})
+		return &m, nil
+	default:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid float %q", value)
+		}
+		m := models.GaugeMetric(models.Gauge{
+			Name:   name,
+			Value:  models.GaugeValue(v),
+			Labels: labels,
+		})
+		return &m, nil
+	}
+}
+
+// split key=value by first unescaped '=', unescape key
+// and tag value, field value is returned as is
+func keyValue(s string, field bool) (string, string, error) {
+	parts := split(s, '=', field)
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("%q is not key=value", s)
+	}
+	key := unescape(parts[0])
+	value := s[len(parts[0])+1:]
+	if key == "" || value == "" {
+		return "", "", fmt.Errorf("%q has empty key or value", s)
+	}
+	if !field {
+		value = unescape(value)
+	}
+	return key, value, nil
+}
+
+// split by unescaped separator, if quoted is set
+// separators inside "..." are ignored too
+func split(s string, sep byte, quoted bool) []string {
+	var parts []string
+	start := 0
+	inQuotes := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"' && quoted:
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case ',', ' ', '=', '"', '\\':
+				i++
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,50 @@
+package influx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestParseLine(t *testing.T) {
+	testCases := []struct {
+		line     string
+		expected models.Metrics
+	}{
+		{
+			line:     "# comment",
+			expected: nil,
+		},
+		{
+			line: `disk\ io,path=/mnt/my\ disk read=1u,busy=t,name="a b,c" 1700000000`,
+			expected: models.Metrics{
+				models.CounterMetric(models.Counter{
+					Name:   "disk io.read",
+					Value:  1,
+					Labels: models.Labels{"path": "/mnt/my disk"},
+				}),
+			},
+		},
+		{
+			line: "load,host=a,env=prod avg1=-1.5e-3",
+			expected: models.Metrics{
+				models.GaugeMetric(models.Gauge{
+					Name:   "load.avg1",
+					Value:  -1.5e-3,
+					Labels: models.Labels{"host": "a", "env": "prod"},
+				}),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.line, func(t *testing.T) {
+			metrics, err := ParseLine(tc.line)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, metrics)
+		})
+	}
+}
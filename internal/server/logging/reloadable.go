@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"sync/atomic"
+
+	"github.com/stepkareserva/obsermon/internal/server/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// log which mode may be changed on the fly,
+// loggers derived from it follow the changes too
+type Reloadable struct {
+	core *swapCore
+	log  *zap.Logger
+}
+
+func NewReloadable(m config.AppMode) (*Reloadable, error) {
+	log, err := New(m)
+	if err != nil {
+		return nil, err
+	}
+	current := &atomic.Pointer[zapcore.Core]{}
+	core := log.Core()
+	current.Store(&core)
+
+	swap := &swapCore{current: current}
+	return &Reloadable{
+		core: swap,
+		log:  zap.New(swap, zap.AddStacktrace(zapcore.ErrorLevel)),
+	}, nil
+}
+
+func (r *Reloadable) Logger() *zap.Logger {
+	return r.log
+}
+
+func (r *Reloadable) SetMode(m config.AppMode) error {
+	log, err := New(m)
+	if err != nil {
+		return err
+	}
+	core := log.Core()
+	old := r.core.current.Swap(&core)
+	// flush buffered entries of replaced core
+	_ = (*old).Sync()
+	return nil
+}
+
+// core delegating to the current one,
+// with fields added by With applied to it
+type swapCore struct {
+	current *atomic.Pointer[zapcore.Core]
+	fields  []zapcore.Field
+}
+
+func (c *swapCore) core() zapcore.Core {
+	core := *c.current.Load()
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+	return core
+}
+
+func (c *swapCore) Enabled(level zapcore.Level) bool {
+	return (*c.current.Load()).Enabled(level)
+}
+
+func (c *swapCore) With(fields []zapcore.Field) zapcore.Core {
+	return &swapCore{
+		current: c.current,
+		fields:  append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *swapCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.core().Check(entry, checked)
+}
+
+func (c *swapCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.core().Write(entry, fields)
+}
+
+func (c *swapCore) Sync() error {
+	return (*c.current.Load()).Sync()
+}
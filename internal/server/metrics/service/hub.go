@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// buffer of every subscription, subscriber which
+// does not keep up with it is dropped
+const subscriptionBuffer = 256
+
+// change notification hub, fan-outs updated metrics
+// to subscribers without blocking of publisher
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+type subscription struct {
+	ch    chan models.Metric
+	names map[string]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*subscription]struct{})}
+}
+
+// subscribe on updates of metrics with passed names, all metrics
+// if names are empty. channel is closed when ctx is done or
+// if subscriber is too slow and was dropped.
+func (h *Hub) Subscribe(ctx context.Context, names []string) <-chan models.Metric {
+	sub := &subscription{
+		ch: make(chan models.Metric, subscriptionBuffer),
+	}
+	if len(names) > 0 {
+		sub.names = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			sub.names[name] = struct{}{}
+		}
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.unsubscribe(sub)
+	}()
+
+	return sub.ch
+}
+
+func (h *Hub) Publish(metrics ...models.Metric) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		if !sub.send(metrics) {
+			// slow subscriber, drop it to don't block others
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func (h *Hub) unsubscribe(sub *subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// false if subscriber's buffer is full
+func (s *subscription) send(metrics []models.Metric) bool {
+	for _, m := range metrics {
+		if !s.match(m) {
+			continue
+		}
+		select {
+		case s.ch <- m:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (s *subscription) match(m models.Metric) bool {
+	if s.names == nil {
+		return true
+	}
+	_, ok := s.names[m.ID]
+	return ok
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestHub(t *testing.T) {
+	gauge := models.GaugeMetric(models.Gauge{Name: "gauge", Value: 1})
+	counter := models.CounterMetric(models.Counter{Name: "counter", Value: 1})
+
+	t.Run("test filter", func(t *testing.T) {
+		hub := NewHub()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		all := hub.Subscribe(ctx, nil)
+		gauges := hub.Subscribe(ctx, []string{"gauge"})
+		hub.Publish(gauge, counter)
+
+		assert.Equal(t, gauge, <-all)
+		assert.Equal(t, counter, <-all)
+		assert.Equal(t, gauge, <-gauges)
+		assert.Empty(t, gauges)
+	})
+
+	t.Run("test unsubscribe", func(t *testing.T) {
+		hub := NewHub()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		updates := hub.Subscribe(ctx, nil)
+		cancel()
+		for range updates {
+		}
+		hub.Publish(gauge)
+	})
+
+	t.Run("test slow subscriber", func(t *testing.T) {
+		hub := NewHub()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		slow := hub.Subscribe(ctx, nil)
+		for i := 0; i <= subscriptionBuffer; i++ {
+			hub.Publish(gauge)
+		}
+
+		received := 0
+		for range slow {
+			received++
+		}
+		assert.Equal(t, subscriptionBuffer, received)
+	})
+}
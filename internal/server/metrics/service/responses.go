@@ -0,0 +1,76 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// in-memory responses of idempotent requests for storages
+// which don't keep them. least recently saved responses
+// are dropped when cache is full.
+type ResponseCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type cachedResponse struct {
+	key     string
+	resp    models.IdempotentResponse
+	expires time.Time
+}
+
+var _ IdempotencyStorage = (*ResponseCache)(nil)
+
+func NewResponseCache(size int) *ResponseCache {
+	return &ResponseCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+func (c *ResponseCache) FindResponse(ctx context.Context, key string) (*models.IdempotentResponse, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	cached := elem.Value.(*cachedResponse)
+	if !c.now().Before(cached.expires) {
+		c.remove(elem)
+		return nil, false, nil
+	}
+	resp := cached.resp
+	return &resp, true, nil
+}
+
+func (c *ResponseCache) SaveResponse(ctx context.Context, key string, resp models.IdempotentResponse, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	cached := &cachedResponse{key: key, resp: resp, expires: c.now().Add(ttl)}
+	c.entries[key] = c.order.PushBack(cached)
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Front())
+	}
+	return nil
+}
+
+func (c *ResponseCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*cachedResponse).key)
+}
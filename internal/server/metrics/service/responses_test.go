@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestResponseCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cache := NewResponseCache(2)
+	cache.now = func() time.Time { return now }
+
+	resp := func(status int) models.IdempotentResponse {
+		return models.IdempotentResponse{RequestHash: "hash", StatusCode: status}
+	}
+	require.NoError(t, cache.SaveResponse(ctx, "a", resp(200), time.Minute))
+	require.NoError(t, cache.SaveResponse(ctx, "b", resp(201), time.Second))
+
+	found, exists, err := cache.FindResponse(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, 200, found.StatusCode)
+
+	// oldest response is dropped when cache is full
+	require.NoError(t, cache.SaveResponse(ctx, "c", resp(202), time.Minute))
+	_, exists, err = cache.FindResponse(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// expired response is not returned
+	now = now.Add(2 * time.Second)
+	_, exists, err = cache.FindResponse(ctx, "b")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	_, exists, err = cache.FindResponse(ctx, "c")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
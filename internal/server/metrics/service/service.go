@@ -4,13 +4,34 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/stepkareserva/obsermon/internal/models"
 	"github.com/stepkareserva/obsermon/internal/server/http/handlers"
 )
 
+// source of current alerts state, like alerting engine
+type AlertsSource interface {
+	Alerts() models.AlertsList
+}
+
+// responses of idempotent requests are kept for repeats
+// of request by client which didn't get response in time
+const (
+	ResponseTTL       = time.Hour
+	ResponseCacheSize = 10000
+)
+
 type Service struct {
 	storage Storage
+	hub     *Hub
+	// storage itself if it keeps responses, memory cache otherwise
+	responses IdempotencyStorage
+
+	// may be replaced on config reload
+	alertsMu sync.RWMutex
+	alerts   AlertsSource
 }
 
 var _ handlers.Service = (*Service)(nil)
@@ -19,7 +40,11 @@ func New(storage Storage) (*Service, error) {
 	if storage == nil {
 		return nil, fmt.Errorf("metrics storage is nil")
 	}
-	return &Service{storage: storage}, nil
+	responses, ok := storage.(IdempotencyStorage)
+	if !ok {
+		responses = NewResponseCache(ResponseCacheSize)
+	}
+	return &Service{storage: storage, hub: NewHub(), responses: responses}, nil
 }
 
 func (s *Service) UpdateGauge(ctx context.Context, val models.Gauge) (*models.Gauge, error) {
@@ -30,6 +55,7 @@ func (s *Service) UpdateGauge(ctx context.Context, val models.Gauge) (*models.Ga
 	if err := s.storage.SetGauge(ctx, val); err != nil {
 		return nil, err
 	}
+	s.hub.Publish(models.GaugeMetric(val))
 
 	return &val, nil
 }
@@ -39,7 +65,7 @@ func (s *Service) FindGauge(ctx context.Context, name string) (*models.Gauge, bo
 		return nil, false, err
 	}
 
-	return s.storage.FindGauge(ctx, name)
+	return s.storage.FindGauge(ctx, name, nil)
 }
 
 func (s *Service) ListGauges(ctx context.Context) (models.GaugesList, error) {
@@ -52,7 +78,7 @@ func (s *Service) ListGauges(ctx context.Context) (models.GaugesList, error) {
 	}
 
 	sort.SliceStable(gauges, func(i, j int) bool {
-		return gauges[i].Name < gauges[j].Name
+		return gauges[i].Key() < gauges[j].Key()
 	})
 
 	return gauges, nil
@@ -67,6 +93,7 @@ func (s *Service) UpdateCounter(ctx context.Context, val models.Counter) (*model
 	if err != nil {
 		return nil, fmt.Errorf("update counter: %v", err)
 	}
+	s.hub.Publish(models.CounterMetric(*updatedVal))
 	return updatedVal, nil
 }
 
@@ -75,7 +102,7 @@ func (s *Service) FindCounter(ctx context.Context, name string) (*models.Counter
 		return nil, false, err
 	}
 
-	return s.storage.FindCounter(ctx, name)
+	return s.storage.FindCounter(ctx, name, nil)
 }
 
 func (s *Service) ListCounters(ctx context.Context) (models.CountersList, error) {
@@ -89,12 +116,50 @@ func (s *Service) ListCounters(ctx context.Context) (models.CountersList, error)
 	}
 
 	sort.SliceStable(counters, func(i, j int) bool {
-		return counters[i].Name < counters[j].Name
+		return counters[i].Key() < counters[j].Key()
 	})
 
 	return counters, nil
 }
 
+func (s *Service) UpdateHistogram(ctx context.Context, val models.Histogram) (*models.Histogram, error) {
+	if err := s.checkValidity(); err != nil {
+		return nil, err
+	}
+
+	updatedVal, err := s.storage.UpdateHistogram(ctx, val)
+	if err != nil {
+		return nil, fmt.Errorf("update histogram: %v", err)
+	}
+	s.hub.Publish(models.HistogramMetric(*updatedVal))
+	return updatedVal, nil
+}
+
+func (s *Service) FindHistogram(ctx context.Context, name string) (*models.Histogram, bool, error) {
+	if err := s.checkValidity(); err != nil {
+		return nil, false, err
+	}
+
+	return s.storage.FindHistogram(ctx, name, nil)
+}
+
+func (s *Service) ListHistograms(ctx context.Context) (models.HistogramsList, error) {
+	if err := s.checkValidity(); err != nil {
+		return nil, err
+	}
+
+	histograms, err := s.storage.ListHistograms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(histograms, func(i, j int) bool {
+		return histograms[i].Key() < histograms[j].Key()
+	})
+
+	return histograms, nil
+}
+
 func (s *Service) UpdateMetric(ctx context.Context, val models.Metric) (*models.Metric, error) {
 	if err := s.checkValidity(); err != nil {
 		return nil, err
@@ -123,31 +188,49 @@ func (s *Service) UpdateMetric(ctx context.Context, val models.Metric) (*models.
 		}
 		updatedMetric := models.GaugeMetric(*updated)
 		return &updatedMetric, nil
+	case models.MetricTypeHistogram:
+		histogram, err := val.AsHistogram()
+		if err != nil {
+			return nil, err
+		}
+		updated, err := s.UpdateHistogram(ctx, *histogram)
+		if err != nil {
+			return nil, err
+		}
+		updatedMetric := models.HistogramMetric(*updated)
+		return &updatedMetric, nil
 	default:
 		return nil, fmt.Errorf("unknown metric type")
 	}
 }
 
-func (s *Service) FindMetric(ctx context.Context, t models.MetricType, name string) (*models.Metric, bool, error) {
+func (s *Service) FindMetric(ctx context.Context, t models.MetricType, name string, labels models.Labels) (*models.Metric, bool, error) {
 	if err := s.checkValidity(); err != nil {
 		return nil, false, err
 	}
 
 	switch t {
 	case models.MetricTypeCounter:
-		c, exists, err := s.FindCounter(ctx, name)
+		c, exists, err := s.storage.FindCounter(ctx, name, labels)
 		if err != nil || !exists {
 			return nil, exists, err
 		}
 		m := models.CounterMetric(*c)
 		return &m, true, nil
 	case models.MetricTypeGauge:
-		g, exists, err := s.FindGauge(ctx, name)
+		g, exists, err := s.storage.FindGauge(ctx, name, labels)
 		if err != nil || !exists {
 			return nil, exists, err
 		}
 		m := models.GaugeMetric(*g)
 		return &m, true, nil
+	case models.MetricTypeHistogram:
+		h, exists, err := s.storage.FindHistogram(ctx, name, labels)
+		if err != nil || !exists {
+			return nil, exists, err
+		}
+		m := models.HistogramMetric(*h)
+		return &m, true, nil
 	default:
 		return nil, false, fmt.Errorf("unknown metric type")
 	}
@@ -158,25 +241,103 @@ func (s *Service) UpdateMetrics(ctx context.Context, vals models.Metrics) (model
 		return nil, err
 	}
 
-	// get counters and gauges from metrics
-	counters, gauges, err := splitMetrics(vals)
+	// get counters, gauges and histograms from metrics
+	counters, gauges, histograms, err := splitMetrics(vals)
 	if err != nil {
 		return nil, fmt.Errorf("split metrics: %v", err)
 	}
 
-	// update counters and gauges
-	counters, err = s.storage.UpdateCounters(ctx, counters)
+	updated, err := s.updateBatch(ctx, Batch{Counters: counters, Gauges: gauges, Histograms: histograms})
 	if err != nil {
-		return nil, fmt.Errorf("update counters: %v", err)
+		return nil, err
 	}
-	err = s.storage.SetGauges(ctx, gauges)
+
+	metrics := mergeMetrics(updated.Counters, updated.Gauges, updated.Histograms)
+	s.hub.Publish(metrics...)
+
+	return metrics, nil
+}
+
+func (s *Service) updateBatch(ctx context.Context, batch Batch) (*Batch, error) {
+	if batchStorage, ok := s.storage.(BatchStorage); ok {
+		updated, err := batchStorage.UpdateBatch(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("update batch: %v", err)
+		}
+		return updated, nil
+	}
+
+	// storage can't apply batch atomically, histograms are
+	// updated first as they are rejected on bounds mismatch
+	histograms, err := s.storage.UpdateHistograms(ctx, batch.Histograms)
+	if err != nil {
+		return nil, fmt.Errorf("update histograms: %v", err)
+	}
+	counters, err := s.storage.UpdateCounters(ctx, batch.Counters)
 	if err != nil {
+		return nil, fmt.Errorf("update counters: %v", err)
+	}
+	if err = s.storage.SetGauges(ctx, batch.Gauges); err != nil {
 		return nil, fmt.Errorf("update gauges: %v", err)
 	}
+	return &Batch{Counters: counters, Gauges: batch.Gauges, Histograms: histograms}, nil
+}
 
-	metrics := mergeMetrics(counters, gauges)
+func (s *Service) GaugeHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.GaugeSamples, error) {
+	history, err := s.historyStorage()
+	if err != nil {
+		return nil, err
+	}
+	return history.GaugeHistory(ctx, name, labels, from, to)
+}
 
-	return metrics, nil
+func (s *Service) CounterHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.CounterSamples, error) {
+	history, err := s.historyStorage()
+	if err != nil {
+		return nil, err
+	}
+	return history.CounterHistory(ctx, name, labels, from, to)
+}
+
+func (s *Service) Subscribe(ctx context.Context, names []string) (<-chan models.Metric, error) {
+	if err := s.checkValidity(); err != nil {
+		return nil, err
+	}
+	return s.hub.Subscribe(ctx, names), nil
+}
+
+// alerts are optional, service without alerts source has no alerts
+func (s *Service) SetAlertsSource(alerts AlertsSource) {
+	s.alertsMu.Lock()
+	defer s.alertsMu.Unlock()
+	s.alerts = alerts
+}
+
+func (s *Service) ListAlerts(ctx context.Context) (models.AlertsList, error) {
+	if err := s.checkValidity(); err != nil {
+		return nil, err
+	}
+	s.alertsMu.RLock()
+	alerts := s.alerts
+	s.alertsMu.RUnlock()
+	if alerts == nil {
+		return models.AlertsList{}, nil
+	}
+	return alerts.Alerts(), nil
+}
+
+func (s *Service) FindResponse(ctx context.Context, key string) (*models.IdempotentResponse, bool, error) {
+	if err := s.checkValidity(); err != nil {
+		return nil, false, err
+	}
+	return s.responses.FindResponse(ctx, key)
+}
+
+func (s *Service) SaveResponse(ctx context.Context, key string, resp models.IdempotentResponse) error {
+	if err := s.checkValidity(); err != nil {
+		return err
+	}
+	return s.responses.SaveResponse(ctx, key, resp, ResponseTTL)
 }
 
 func (s *Service) Ping(ctx context.Context) error {
@@ -191,6 +352,17 @@ func (s *Service) Ping(ctx context.Context) error {
 	return pingable.Ping(ctx)
 }
 
+func (s *Service) historyStorage() (HistoryStorage, error) {
+	if err := s.checkValidity(); err != nil {
+		return nil, err
+	}
+	history, ok := s.storage.(HistoryStorage)
+	if !ok {
+		return nil, fmt.Errorf("Storage has no history")
+	}
+	return history, nil
+}
+
 func (s *Service) checkValidity() error {
 	if s == nil || s.storage == nil {
 		return fmt.Errorf("Service not exists")
@@ -198,37 +370,47 @@ func (s *Service) checkValidity() error {
 	return nil
 }
 
-func splitMetrics(vals models.Metrics) (models.CountersList, models.GaugesList, error) {
+func splitMetrics(vals models.Metrics) (models.CountersList, models.GaugesList, models.HistogramsList, error) {
 	var counters models.CountersList
 	var gauges models.GaugesList
+	var histograms models.HistogramsList
 	for _, val := range vals {
 		switch val.MType {
 		case models.MetricTypeCounter:
 			counter, err := val.Counter()
 			if err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
 			counters = append(counters, *counter)
 		case models.MetricTypeGauge:
 			gauge, err := val.Gauge()
 			if err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
 			gauges = append(gauges, *gauge)
+		case models.MetricTypeHistogram:
+			histogram, err := val.AsHistogram()
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			histograms = append(histograms, *histogram)
 		default:
-			return nil, nil, fmt.Errorf("unknown metric type")
+			return nil, nil, nil, fmt.Errorf("unknown metric type")
 		}
 	}
-	return counters, gauges, nil
+	return counters, gauges, histograms, nil
 }
 
-func mergeMetrics(counters models.CountersList, gauges models.GaugesList) models.Metrics {
-	metrics := make(models.Metrics, 0, len(counters)+len(gauges))
+func mergeMetrics(counters models.CountersList, gauges models.GaugesList, histograms models.HistogramsList) models.Metrics {
+	metrics := make(models.Metrics, 0, len(counters)+len(gauges)+len(histograms))
 	for _, counter := range counters {
 		metrics = append(metrics, models.CounterMetric(counter))
 	}
 	for _, gauge := range gauges {
 		metrics = append(metrics, models.GaugeMetric(gauge))
 	}
+	for _, histogram := range histograms {
+		metrics = append(metrics, models.HistogramMetric(histogram))
+	}
 	return metrics
 }
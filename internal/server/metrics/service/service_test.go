@@ -29,7 +29,7 @@ func TestGaugeService(t *testing.T) {
 
 		mockStorage.
 			EXPECT().
-			FindGauge(context.TODO(), "name").
+			FindGauge(context.TODO(), "name", gomock.Nil()).
 			Return(&models.Gauge{
 				Name:  "name",
 				Value: 1.0,
@@ -59,18 +59,20 @@ func TestCounterService(t *testing.T) {
 			UpdateCounter(context.TODO(), models.Counter{
 				Name:  "name",
 				Value: 1,
-			})
+			}).
+			Return(&models.Counter{Name: "name", Value: 1}, nil)
 
 		mockStorage.
 			EXPECT().
 			UpdateCounter(context.TODO(), models.Counter{
 				Name:  "name",
 				Value: 2,
-			})
+			}).
+			Return(&models.Counter{Name: "name", Value: 3}, nil)
 
 		mockStorage.
 			EXPECT().
-			FindCounter(context.TODO(), "name").
+			FindCounter(context.TODO(), "name", gomock.Nil()).
 			Return(&models.Counter{
 				Name:  "name",
 				Value: 3,
@@ -87,3 +89,77 @@ func TestCounterService(t *testing.T) {
 		assert.Equal(t, counter, &models.Counter{Name: "name", Value: 3})
 	})
 }
+
+func TestLabeledMetricService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mocks.NewMockStorage(ctrl)
+	service, err := New(mockStorage)
+	require.NoError(t, err, "service initialization error")
+
+	t.Run("test labeled gauge", func(t *testing.T) {
+		labels := models.Labels{"host": "a"}
+
+		mockStorage.
+			EXPECT().
+			FindGauge(context.TODO(), "name", labels).
+			Return(&models.Gauge{
+				Name:   "name",
+				Value:  1.0,
+				Labels: labels,
+			}, true, nil)
+
+		metric, exists, err := service.FindMetric(context.TODO(), models.MetricTypeGauge, "name", labels)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, labels, metric.Labels)
+	})
+}
+
+func TestHistogramService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mocks.NewMockStorage(ctrl)
+	service, err := New(mockStorage)
+	require.NoError(t, err, "service initialization error")
+
+	t.Run("test histograms in batch", func(t *testing.T) {
+		value := func(counts ...uint64) *models.HistogramValue {
+			v := &models.HistogramValue{Bounds: []float64{1}, Counts: counts}
+			for _, c := range counts {
+				v.Count += c
+				v.Sum += float64(c)
+			}
+			return v
+		}
+		metrics := models.Metrics{
+			{ID: "name", MType: models.MetricTypeHistogram, Histogram: value(1, 0)},
+			{ID: "name", MType: models.MetricTypeHistogram, Histogram: value(0, 2)},
+		}
+		merged := models.HistogramsList{{Name: "name", Value: *value(1, 2)}}
+
+		mockStorage.EXPECT().UpdateCounters(context.TODO(), gomock.Len(0)).Return(nil, nil)
+		mockStorage.EXPECT().SetGauges(context.TODO(), gomock.Len(0))
+		mockStorage.
+			EXPECT().
+			UpdateHistograms(context.TODO(), gomock.Len(2)).
+			Return(merged, nil)
+
+		updated, err := service.UpdateMetrics(context.TODO(), metrics)
+		require.NoError(t, err)
+		assert.Equal(t, models.Metrics{
+			{ID: "name", MType: models.MetricTypeHistogram, Histogram: value(1, 2)},
+		}, updated)
+	})
+
+	t.Run("test invalid histogram", func(t *testing.T) {
+		_, err := service.UpdateMetric(context.TODO(), models.Metric{
+			ID:        "name",
+			MType:     models.MetricTypeHistogram,
+			Histogram: &models.HistogramValue{Bounds: []float64{1}, Counts: []uint64{1}},
+		})
+		assert.Error(t, err)
+	})
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/stepkareserva/obsermon/internal/models"
 )
@@ -9,7 +10,7 @@ import (
 type GaugeStorage interface {
 	SetGauge(ctx context.Context, val models.Gauge) error
 	SetGauges(ctx context.Context, val models.GaugesList) error
-	FindGauge(ctx context.Context, name string) (*models.Gauge, bool, error)
+	FindGauge(ctx context.Context, name string, labels models.Labels) (*models.Gauge, bool, error)
 	ListGauges(ctx context.Context) (models.GaugesList, error)
 	ReplaceGauges(ctx context.Context, val models.GaugesList) error
 }
@@ -19,11 +20,50 @@ type CounterOp = func(val *models.CounterValue) error
 type CounterStorage interface {
 	UpdateCounter(ctx context.Context, val models.Counter) (*models.Counter, error)
 	UpdateCounters(ctx context.Context, vals models.CountersList) (models.CountersList, error)
-	FindCounter(ctx context.Context, name string) (*models.Counter, bool, error)
+	FindCounter(ctx context.Context, name string, labels models.Labels) (*models.Counter, bool, error)
 	ListCounters(ctx context.Context) (models.CountersList, error)
 	ReplaceCounters(ctx context.Context, val models.CountersList) error
 }
 
+// max count of samples kept by history storage
+// for every gauge and counter series
+const HistoryDepth = 1024
+
+// storage which keeps timestamped sample on each
+// gauges and counters modification
+type HistoryStorage interface {
+	GaugeHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.GaugeSamples, error)
+	CounterHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.CounterSamples, error)
+}
+
+type HistogramStorage interface {
+	UpdateHistogram(ctx context.Context, val models.Histogram) (*models.Histogram, error)
+	UpdateHistograms(ctx context.Context, vals models.HistogramsList) (models.HistogramsList, error)
+	FindHistogram(ctx context.Context, name string, labels models.Labels) (*models.Histogram, bool, error)
+	ListHistograms(ctx context.Context) (models.HistogramsList, error)
+	ReplaceHistograms(ctx context.Context, val models.HistogramsList) error
+}
+
+// counters, gauges and histograms of one update request
+type Batch struct {
+	Counters   models.CountersList
+	Gauges     models.GaugesList
+	Histograms models.HistogramsList
+}
+
+// storage which applies the whole batch or nothing of it,
+// so failed request may be retried without double counting
+type BatchStorage interface {
+	UpdateBatch(ctx context.Context, batch Batch) (*Batch, error)
+}
+
+// storage which keeps responses of idempotent requests,
+// expired ones are not returned
+type IdempotencyStorage interface {
+	FindResponse(ctx context.Context, key string) (*models.IdempotentResponse, bool, error)
+	SaveResponse(ctx context.Context, key string, resp models.IdempotentResponse, ttl time.Duration) error
+}
+
 type Pingable interface {
 	Ping(ctx context.Context) error
 }
@@ -33,4 +73,5 @@ type Pingable interface {
 type Storage interface {
 	GaugeStorage
 	CounterStorage
+	HistogramStorage
 }
@@ -0,0 +1,32 @@
+package dbstorage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// histogram bounds and counts are stored as json arrays text
+
+func EncodeBuckets(val models.HistogramValue) (bounds string, counts string, err error) {
+	encodedBounds, err := json.Marshal(val.Bounds)
+	if err != nil {
+		return "", "", fmt.Errorf("bounds encoding: %w", err)
+	}
+	encodedCounts, err := json.Marshal(val.Counts)
+	if err != nil {
+		return "", "", fmt.Errorf("counts encoding: %w", err)
+	}
+	return string(encodedBounds), string(encodedCounts), nil
+}
+
+func DecodeBuckets(bounds string, counts string, val *models.HistogramValue) error {
+	if err := json.Unmarshal([]byte(bounds), &val.Bounds); err != nil {
+		return fmt.Errorf("bounds decoding: %w", err)
+	}
+	if err := json.Unmarshal([]byte(counts), &val.Counts); err != nil {
+		return fmt.Errorf("counts decoding: %w", err)
+	}
+	return nil
+}
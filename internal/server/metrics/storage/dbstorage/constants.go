@@ -6,8 +6,29 @@ const (
 	CountersTable = "counters"
 	GaugesTable   = "gauges"
 
-	NameColumn  = "name"
-	ValueColumn = "value"
+	HistogramsTable = "histograms"
+
+	CounterSamplesTable = "counter_samples"
+	GaugeSamplesTable   = "gauge_samples"
+
+	IdempotencyKeysTable = "idempotency_keys"
+
+	NameColumn   = "name"
+	LabelsColumn = "labels"
+	ValueColumn  = "value"
+	TimeColumn   = "ts"
+
+	BoundsColumn = "bounds"
+	CountsColumn = "counts"
+	SumColumn    = "sum"
+	CountColumn  = "count"
+
+	IdempotencyKeyColumn = "idempotency_key"
+	RequestHashColumn    = "request_hash"
+	StatusColumn         = "status"
+	ContentTypeColumn    = "content_type"
+	BodyColumn           = "body"
+	ExpiresColumn        = "expires_at"
 )
 
 const (
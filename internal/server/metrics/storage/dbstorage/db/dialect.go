@@ -0,0 +1,99 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// prefix of sqlite connection string, like sqlite://path/to/db.sqlite
+const sqlitePrefix = "sqlite://"
+
+// queries are written for postgres, other dialects
+// rewrite them and their args before execution
+type dialect struct {
+	// database/sql driver name
+	driver string
+	// driver connection string
+	dsn string
+	// goose dialect and migrations dir
+	gooseDialect  string
+	migrationsDir string
+	// max open connections, 0 for unlimited
+	maxOpenConns int
+	// connections lifetime, 0 for unlimited
+	connMaxLifetime time.Duration
+	connMaxIdleTime time.Duration
+
+	rebind func(query string) string
+	args   func(args []any) []any
+}
+
+func parseDialect(dbConn string) dialect {
+	if path, ok := strings.CutPrefix(dbConn, sqlitePrefix); ok {
+		return sqliteDialect(path)
+	}
+	return postgresDialect(dbConn)
+}
+
+func postgresDialect(dbConn string) dialect {
+	return dialect{
+		driver:          "pgx",
+		dsn:             dbConn,
+		gooseDialect:    "postgres",
+		migrationsDir:   "migrations",
+		maxOpenConns:    16,
+		connMaxLifetime: 30 * time.Minute,
+		connMaxIdleTime: 300 * time.Minute,
+		rebind:          func(query string) string { return query },
+		args:            func(args []any) []any { return args },
+	}
+}
+
+var (
+	// sqlite binds $N by first appearance order, ?N by number
+	placeholderRe = regexp.MustCompile(`\$(\d+)`)
+	// sqlite has no row locks, it locks whole db on write
+	forUpdateRe = regexp.MustCompile(`(?i)\s+FOR\s+UPDATE`)
+)
+
+func sqliteDialect(path string) dialect {
+	// immediate transactions take write lock at start,
+	// so concurrent transactions wait on busy timeout
+	// instead of failing on lock upgrade
+	options := "_busy_timeout=5000&_txlock=immediate"
+	dsn := "file:" + path
+	if strings.Contains(path, "?") {
+		dsn += "&" + options
+	} else {
+		dsn += "?" + options
+	}
+
+	return dialect{
+		driver:        "sqlite3",
+		dsn:           dsn,
+		gooseDialect:  "sqlite3",
+		migrationsDir: "migrations/sqlite",
+		// single writer anyway, and in-memory db lives
+		// until its connection is closed
+		maxOpenConns: 1,
+		rebind: func(query string) string {
+			query = placeholderRe.ReplaceAllString(query, "?$1")
+			return forUpdateRe.ReplaceAllString(query, "")
+		},
+		args: func(args []any) []any {
+			// sqlite stores time as text, keep it comparable
+			converted := make([]any, len(args))
+			for i, arg := range args {
+				if t, ok := arg.(time.Time); ok {
+					arg = t.UTC()
+				}
+				converted[i] = arg
+			}
+			return converted
+		},
+	}
+}
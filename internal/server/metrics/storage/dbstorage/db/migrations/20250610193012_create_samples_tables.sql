@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS counter_samples (
+    name TEXT NOT NULL,
+    ts TIMESTAMPTZ NOT NULL,
+    value BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS counter_samples_name_ts_idx
+    ON counter_samples (name, ts);
+
+CREATE TABLE IF NOT EXISTS gauge_samples (
+    name TEXT NOT NULL,
+    ts TIMESTAMPTZ NOT NULL,
+    value DOUBLE PRECISION NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS gauge_samples_name_ts_idx
+    ON gauge_samples (name, ts);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE gauge_samples;
+
+DROP TABLE counter_samples;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE counters ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE counters DROP CONSTRAINT IF EXISTS counters_pkey;
+ALTER TABLE counters ADD PRIMARY KEY (name, labels);
+
+ALTER TABLE gauges ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE gauges DROP CONSTRAINT IF EXISTS gauges_pkey;
+ALTER TABLE gauges ADD PRIMARY KEY (name, labels);
+
+ALTER TABLE counter_samples ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '{}';
+DROP INDEX IF EXISTS counter_samples_name_ts_idx;
+CREATE INDEX IF NOT EXISTS counter_samples_name_labels_ts_idx
+    ON counter_samples (name, labels, ts);
+
+ALTER TABLE gauge_samples ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '{}';
+DROP INDEX IF EXISTS gauge_samples_name_ts_idx;
+CREATE INDEX IF NOT EXISTS gauge_samples_name_labels_ts_idx
+    ON gauge_samples (name, labels, ts);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS gauge_samples_name_labels_ts_idx;
+DELETE FROM gauge_samples WHERE labels <> '{}';
+ALTER TABLE gauge_samples DROP COLUMN labels;
+CREATE INDEX IF NOT EXISTS gauge_samples_name_ts_idx
+    ON gauge_samples (name, ts);
+
+DROP INDEX IF EXISTS counter_samples_name_labels_ts_idx;
+DELETE FROM counter_samples WHERE labels <> '{}';
+ALTER TABLE counter_samples DROP COLUMN labels;
+CREATE INDEX IF NOT EXISTS counter_samples_name_ts_idx
+    ON counter_samples (name, ts);
+
+DELETE FROM gauges WHERE labels <> '{}';
+ALTER TABLE gauges DROP CONSTRAINT gauges_pkey;
+ALTER TABLE gauges DROP COLUMN labels;
+ALTER TABLE gauges ADD PRIMARY KEY (name);
+
+DELETE FROM counters WHERE labels <> '{}';
+ALTER TABLE counters DROP CONSTRAINT counters_pkey;
+ALTER TABLE counters DROP COLUMN labels;
+ALTER TABLE counters ADD PRIMARY KEY (name);
+-- +goose StatementEnd
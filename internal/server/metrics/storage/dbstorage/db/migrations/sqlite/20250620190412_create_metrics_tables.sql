@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS counters (
+    name TEXT NOT NULL,
+    labels TEXT NOT NULL DEFAULT '{}',
+    value BIGINT NOT NULL,
+    PRIMARY KEY (name, labels)
+);
+
+CREATE TABLE IF NOT EXISTS gauges (
+    name TEXT NOT NULL,
+    labels TEXT NOT NULL DEFAULT '{}',
+    value DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (name, labels)
+);
+
+CREATE TABLE IF NOT EXISTS counter_samples (
+    name TEXT NOT NULL,
+    labels TEXT NOT NULL DEFAULT '{}',
+    ts TIMESTAMP NOT NULL,
+    value BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS counter_samples_name_labels_ts_idx
+    ON counter_samples (name, labels, ts);
+
+CREATE TABLE IF NOT EXISTS gauge_samples (
+    name TEXT NOT NULL,
+    labels TEXT NOT NULL DEFAULT '{}',
+    ts TIMESTAMP NOT NULL,
+    value DOUBLE PRECISION NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS gauge_samples_name_labels_ts_idx
+    ON gauge_samples (name, labels, ts);
+
+CREATE TABLE IF NOT EXISTS histograms (
+    name TEXT NOT NULL,
+    labels TEXT NOT NULL DEFAULT '{}',
+    bounds TEXT NOT NULL,
+    counts TEXT NOT NULL,
+    sum DOUBLE PRECISION NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (name, labels)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE histograms;
+
+DROP TABLE gauge_samples;
+
+DROP TABLE counter_samples;
+
+DROP TABLE gauges;
+
+DROP TABLE counters;
+-- +goose StatementEnd
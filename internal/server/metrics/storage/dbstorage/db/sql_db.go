@@ -9,12 +9,10 @@ import (
 	"time"
 
 	"go.uber.org/zap"
-
-	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 type SQLDB struct {
-	dbConn string
+	dialect dialect
 
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
@@ -32,8 +30,8 @@ func NewSQLDB(dbConn string, log *zap.Logger) *SQLDB {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	d := &SQLDB{
-		dbConn: dbConn,
-		cancel: cancel,
+		dialect: parseDialect(dbConn),
+		cancel:  cancel,
 	}
 
 	// run connection loop
@@ -68,20 +66,20 @@ func (d *SQLDB) keepConnection(ctx context.Context, log *zap.Logger) {
 	}
 
 	// try to reconnect
-	sqlDB, err := sql.Open("pgx", d.dbConn)
+	sqlDB, err := sql.Open(d.dialect.driver, d.dialect.dsn)
 	if err != nil {
 		log.Warn("db reconnect", zap.Error(err))
 		return
 	}
 
 	// set connection params (no idea what's good for our service)
-	sqlDB.SetMaxOpenConns(16)
-	sqlDB.SetMaxIdleConns(8)
-	sqlDB.SetConnMaxLifetime(30 * time.Minute)
-	sqlDB.SetConnMaxIdleTime(300 * time.Minute)
+	sqlDB.SetMaxOpenConns(d.dialect.maxOpenConns)
+	sqlDB.SetMaxIdleConns(max(d.dialect.maxOpenConns/2, 1))
+	sqlDB.SetConnMaxLifetime(d.dialect.connMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(d.dialect.connMaxIdleTime)
 
 	// try to migrate
-	if err = Migrate(ctx, sqlDB); err != nil {
+	if err = migrate(ctx, sqlDB, d.dialect); err != nil {
 		log.Warn("db migration", zap.Error(err))
 		return
 	}
@@ -124,7 +122,7 @@ func (d *SQLDB) BeginTx(ctx context.Context) (Tx, error) {
 		return nil, fmt.Errorf("begin tx: %v", err)
 	}
 
-	return &sqlTx{Tx: tx}, nil
+	return &sqlTx{Tx: tx, dialect: &d.dialect}, nil
 }
 
 func (d *SQLDB) PingContext(ctx context.Context) error {
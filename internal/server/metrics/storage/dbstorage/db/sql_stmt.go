@@ -8,12 +8,13 @@ import (
 // Stmt impl
 type sqlStmt struct {
 	*sql.Stmt
+	dialect *dialect
 }
 
 var _ Stmt = (*sqlStmt)(nil)
 
 func (stmt *sqlStmt) ExecContext(ctx context.Context, args ...any) (Result, error) {
-	res, err := stmt.Stmt.ExecContext(ctx, args...)
+	res, err := stmt.Stmt.ExecContext(ctx, stmt.dialect.args(args)...)
 	if err != nil {
 		return nil, err
 	}
@@ -21,7 +22,7 @@ func (stmt *sqlStmt) ExecContext(ctx context.Context, args ...any) (Result, erro
 }
 
 func (stmt *sqlStmt) QueryContext(ctx context.Context, args ...any) (Rows, error) {
-	rows, err := stmt.Stmt.QueryContext(ctx, args...)
+	rows, err := stmt.Stmt.QueryContext(ctx, stmt.dialect.args(args)...)
 	if err != nil {
 		return nil, err
 	}
@@ -8,20 +8,21 @@ import (
 // Tx impl
 type sqlTx struct {
 	*sql.Tx
+	dialect *dialect
 }
 
 var _ Tx = (*sqlTx)(nil)
 
 func (tx *sqlTx) PrepareContext(ctx context.Context, query string) (Stmt, error) {
-	stmt, err := tx.Tx.PrepareContext(ctx, query)
+	stmt, err := tx.Tx.PrepareContext(ctx, tx.dialect.rebind(query))
 	if err != nil {
 		return nil, err
 	}
-	return &sqlStmt{Stmt: stmt}, nil
+	return &sqlStmt{Stmt: stmt, dialect: tx.dialect}, nil
 }
 
 func (tx *sqlTx) ExecContext(ctx context.Context, query string, args ...any) (Result, error) {
-	res, err := tx.Tx.ExecContext(ctx, query, args...)
+	res, err := tx.Tx.ExecContext(ctx, tx.dialect.rebind(query), tx.dialect.args(args)...)
 	if err != nil {
 		return nil, err
 	}
@@ -29,7 +30,7 @@ func (tx *sqlTx) ExecContext(ctx context.Context, query string, args ...any) (Re
 }
 
 func (tx *sqlTx) QueryContext(ctx context.Context, query string, args ...any) (Rows, error) {
-	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	rows, err := tx.Tx.QueryContext(ctx, tx.dialect.rebind(query), tx.dialect.args(args)...)
 	if err != nil {
 		return nil, err
 	}
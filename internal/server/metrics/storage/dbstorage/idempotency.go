@@ -0,0 +1,85 @@
+package dbstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/metrics/service"
+	"github.com/stepkareserva/obsermon/internal/server/metrics/storage/dbstorage/db"
+)
+
+func SelectResponse(ctx context.Context, uow *UnitOfWork, key string, now time.Time) (*models.IdempotentResponse, bool, error) {
+	var resp *models.IdempotentResponse
+
+	txFn := func(ctx context.Context, tx db.Tx) (err error) {
+		rows, err := tx.QueryContext(ctx, findResponseQuery, key, now)
+		if err != nil {
+			return fmt.Errorf("query response: %w", err)
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("rows closing: %w", closeErr))
+			}
+		}()
+
+		resp = nil
+		for rows.Next() {
+			var found models.IdempotentResponse
+			var body string
+			if err := rows.Scan(&found.RequestHash, &found.StatusCode, &found.ContentType, &body); err != nil {
+				return fmt.Errorf("response row scan: %w", err)
+			}
+			found.Body = []byte(body)
+			resp = &found
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows err: %w", err)
+		}
+		return nil
+	}
+
+	if err := uow.Do(ctx, txFn); err != nil {
+		return nil, false, err
+	}
+	return resp, resp != nil, nil
+}
+
+// key of request in ctx is saved in update transaction with
+// lost response, so repeat of the request after crash or failed
+// response saving is not applied again
+func insertAppliedKey(ctx context.Context, tx db.Tx) error {
+	key, ok := models.IdempotencyKeyFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	expires := time.Now().Add(service.ResponseTTL)
+	if _, err := tx.ExecContext(ctx, saveAppliedKeyQuery, key.Key, key.RequestHash, expires); err != nil {
+		return fmt.Errorf("insert idempotency key: %w", err)
+	}
+	return nil
+}
+
+// expired responses are deleted on saving of new one,
+// and the oldest ones if there are more than limit,
+// like in memory responses cache
+func InsertResponse(ctx context.Context, uow *UnitOfWork, key string, resp models.IdempotentResponse,
+	now, expires time.Time, limit int) error {
+	txFn := func(ctx context.Context, tx db.Tx) error {
+		if _, err := tx.ExecContext(ctx, deleteExpiredResponsesQuery, now); err != nil {
+			return fmt.Errorf("delete expired responses: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, saveResponseQuery, key, resp.RequestHash,
+			resp.StatusCode, resp.ContentType, string(resp.Body), expires); err != nil {
+			return fmt.Errorf("insert response: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, deleteOldestResponsesQuery, limit); err != nil {
+			return fmt.Errorf("delete oldest responses: %w", err)
+		}
+		return nil
+	}
+
+	return uow.Do(ctx, txFn)
+}
@@ -0,0 +1,34 @@
+package dbstorage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// labels are stored as json object text. json.Marshal sorts
+// map keys, so equal labels always have the same text and
+// text column can be used as part of primary key.
+
+func EncodeLabels(labels models.Labels) (string, error) {
+	if len(labels) == 0 {
+		return "{}", nil
+	}
+	encoded, err := json.Marshal(labels)
+	if err != nil {
+		return "", fmt.Errorf("labels encoding: %w", err)
+	}
+	return string(encoded), nil
+}
+
+func DecodeLabels(encoded string) (models.Labels, error) {
+	var labels models.Labels
+	if err := json.Unmarshal([]byte(encoded), &labels); err != nil {
+		return nil, fmt.Errorf("labels decoding: %w", err)
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	return labels, nil
+}
@@ -7,47 +7,67 @@ var (
 		"{counters}", CountersTable,
 		"{gauges}", GaugesTable,
 		"{name}", NameColumn,
-		"{value}", ValueColumn)
+		"{labels}", LabelsColumn,
+		"{value}", ValueColumn,
+		"{counter_samples}", CounterSamplesTable,
+		"{gauge_samples}", GaugeSamplesTable,
+		"{ts}", TimeColumn,
+		"{histograms}", HistogramsTable,
+		"{bounds}", BoundsColumn,
+		"{counts}", CountsColumn,
+		"{sum}", SumColumn,
+		"{count}", CountColumn,
+		"{idempotency_keys}", IdempotencyKeysTable,
+		"{key}", IdempotencyKeyColumn,
+		"{request_hash}", RequestHashColumn,
+		"{status}", StatusColumn,
+		"{content_type}", ContentTypeColumn,
+		"{body}", BodyColumn,
+		"{expires}", ExpiresColumn)
 
 	createCountersQuery = queryReplacer.Replace(`
 		CREATE TABLE IF NOT EXISTS {counters} (
-			{name} TEXT PRIMARY KEY,
-			{value} BIGINT NOT NULL
+			{name} TEXT NOT NULL,
+			{labels} TEXT NOT NULL DEFAULT '{}',
+			{value} BIGINT NOT NULL,
+			PRIMARY KEY ({name}, {labels})
 		)`)
 
 	createGaugesQuery = queryReplacer.Replace(`
 			CREATE TABLE IF NOT EXISTS {gauges} (
-			{name} TEXT PRIMARY KEY,
-			{value} DOUBLE PRECISION NOT NULL
+			{name} TEXT NOT NULL,
+			{labels} TEXT NOT NULL DEFAULT '{}',
+			{value} DOUBLE PRECISION NOT NULL,
+			PRIMARY KEY ({name}, {labels})
 		)`)
 
 	insertCounterQuery = queryReplacer.Replace(`
 		INSERT
-			INTO {counters} ({name}, {value})
-			VALUES ($1, $2)
+			INTO {counters} ({name}, {labels}, {value})
+			VALUES ($1, $2, $3)
 		`)
 
 	updateCounterQuery = queryReplacer.Replace(`
 		UPDATE {counters}
-			SET {value} = $2
-			WHERE {name} = $1
+			SET {value} = $3
+			WHERE {name} = $1 AND {labels} = $2
 		`)
 
 	findCounterQuery = queryReplacer.Replace(`
-		SELECT {name}, {value}
+		SELECT {name}, {labels}, {value}
 			FROM {counters}
-			WHERE {name} = $1
+			WHERE {name} = $1 AND {labels} = $2
 		`)
 
 	listCountersQuery = queryReplacer.Replace(`
-		SELECT {name}, {value}
+		SELECT {name}, {labels}, {value}
 			FROM {counters}
 		`)
 
 	selectCounterForUpdateQuery = queryReplacer.Replace(`
-		SELECT {name}, {value}
+		SELECT {name}, {labels}, {value}
 			FROM {counters}
-			WHERE {name} = $1
+			WHERE {name} = $1 AND {labels} = $2
 		FOR UPDATE
 		`)
 
@@ -57,30 +77,157 @@ var (
 
 	setGaugeQuery = queryReplacer.Replace(`
 		INSERT
-			INTO {gauges} ({name}, {value})
-			VALUES ($1, $2)
-		ON CONFLICT ({name})
+			INTO {gauges} ({name}, {labels}, {value})
+			VALUES ($1, $2, $3)
+		ON CONFLICT ({name}, {labels})
 			DO UPDATE SET {value} = EXCLUDED.{value}
 		`)
 
 	insertGaugeQuery = queryReplacer.Replace(`
 		INSERT
-			INTO {gauges} ({name}, {value})
-			VALUES ($1, $2)
+			INTO {gauges} ({name}, {labels}, {value})
+			VALUES ($1, $2, $3)
 		`)
 
 	findGaugeQuery = queryReplacer.Replace(`
-		SELECT {name}, {value}
+		SELECT {name}, {labels}, {value}
 			FROM {gauges}
-			WHERE {name} = $1
+			WHERE {name} = $1 AND {labels} = $2
 		`)
 
 	listGaugesQuery = queryReplacer.Replace(`
-		SELECT {name}, {value}
+		SELECT {name}, {labels}, {value}
 			FROM {gauges}
 		`)
 
 	clearGaugeQuery = queryReplacer.Replace(`
 		DELETE FROM {gauges}
 	`)
+
+	insertCounterSampleQuery = queryReplacer.Replace(`
+		INSERT
+			INTO {counter_samples} ({name}, {labels}, {ts}, {value})
+			VALUES ($1, $2, $3, $4)
+		`)
+
+	counterHistoryQuery = queryReplacer.Replace(`
+		SELECT {ts}, {value}
+			FROM {counter_samples}
+			WHERE {name} = $1 AND {labels} = $2 AND {ts} >= $3 AND {ts} <= $4
+			ORDER BY {ts}
+		`)
+
+	// keeps samples not older than $3-th newest one
+	trimCounterSamplesQuery = queryReplacer.Replace(`
+		DELETE FROM {counter_samples}
+			WHERE {name} = $1 AND {labels} = $2 AND {ts} < (
+				SELECT {ts}
+					FROM {counter_samples}
+					WHERE {name} = $1 AND {labels} = $2
+					ORDER BY {ts} DESC
+					LIMIT 1 OFFSET $3
+			)
+		`)
+
+	insertGaugeSampleQuery = queryReplacer.Replace(`
+		INSERT
+			INTO {gauge_samples} ({name}, {labels}, {ts}, {value})
+			VALUES ($1, $2, $3, $4)
+		`)
+
+	gaugeHistoryQuery = queryReplacer.Replace(`
+		SELECT {ts}, {value}
+			FROM {gauge_samples}
+			WHERE {name} = $1 AND {labels} = $2 AND {ts} >= $3 AND {ts} <= $4
+			ORDER BY {ts}
+		`)
+
+	trimGaugeSamplesQuery = queryReplacer.Replace(`
+		DELETE FROM {gauge_samples}
+			WHERE {name} = $1 AND {labels} = $2 AND {ts} < (
+				SELECT {ts}
+					FROM {gauge_samples}
+					WHERE {name} = $1 AND {labels} = $2
+					ORDER BY {ts} DESC
+					LIMIT 1 OFFSET $3
+			)
+		`)
+
+	insertHistogramQuery = queryReplacer.Replace(`
+		INSERT
+			INTO {histograms} ({name}, {labels}, {bounds}, {counts}, {sum}, {count})
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`)
+
+	updateHistogramQuery = queryReplacer.Replace(`
+		UPDATE {histograms}
+			SET {bounds} = $3, {counts} = $4, {sum} = $5, {count} = $6
+			WHERE {name} = $1 AND {labels} = $2
+		`)
+
+	findHistogramQuery = queryReplacer.Replace(`
+		SELECT {name}, {labels}, {bounds}, {counts}, {sum}, {count}
+			FROM {histograms}
+			WHERE {name} = $1 AND {labels} = $2
+		`)
+
+	listHistogramsQuery = queryReplacer.Replace(`
+		SELECT {name}, {labels}, {bounds}, {counts}, {sum}, {count}
+			FROM {histograms}
+		`)
+
+	selectHistogramForUpdateQuery = queryReplacer.Replace(`
+		SELECT {name}, {labels}, {bounds}, {counts}, {sum}, {count}
+			FROM {histograms}
+			WHERE {name} = $1 AND {labels} = $2
+		FOR UPDATE
+		`)
+
+	clearHistogramsQuery = queryReplacer.Replace(`
+		DELETE FROM {histograms}
+	`)
+
+	findResponseQuery = queryReplacer.Replace(`
+		SELECT {request_hash}, {status}, {content_type}, {body}
+			FROM {idempotency_keys}
+			WHERE {key} = $1 AND {expires} > $2
+		`)
+
+	// the first response is kept, except of
+	// lost one saved with applied request
+	saveResponseQuery = queryReplacer.Replace(`
+		INSERT
+			INTO {idempotency_keys} ({key}, {request_hash}, {status}, {content_type}, {body}, {expires})
+			VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT ({key}) DO UPDATE SET
+			{request_hash} = excluded.{request_hash},
+			{status} = excluded.{status},
+			{content_type} = excluded.{content_type},
+			{body} = excluded.{body},
+			{expires} = excluded.{expires}
+			WHERE {idempotency_keys}.{status} = 0
+		`)
+
+	// lost response, replaced by real one after request
+	saveAppliedKeyQuery = queryReplacer.Replace(`
+		INSERT
+			INTO {idempotency_keys} ({key}, {request_hash}, {status}, {content_type}, {body}, {expires})
+			VALUES ($1, $2, 0, '', '', $3)
+		ON CONFLICT ({key}) DO NOTHING
+		`)
+
+	// keeps $1 responses expiring last, they are saved last
+	// as all responses have the same ttl
+	deleteOldestResponsesQuery = queryReplacer.Replace(`
+		DELETE FROM {idempotency_keys}
+			WHERE {expires} <= (
+				SELECT {expires} FROM {idempotency_keys}
+				ORDER BY {expires} DESC
+				LIMIT 1 OFFSET $1)
+		`)
+
+	deleteExpiredResponsesQuery = queryReplacer.Replace(`
+		DELETE FROM {idempotency_keys}
+			WHERE {expires} <= $1
+		`)
 )
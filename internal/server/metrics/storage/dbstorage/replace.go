@@ -27,7 +27,11 @@ func ReplaceGauges(ctx context.Context, uow *UnitOfWork, gauges []models.Gauge)
 		}()
 
 		for _, gauge := range gauges {
-			if _, err = insStmt.ExecContext(ctx, gauge.Name, gauge.Value); err != nil {
+			labels, err := EncodeLabels(gauge.Labels)
+			if err != nil {
+				return err
+			}
+			if _, err = insStmt.ExecContext(ctx, gauge.Name, labels, gauge.Value); err != nil {
 				return fmt.Errorf("ins gauge stmt exec: %w", err)
 			}
 		}
@@ -56,7 +60,11 @@ func ReplaceCounters(ctx context.Context, uow *UnitOfWork, counters []models.Cou
 		}()
 
 		for _, counter := range counters {
-			if _, err = insStmt.ExecContext(ctx, counter.Name, counter.Value); err != nil {
+			labels, err := EncodeLabels(counter.Labels)
+			if err != nil {
+				return err
+			}
+			if _, err = insStmt.ExecContext(ctx, counter.Name, labels, counter.Value); err != nil {
 				return fmt.Errorf("ins counter stmt exec: %w", err)
 			}
 		}
@@ -66,3 +74,41 @@ func ReplaceCounters(ctx context.Context, uow *UnitOfWork, counters []models.Cou
 
 	return uow.Do(ctx, txFn)
 }
+
+func ReplaceHistograms(ctx context.Context, uow *UnitOfWork, histograms []models.Histogram) error {
+	txFn := func(ctx context.Context, tx db.Tx) (err error) {
+		_, err = tx.ExecContext(ctx, clearHistogramsQuery)
+		if err != nil {
+			return fmt.Errorf("clear histograms: %w", err)
+		}
+
+		insStmt, err := tx.PrepareContext(ctx, insertHistogramQuery)
+		if err != nil {
+			return fmt.Errorf("prepare insert histogram stmt: %w", err)
+		}
+		defer func() {
+			if closeErr := insStmt.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("close ins histograms stmt: %w", closeErr))
+			}
+		}()
+
+		for _, histogram := range histograms {
+			labels, err := EncodeLabels(histogram.Labels)
+			if err != nil {
+				return err
+			}
+			bounds, counts, err := EncodeBuckets(histogram.Value)
+			if err != nil {
+				return err
+			}
+			if _, err = insStmt.ExecContext(ctx, histogram.Name, labels, bounds, counts,
+				histogram.Value.Sum, histogram.Value.Count); err != nil {
+				return fmt.Errorf("ins histogram stmt exec: %w", err)
+			}
+		}
+
+		return
+	}
+
+	return uow.Do(ctx, txFn)
+}
@@ -9,11 +9,16 @@ import (
 
 func ScanCounters(rows db.Rows) ([]models.Counter, error) {
 	var counters []models.Counter
-	var counter models.Counter
 	for rows.Next() {
-		if err := rows.Scan(&counter.Name, &counter.Value); err != nil {
+		var counter models.Counter
+		var labels string
+		if err := rows.Scan(&counter.Name, &labels, &counter.Value); err != nil {
 			return nil, fmt.Errorf("counter row scan: %w", err)
 		}
+		var err error
+		if counter.Labels, err = DecodeLabels(labels); err != nil {
+			return nil, fmt.Errorf("counter row labels: %w", err)
+		}
 		counters = append(counters, counter)
 	}
 	if err := rows.Err(); err != nil {
@@ -40,11 +45,16 @@ func ScanCounter(rows db.Rows) (*models.Counter, error) {
 
 func ScanGauges(rows db.Rows) ([]models.Gauge, error) {
 	var gauges []models.Gauge
-	var gauge models.Gauge
 	for rows.Next() {
-		if err := rows.Scan(&gauge.Name, &gauge.Value); err != nil {
+		var gauge models.Gauge
+		var labels string
+		if err := rows.Scan(&gauge.Name, &labels, &gauge.Value); err != nil {
 			return nil, fmt.Errorf("gauge row scan: %w", err)
 		}
+		var err error
+		if gauge.Labels, err = DecodeLabels(labels); err != nil {
+			return nil, fmt.Errorf("gauge row labels: %w", err)
+		}
 		gauges = append(gauges, gauge)
 	}
 	if err := rows.Err(); err != nil {
@@ -68,3 +78,73 @@ func ScanGauge(rows db.Rows) (*models.Gauge, error) {
 		return nil, fmt.Errorf("more than one gauges with the same name")
 	}
 }
+
+func ScanCounterSamples(rows db.Rows) (models.CounterSamples, error) {
+	samples := models.CounterSamples{}
+	var sample models.CounterSample
+	for rows.Next() {
+		if err := rows.Scan(&sample.Time, &sample.Value); err != nil {
+			return nil, fmt.Errorf("counter sample row scan: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return samples, nil
+}
+
+func ScanGaugeSamples(rows db.Rows) (models.GaugeSamples, error) {
+	samples := models.GaugeSamples{}
+	var sample models.GaugeSample
+	for rows.Next() {
+		if err := rows.Scan(&sample.Time, &sample.Value); err != nil {
+			return nil, fmt.Errorf("gauge sample row scan: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return samples, nil
+}
+
+func ScanHistograms(rows db.Rows) ([]models.Histogram, error) {
+	var histograms []models.Histogram
+	for rows.Next() {
+		var histogram models.Histogram
+		var labels, bounds, counts string
+		if err := rows.Scan(&histogram.Name, &labels, &bounds, &counts,
+			&histogram.Value.Sum, &histogram.Value.Count); err != nil {
+			return nil, fmt.Errorf("histogram row scan: %w", err)
+		}
+		var err error
+		if histogram.Labels, err = DecodeLabels(labels); err != nil {
+			return nil, fmt.Errorf("histogram row labels: %w", err)
+		}
+		if err = DecodeBuckets(bounds, counts, &histogram.Value); err != nil {
+			return nil, fmt.Errorf("histogram row buckets: %w", err)
+		}
+		histograms = append(histograms, histogram)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return histograms, nil
+}
+
+func ScanHistogram(rows db.Rows) (*models.Histogram, error) {
+	histograms, err := ScanHistograms(rows)
+	if err != nil {
+		return nil, err
+	}
+	switch len(histograms) {
+	case 0:
+		return nil, nil
+	case 1:
+		histogram := histograms[0]
+		return &histogram, nil
+	default:
+		return nil, fmt.Errorf("more than one histograms with the same name")
+	}
+}
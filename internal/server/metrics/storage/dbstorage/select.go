@@ -94,3 +94,100 @@ func SelectGauge(ctx context.Context, uow *UnitOfWork, query string, args ...any
 		return nil, false, fmt.Errorf("more than one gauges with the same name")
 	}
 }
+
+func SelectCounterSamples(ctx context.Context, uow *UnitOfWork, query string, args ...any) (models.CounterSamples, error) {
+	var samples models.CounterSamples
+
+	txFn := func(ctx context.Context, tx db.Tx) (err error) {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("query counter samples: %w", err)
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("rows closing: %w", err))
+			}
+		}()
+
+		samples, err = ScanCounterSamples(rows)
+		if err != nil {
+			return fmt.Errorf("scan counter samples: %w", err)
+		}
+		return nil
+	}
+
+	if err := uow.Do(ctx, txFn); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+func SelectGaugeSamples(ctx context.Context, uow *UnitOfWork, query string, args ...any) (models.GaugeSamples, error) {
+	var samples models.GaugeSamples
+
+	txFn := func(ctx context.Context, tx db.Tx) (err error) {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("query gauge samples: %w", err)
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("rows closing: %w", err))
+			}
+		}()
+
+		samples, err = ScanGaugeSamples(rows)
+		if err != nil {
+			return fmt.Errorf("scan gauge samples: %w", err)
+		}
+		return nil
+	}
+
+	if err := uow.Do(ctx, txFn); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+func SelectHistograms(ctx context.Context, uow *UnitOfWork, query string, args ...any) (models.HistogramsList, error) {
+	var histograms models.HistogramsList
+
+	txFn := func(ctx context.Context, tx db.Tx) (err error) {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("query histograms: %w", err)
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("rows closing: %w", err))
+			}
+		}()
+
+		histograms, err = ScanHistograms(rows)
+		if err != nil {
+			return fmt.Errorf("scan histograms: %w", err)
+		}
+		return nil
+	}
+
+	if err := uow.Do(ctx, txFn); err != nil {
+		return nil, err
+	}
+	return histograms, nil
+}
+
+func SelectHistogram(ctx context.Context, uow *UnitOfWork, query string, args ...any) (*models.Histogram, bool, error) {
+	histograms, err := SelectHistograms(ctx, uow, query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("select histogram: %w", err)
+	}
+	switch len(histograms) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		histogram := histograms[0]
+		return &histogram, true, nil
+	default:
+		return nil, false, fmt.Errorf("more than one histograms with the same name")
+	}
+}
@@ -0,0 +1,178 @@
+package dbstorage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/metrics/service"
+)
+
+func TestSQLiteStorage(t *testing.T) {
+	dsn := "sqlite://" + filepath.Join(t.TempDir(), "obsermon.db")
+	storage, err := New(dsn, zap.NewNop())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, storage.Close())
+	}()
+
+	ctx := context.Background()
+	// db is connected and migrated in background
+	require.Eventually(t, func() bool {
+		return storage.Ping(ctx) == nil
+	}, 5*time.Second, 10*time.Millisecond, "sqlite is not connected")
+
+	labels := models.Labels{"host": "a"}
+
+	t.Run("test gauges", func(t *testing.T) {
+		from := time.Now().Add(-time.Second)
+		require.NoError(t, storage.SetGauge(ctx, models.Gauge{Name: "gauge", Value: 1.5}))
+		require.NoError(t, storage.SetGauge(ctx, models.Gauge{Name: "gauge", Value: 2.5}))
+		require.NoError(t, storage.SetGauge(ctx, models.Gauge{Name: "gauge", Value: 3.5, Labels: labels}))
+
+		gauge, exists, err := storage.FindGauge(ctx, "gauge", nil)
+		require.NoError(t, err)
+		require.True(t, exists)
+		assert.Equal(t, models.GaugeValue(2.5), gauge.Value)
+
+		gauges, err := storage.ListGauges(ctx)
+		require.NoError(t, err)
+		assert.Len(t, gauges, 2)
+
+		history, err := storage.GaugeHistory(ctx, "gauge", nil, from, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, models.GaugeValue(1.5), history[0].Value)
+	})
+
+	t.Run("test counters", func(t *testing.T) {
+		_, err := storage.UpdateCounter(ctx, models.Counter{Name: "counter", Value: 1})
+		require.NoError(t, err)
+		updated, err := storage.UpdateCounters(ctx, models.CountersList{
+			{Name: "counter", Value: 2},
+			{Name: "counter", Value: 3, Labels: labels},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.CountersList{
+			{Name: "counter", Value: 3},
+			{Name: "counter", Value: 3, Labels: labels},
+		}, updated)
+	})
+
+	t.Run("test histograms", func(t *testing.T) {
+		value := models.HistogramValue{Bounds: []float64{1}, Counts: []uint64{1, 2}, Sum: 4, Count: 3}
+		_, err := storage.UpdateHistogram(ctx, models.Histogram{Name: "histogram", Value: value})
+		require.NoError(t, err)
+		updated, err := storage.UpdateHistogram(ctx, models.Histogram{Name: "histogram", Value: value})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(6), updated.Value.Count)
+		assert.Equal(t, []uint64{2, 4}, updated.Value.Counts)
+	})
+
+	t.Run("test failed batch", func(t *testing.T) {
+		mismatched := models.HistogramValue{Bounds: []float64{2}, Counts: []uint64{1, 0}, Sum: 1, Count: 1}
+		_, err := storage.UpdateBatch(ctx, service.Batch{
+			Counters:   models.CountersList{{Name: "counter", Value: 10}},
+			Histograms: models.HistogramsList{{Name: "histogram", Value: mismatched}},
+		})
+		require.Error(t, err)
+
+		// counters are not updated with failed histogram
+		counter, _, err := storage.FindCounter(ctx, "counter", nil)
+		require.NoError(t, err)
+		assert.Equal(t, models.CounterValue(3), counter.Value)
+	})
+
+	t.Run("test idempotent responses", func(t *testing.T) {
+		resp := models.IdempotentResponse{RequestHash: "hash", StatusCode: 200,
+			ContentType: "application/json", Body: []byte(`[]`)}
+		require.NoError(t, storage.SaveResponse(ctx, "key", resp, time.Minute))
+		// the first response is kept
+		require.NoError(t, storage.SaveResponse(ctx, "key",
+			models.IdempotentResponse{RequestHash: "other", StatusCode: 400}, time.Minute))
+
+		found, exists, err := storage.FindResponse(ctx, "key")
+		require.NoError(t, err)
+		require.True(t, exists)
+		assert.Equal(t, resp, *found)
+
+		require.NoError(t, storage.SaveResponse(ctx, "expired", resp, -time.Second))
+		_, exists, err = storage.FindResponse(ctx, "expired")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("test idempotency key saved with batch", func(t *testing.T) {
+		keyCtx := models.ContextWithIdempotencyKey(ctx,
+			models.IdempotencyKey{Key: "applied", RequestHash: "hash"})
+		_, err := storage.UpdateBatch(keyCtx, service.Batch{
+			Counters: models.CountersList{{Name: "keyed", Value: 1}},
+		})
+		require.NoError(t, err)
+
+		// response is not saved yet, but batch is applied
+		found, exists, err := storage.FindResponse(ctx, "applied")
+		require.NoError(t, err)
+		require.True(t, exists)
+		assert.True(t, found.Lost())
+		assert.Equal(t, "hash", found.RequestHash)
+
+		resp := models.IdempotentResponse{RequestHash: "hash", StatusCode: 200, Body: []byte(`[]`)}
+		require.NoError(t, storage.SaveResponse(ctx, "applied", resp, time.Minute))
+		found, exists, err = storage.FindResponse(ctx, "applied")
+		require.NoError(t, err)
+		require.True(t, exists)
+		assert.Equal(t, resp, *found)
+
+		// failed batch doesn't save key
+		failedCtx := models.ContextWithIdempotencyKey(ctx,
+			models.IdempotencyKey{Key: "failed", RequestHash: "hash"})
+		_, err = storage.UpdateBatch(failedCtx, service.Batch{
+			Histograms: models.HistogramsList{{Name: "histogram", Value: models.HistogramValue{
+				Bounds: []float64{2}, Counts: []uint64{1, 0}, Sum: 1, Count: 1}}},
+		})
+		require.Error(t, err)
+		_, exists, err = storage.FindResponse(ctx, "failed")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("test idempotent responses limit", func(t *testing.T) {
+		limit := storage.responsesLimit
+		storage.responsesLimit = 2
+		defer func() { storage.responsesLimit = limit }()
+
+		resp := models.IdempotentResponse{RequestHash: "hash", StatusCode: 200}
+		for i, key := range []string{"first", "second", "third"} {
+			ttl := time.Minute + time.Duration(i)*time.Second
+			require.NoError(t, storage.SaveResponse(ctx, key, resp, ttl))
+		}
+
+		// the oldest response is evicted
+		_, exists, err := storage.FindResponse(ctx, "first")
+		require.NoError(t, err)
+		assert.False(t, exists)
+		for _, key := range []string{"second", "third"} {
+			_, exists, err := storage.FindResponse(ctx, key)
+			require.NoError(t, err)
+			assert.True(t, exists, key)
+		}
+	})
+
+	t.Run("test history trimming", func(t *testing.T) {
+		from := time.Now()
+		for i := 0; i < service.HistoryDepth+10; i++ {
+			require.NoError(t, storage.SetGauge(ctx, models.Gauge{Name: "trimmed", Value: models.GaugeValue(i)}))
+		}
+		history, err := storage.GaugeHistory(ctx, "trimmed", nil, from, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, history, service.HistoryDepth)
+		assert.Equal(t, models.GaugeValue(10), history[0].Value)
+	})
+}
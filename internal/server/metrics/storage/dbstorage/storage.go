@@ -14,10 +14,15 @@ import (
 type Storage struct {
 	db  db.DB
 	uow *UnitOfWork
+	// max count of kept idempotent responses
+	responsesLimit int
 }
 
 var _ service.Storage = (*Storage)(nil)
 var _ service.Pingable = (*Storage)(nil)
+var _ service.HistoryStorage = (*Storage)(nil)
+var _ service.BatchStorage = (*Storage)(nil)
+var _ service.IdempotencyStorage = (*Storage)(nil)
 
 func New(dbConn string, log *zap.Logger) (*Storage, error) {
 	if log == nil {
@@ -34,8 +39,9 @@ func New(dbConn string, log *zap.Logger) (*Storage, error) {
 	uow := UnitOfWork{db: db, retryPolicy: retryPolicy}
 
 	storage := Storage{
-		db:  db,
-		uow: &uow,
+		db:             db,
+		uow:            &uow,
+		responsesLimit: service.ResponseCacheSize,
 	}
 
 	return &storage, nil
@@ -62,11 +68,15 @@ func (s *Storage) SetGauges(ctx context.Context, vals models.GaugesList) error {
 	return UpdateGauges(ctx, s.uow, vals)
 }
 
-func (s *Storage) FindGauge(ctx context.Context, name string) (*models.Gauge, bool, error) {
+func (s *Storage) FindGauge(ctx context.Context, name string, labels models.Labels) (*models.Gauge, bool, error) {
 	if s == nil || s.uow == nil {
 		return nil, false, fmt.Errorf("database not exists")
 	}
-	return SelectGauge(ctx, s.uow, findGaugeQuery, name)
+	encodedLabels, err := EncodeLabels(labels)
+	if err != nil {
+		return nil, false, err
+	}
+	return SelectGauge(ctx, s.uow, findGaugeQuery, name, encodedLabels)
 }
 
 func (s *Storage) ListGauges(ctx context.Context) (models.GaugesList, error) {
@@ -98,11 +108,15 @@ func (s *Storage) UpdateCounters(ctx context.Context, vals models.CountersList)
 	return UpdateCounters(ctx, s.uow, vals)
 }
 
-func (s *Storage) FindCounter(ctx context.Context, name string) (*models.Counter, bool, error) {
+func (s *Storage) FindCounter(ctx context.Context, name string, labels models.Labels) (*models.Counter, bool, error) {
 	if s == nil || s.uow == nil {
 		return nil, false, fmt.Errorf("database not exists")
 	}
-	return SelectCounter(ctx, s.uow, findCounterQuery, name)
+	encodedLabels, err := EncodeLabels(labels)
+	if err != nil {
+		return nil, false, err
+	}
+	return SelectCounter(ctx, s.uow, findCounterQuery, name, encodedLabels)
 }
 
 func (s *Storage) ListCounters(ctx context.Context) (models.CountersList, error) {
@@ -119,6 +133,90 @@ func (s *Storage) ReplaceCounters(ctx context.Context, val models.CountersList)
 	return ReplaceCounters(ctx, s.uow, val)
 }
 
+func (s *Storage) UpdateHistogram(ctx context.Context, val models.Histogram) (*models.Histogram, error) {
+	updated, err := s.UpdateHistograms(ctx, models.HistogramsList{val})
+	if err != nil {
+		return nil, err
+	}
+	return &updated[0], nil
+}
+
+func (s *Storage) UpdateHistograms(ctx context.Context, vals models.HistogramsList) (models.HistogramsList, error) {
+	if s == nil || s.uow == nil {
+		return nil, fmt.Errorf("database not exists")
+	}
+	return UpdateHistograms(ctx, s.uow, vals)
+}
+
+func (s *Storage) UpdateBatch(ctx context.Context, batch service.Batch) (*service.Batch, error) {
+	if s == nil || s.uow == nil {
+		return nil, fmt.Errorf("database not exists")
+	}
+	return UpdateBatch(ctx, s.uow, batch)
+}
+
+func (s *Storage) FindHistogram(ctx context.Context, name string, labels models.Labels) (*models.Histogram, bool, error) {
+	if s == nil || s.uow == nil {
+		return nil, false, fmt.Errorf("database not exists")
+	}
+	encodedLabels, err := EncodeLabels(labels)
+	if err != nil {
+		return nil, false, err
+	}
+	return SelectHistogram(ctx, s.uow, findHistogramQuery, name, encodedLabels)
+}
+
+func (s *Storage) ListHistograms(ctx context.Context) (models.HistogramsList, error) {
+	if s == nil || s.uow == nil {
+		return nil, fmt.Errorf("database not exists")
+	}
+	return SelectHistograms(ctx, s.uow, listHistogramsQuery)
+}
+
+func (s *Storage) ReplaceHistograms(ctx context.Context, val models.HistogramsList) error {
+	if s == nil || s.uow == nil {
+		return fmt.Errorf("database not exists")
+	}
+	return ReplaceHistograms(ctx, s.uow, val)
+}
+
+func (s *Storage) GaugeHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.GaugeSamples, error) {
+	if s == nil || s.uow == nil {
+		return nil, fmt.Errorf("database not exists")
+	}
+	encodedLabels, err := EncodeLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	return SelectGaugeSamples(ctx, s.uow, gaugeHistoryQuery, name, encodedLabels, from, to)
+}
+
+func (s *Storage) CounterHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.CounterSamples, error) {
+	if s == nil || s.uow == nil {
+		return nil, fmt.Errorf("database not exists")
+	}
+	encodedLabels, err := EncodeLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	return SelectCounterSamples(ctx, s.uow, counterHistoryQuery, name, encodedLabels, from, to)
+}
+
+func (s *Storage) FindResponse(ctx context.Context, key string) (*models.IdempotentResponse, bool, error) {
+	if s == nil || s.uow == nil {
+		return nil, false, fmt.Errorf("database not exists")
+	}
+	return SelectResponse(ctx, s.uow, key, time.Now())
+}
+
+func (s *Storage) SaveResponse(ctx context.Context, key string, resp models.IdempotentResponse, ttl time.Duration) error {
+	if s == nil || s.uow == nil {
+		return fmt.Errorf("database not exists")
+	}
+	now := time.Now()
+	return InsertResponse(ctx, s.uow, key, resp, now, now.Add(ttl), s.responsesLimit)
+}
+
 func (s *Storage) Ping(ctx context.Context) error {
 	if s == nil || s.db == nil {
 		return fmt.Errorf("database not exists")
@@ -4,39 +4,107 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/metrics/service"
 	"github.com/stepkareserva/obsermon/internal/server/metrics/storage/dbstorage/db"
 )
 
-func UpdateGauges(ctx context.Context, uow *UnitOfWork, gauges []models.Gauge) error {
-	txFn := func(ctx context.Context, tx db.Tx) (err error) {
-		setStmt, err := tx.PrepareContext(ctx, setGaugeQuery)
+// counters, gauges and histograms are updated in one transaction
+func UpdateBatch(ctx context.Context, uow *UnitOfWork, batch service.Batch) (*service.Batch, error) {
+	var updated *service.Batch
+
+	txFn := func(ctx context.Context, tx db.Tx) error {
+		counters, err := updateCounters(ctx, tx, batch.Counters)
+		if err != nil {
+			return fmt.Errorf("update counters: %w", err)
+		}
+		if err = updateGauges(ctx, tx, batch.Gauges); err != nil {
+			return fmt.Errorf("update gauges: %w", err)
+		}
+		histograms, err := updateHistograms(ctx, tx, batch.Histograms)
 		if err != nil {
-			return fmt.Errorf("prepare set gauge stmt: %w", err)
+			return fmt.Errorf("update histograms: %w", err)
+		}
+		updated = &service.Batch{Counters: counters, Gauges: batch.Gauges, Histograms: histograms}
+		return insertAppliedKey(ctx, tx)
+	}
+
+	if err := uow.Do(ctx, txFn); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func UpdateGauges(ctx context.Context, uow *UnitOfWork, gauges []models.Gauge) error {
+	return uow.Do(ctx, func(ctx context.Context, tx db.Tx) error {
+		if err := updateGauges(ctx, tx, gauges); err != nil {
+			return err
+		}
+		return insertAppliedKey(ctx, tx)
+	})
+}
+
+func updateGauges(ctx context.Context, tx db.Tx, gauges []models.Gauge) (err error) {
+	setStmt, err := tx.PrepareContext(ctx, setGaugeQuery)
+	if err != nil {
+		return fmt.Errorf("prepare set gauge stmt: %w", err)
+	}
+	defer func() {
+		if closeErr := setStmt.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("close set gauges stmt: %w", closeErr))
+		}
+	}()
+
+	smpStmt, err := tx.PrepareContext(ctx, insertGaugeSampleQuery)
+	if err != nil {
+		return fmt.Errorf("prepare gauge sample stmt: %w", err)
+	}
+	defer func() {
+		if closeErr := smpStmt.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("close gauge sample stmt: %w", closeErr))
 		}
-		defer func() {
-			if closeErr := setStmt.Close(); closeErr != nil {
-				err = errors.Join(err, fmt.Errorf("close set gauges stmt: %w", closeErr))
-			}
-		}()
+	}()
 
-		for _, gauge := range gauges {
-			if _, err = setStmt.ExecContext(ctx, gauge.Name, gauge.Value); err != nil {
-				return fmt.Errorf("set gauge stmt exec: %w", err)
-			}
+	trimStmt, err := tx.PrepareContext(ctx, trimGaugeSamplesQuery)
+	if err != nil {
+		return fmt.Errorf("prepare trim gauge samples stmt: %w", err)
+	}
+	defer func() {
+		if closeErr := trimStmt.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("close trim gauge samples stmt: %w", closeErr))
 		}
+	}()
 
-		return
+	now := time.Now()
+	for _, gauge := range gauges {
+		labels, err := EncodeLabels(gauge.Labels)
+		if err != nil {
+			return err
+		}
+		if _, err = setStmt.ExecContext(ctx, gauge.Name, labels, gauge.Value); err != nil {
+			return fmt.Errorf("set gauge stmt exec: %w", err)
+		}
+		if _, err = smpStmt.ExecContext(ctx, gauge.Name, labels, now, gauge.Value); err != nil {
+			return fmt.Errorf("gauge sample stmt exec: %w", err)
+		}
+		// history is as deep as in memory storage
+		if _, err = trimStmt.ExecContext(ctx, gauge.Name, labels, service.HistoryDepth-1); err != nil {
+			return fmt.Errorf("trim gauge samples stmt exec: %w", err)
+		}
 	}
 
-	return uow.Do(ctx, txFn)
+	return
 }
 
 type counterStmts struct {
 	sel db.Stmt
 	upd db.Stmt
 	ins db.Stmt
+	smp db.Stmt
+	trm db.Stmt
 }
 
 func newCounterStmts(ctx context.Context, tx db.Tx) (stmts *counterStmts, err error) {
@@ -67,10 +135,24 @@ func newCounterStmts(ctx context.Context, tx db.Tx) (stmts *counterStmts, err er
 	}
 	defer func() { closeStmt(ins) }()
 
+	smp, err := tx.PrepareContext(ctx, insertCounterSampleQuery)
+	if err != nil {
+		return nil, fmt.Errorf("prepare smp stmt: %w", err)
+	}
+	defer func() { closeStmt(smp) }()
+
+	trm, err := tx.PrepareContext(ctx, trimCounterSamplesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("prepare trm stmt: %w", err)
+	}
+	defer func() { closeStmt(trm) }()
+
 	return &counterStmts{
 		sel: sel,
 		upd: upd,
 		ins: ins,
+		smp: smp,
+		trm: trm,
 	}, nil
 }
 
@@ -85,6 +167,12 @@ func (stmts *counterStmts) Close() error {
 	if err := stmts.ins.Close(); err != nil {
 		errs = append(errs, fmt.Errorf("close ins stmt: %w", err))
 	}
+	if err := stmts.smp.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close smp stmt: %w", err))
+	}
+	if err := stmts.trm.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close trm stmt: %w", err))
+	}
 	return errors.Join(errs...)
 }
 
@@ -92,25 +180,11 @@ func UpdateCounters(ctx context.Context, uow *UnitOfWork, counters []models.Coun
 	var updatedCounters []models.Counter
 
 	txFn := func(ctx context.Context, tx db.Tx) (err error) {
-		stmts, err := newCounterStmts(ctx, tx)
-		if err != nil {
-			return fmt.Errorf("prepare set gauge stmt: %w", err)
+		// tx may be retried, results of failed attempts are replaced
+		if updatedCounters, err = updateCounters(ctx, tx, counters); err != nil {
+			return err
 		}
-		defer func() {
-			if closeErr := stmts.Close(); closeErr != nil {
-				err = errors.Join(err, fmt.Errorf("close update counters stmts: %w", closeErr))
-			}
-		}()
-
-		for _, counter := range counters {
-			updated, err := updateCounter(ctx, stmts, counter)
-			if err != nil {
-				return fmt.Errorf("set gauge stmt exec: %w", err)
-			}
-			updatedCounters = append(updatedCounters, *updated)
-		}
-
-		return
+		return insertAppliedKey(ctx, tx)
 	}
 
 	if err := uow.Do(ctx, txFn); err != nil {
@@ -120,8 +194,42 @@ func UpdateCounters(ctx context.Context, uow *UnitOfWork, counters []models.Coun
 	return updatedCounters, nil
 }
 
-func updateCounter(ctx context.Context, stmts *counterStmts, counter models.Counter) (updated *models.Counter, err error) {
-	rows, err := stmts.sel.QueryContext(ctx, counter.Name)
+func updateCounters(ctx context.Context, tx db.Tx, counters []models.Counter) (updatedCounters []models.Counter, err error) {
+	stmts, err := newCounterStmts(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("prepare update counters stmts: %w", err)
+	}
+	defer func() {
+		if closeErr := stmts.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("close update counters stmts: %w", closeErr))
+		}
+	}()
+
+	now := time.Now()
+	for _, counter := range counters {
+		labels, err := EncodeLabels(counter.Labels)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := updateCounter(ctx, stmts, counter, labels)
+		if err != nil {
+			return nil, fmt.Errorf("update counter: %w", err)
+		}
+		if _, err = stmts.smp.ExecContext(ctx, updated.Name, labels, now, updated.Value); err != nil {
+			return nil, fmt.Errorf("counter sample stmt exec: %w", err)
+		}
+		// history is as deep as in memory storage
+		if _, err = stmts.trm.ExecContext(ctx, updated.Name, labels, service.HistoryDepth-1); err != nil {
+			return nil, fmt.Errorf("trim counter samples stmt exec: %w", err)
+		}
+		updatedCounters = append(updatedCounters, *updated)
+	}
+
+	return updatedCounters, nil
+}
+
+func updateCounter(ctx context.Context, stmts *counterStmts, counter models.Counter, labels string) (updated *models.Counter, err error) {
+	rows, err := stmts.sel.QueryContext(ctx, counter.Name, labels)
 	if err != nil {
 		return nil, fmt.Errorf("query counters: %w", err)
 	}
@@ -138,7 +246,7 @@ func updateCounter(ctx context.Context, stmts *counterStmts, counter models.Coun
 	}
 
 	if current == nil {
-		if _, err = stmts.ins.ExecContext(ctx, counter.Name, counter.Value); err != nil {
+		if _, err = stmts.ins.ExecContext(ctx, counter.Name, labels, counter.Value); err != nil {
 			return nil, fmt.Errorf("insert counter: %w", err)
 		}
 		return &counter, nil
@@ -148,9 +256,143 @@ func updateCounter(ctx context.Context, stmts *counterStmts, counter models.Coun
 		return nil, fmt.Errorf("update counter value: %w", err)
 	}
 
-	if _, err = stmts.upd.ExecContext(ctx, current.Name, current.Value); err != nil {
+	if _, err = stmts.upd.ExecContext(ctx, current.Name, labels, current.Value); err != nil {
 		return nil, fmt.Errorf("update counter: %w", err)
 	}
 	return current, nil
 
 }
+
+type histogramStmts struct {
+	sel db.Stmt
+	upd db.Stmt
+	ins db.Stmt
+}
+
+func newHistogramStmts(ctx context.Context, tx db.Tx) (stmts *histogramStmts, err error) {
+	closeStmt := func(stmt db.Stmt) {
+		if err == nil {
+			return
+		}
+		if closeErr := stmt.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("close stmt: %w", closeErr))
+		}
+	}
+
+	sel, err := tx.PrepareContext(ctx, selectHistogramForUpdateQuery)
+	if err != nil {
+		return nil, fmt.Errorf("prepare sel stmt: %w", err)
+	}
+	defer func() { closeStmt(sel) }()
+
+	upd, err := tx.PrepareContext(ctx, updateHistogramQuery)
+	if err != nil {
+		return nil, fmt.Errorf("prepare upd stmt: %w", err)
+	}
+	defer func() { closeStmt(upd) }()
+
+	ins, err := tx.PrepareContext(ctx, insertHistogramQuery)
+	if err != nil {
+		return nil, fmt.Errorf("prepare ins stmt: %w", err)
+	}
+	defer func() { closeStmt(ins) }()
+
+	return &histogramStmts{
+		sel: sel,
+		upd: upd,
+		ins: ins,
+	}, nil
+}
+
+func (stmts *histogramStmts) Close() error {
+	var errs []error
+	if err := stmts.sel.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close sel stmt: %w", err))
+	}
+	if err := stmts.upd.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close upd stmt: %w", err))
+	}
+	if err := stmts.ins.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close ins stmt: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func UpdateHistograms(ctx context.Context, uow *UnitOfWork, histograms []models.Histogram) ([]models.Histogram, error) {
+	var updatedHistograms []models.Histogram
+
+	txFn := func(ctx context.Context, tx db.Tx) (err error) {
+		// tx may be retried, results of failed attempts are replaced
+		if updatedHistograms, err = updateHistograms(ctx, tx, histograms); err != nil {
+			return err
+		}
+		return insertAppliedKey(ctx, tx)
+	}
+
+	if err := uow.Do(ctx, txFn); err != nil {
+		return nil, err
+	}
+
+	return updatedHistograms, nil
+}
+
+func updateHistograms(ctx context.Context, tx db.Tx, histograms []models.Histogram) (updatedHistograms []models.Histogram, err error) {
+	stmts, err := newHistogramStmts(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("prepare histogram stmts: %w", err)
+	}
+	defer func() {
+		if closeErr := stmts.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("close update histograms stmts: %w", closeErr))
+		}
+	}()
+
+	for _, histogram := range histograms {
+		labels, err := EncodeLabels(histogram.Labels)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := updateHistogram(ctx, stmts, histogram, labels)
+		if err != nil {
+			return nil, fmt.Errorf("update histogram: %w", err)
+		}
+		updatedHistograms = append(updatedHistograms, *updated)
+	}
+
+	return updatedHistograms, nil
+}
+
+func updateHistogram(ctx context.Context, stmts *histogramStmts, histogram models.Histogram, labels string) (updated *models.Histogram, err error) {
+	rows, err := stmts.sel.QueryContext(ctx, histogram.Name, labels)
+	if err != nil {
+		return nil, fmt.Errorf("query histograms: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("rows closing: %w", err))
+		}
+	}()
+
+	current, err := ScanHistogram(rows)
+	if err != nil {
+		return nil, fmt.Errorf("scan histograms: %w", err)
+	}
+
+	stmt := stmts.ins
+	if current != nil {
+		if err = histogram.Value.Update(current.Value); err != nil {
+			return nil, fmt.Errorf("update histogram value: %w", err)
+		}
+		stmt = stmts.upd
+	}
+
+	bounds, counts, err := EncodeBuckets(histogram.Value)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = stmt.ExecContext(ctx, histogram.Name, labels, bounds, counts,
+		histogram.Value.Sum, histogram.Value.Count); err != nil {
+		return nil, fmt.Errorf("store histogram: %w", err)
+	}
+	return &histogram, nil
+}
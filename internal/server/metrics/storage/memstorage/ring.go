@@ -0,0 +1,40 @@
+package memstorage
+
+// fixed-capacity buffer which overwrites
+// the oldest items when it is full.
+// memory is allocated as items are pushed,
+// so rare series don't take full capacity.
+type ring[T any] struct {
+	items    []T
+	capacity int
+	start    int
+}
+
+func newRing[T any](capacity int) *ring[T] {
+	return &ring[T]{capacity: capacity}
+}
+
+func (r *ring[T]) Push(item T) {
+	if r.capacity <= 0 {
+		return
+	}
+	if len(r.items) < r.capacity {
+		r.items = append(r.items, item)
+		return
+	}
+	r.items[r.start] = item
+	r.start = (r.start + 1) % len(r.items)
+}
+
+// items from oldest to newest, which satisfy filter
+func (r *ring[T]) Filter(filter func(T) bool) []T {
+	// empty, not nil, so it's encoded as []
+	items := []T{}
+	for i := range r.items {
+		item := r.items[(r.start+i)%len(r.items)]
+		if filter(item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
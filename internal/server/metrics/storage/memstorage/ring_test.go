@@ -0,0 +1,27 @@
+package memstorage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	all := func(int) bool { return true }
+
+	r := newRing[int](3)
+	assert.NotNil(t, r.Filter(all))
+	assert.Empty(t, r.Filter(all))
+
+	r.Push(1)
+	r.Push(2)
+	assert.Equal(t, []int{1, 2}, r.Filter(all))
+	assert.Len(t, r.items, 2)
+
+	// the oldest items are overwritten
+	r.Push(3)
+	r.Push(4)
+	r.Push(5)
+	assert.Equal(t, []int{3, 4, 5}, r.Filter(all))
+	assert.Equal(t, []int{3, 5}, r.Filter(func(v int) bool { return v%2 == 1 }))
+}
@@ -4,23 +4,36 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/stepkareserva/obsermon/internal/models"
 	"github.com/stepkareserva/obsermon/internal/server/metrics/service"
 )
 
 var _ service.Storage = (*Storage)(nil)
+var _ service.HistoryStorage = (*Storage)(nil)
+var _ service.BatchStorage = (*Storage)(nil)
 
+// gauges and counters are keyed by series key,
+// see models.SeriesKey
 type Storage struct {
-	gauges   models.GaugesMap
-	counters models.CountersMap
-	lock     sync.RWMutex
+	gauges     map[string]models.Gauge
+	counters   map[string]models.Counter
+	histograms map[string]models.Histogram
+
+	gaugesHistory   map[string]*ring[models.GaugeSample]
+	countersHistory map[string]*ring[models.CounterSample]
+
+	lock sync.RWMutex
 }
 
 func New() *Storage {
 	return &Storage{
-		gauges:   make(models.GaugesMap),
-		counters: make(models.CountersMap),
+		gauges:          make(map[string]models.Gauge),
+		counters:        make(map[string]models.Counter),
+		histograms:      make(map[string]models.Histogram),
+		gaugesHistory:   make(map[string]*ring[models.GaugeSample]),
+		countersHistory: make(map[string]*ring[models.CounterSample]),
 	}
 }
 
@@ -28,7 +41,8 @@ func (m *Storage) SetGauge(ctx context.Context, val models.Gauge) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	m.gauges[val.Name] = val.Value
+	m.gauges[val.Key()] = val
+	m.addGaugeSample(val, time.Now())
 	return nil
 }
 
@@ -36,31 +50,41 @@ func (m *Storage) SetGauges(ctx context.Context, vals models.GaugesList) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	now := time.Now()
 	for _, val := range vals {
-		m.gauges[val.Name] = val.Value
+		m.gauges[val.Key()] = val
+		m.addGaugeSample(val, now)
 	}
 	return nil
 }
-func (m *Storage) FindGauge(ctx context.Context, name string) (*models.Gauge, bool, error) {
+
+func (m *Storage) FindGauge(ctx context.Context, name string, labels models.Labels) (*models.Gauge, bool, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
 
-	val, exists := m.gauges[name]
-	return &models.Gauge{Name: name, Value: val}, exists, nil
+	val, exists := m.gauges[models.SeriesKey(name, labels)]
+	return &val, exists, nil
 }
 
 func (m *Storage) ListGauges(ctx context.Context) (models.GaugesList, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
 
-	return m.gauges.List(), nil
+	gauges := make(models.GaugesList, 0, len(m.gauges))
+	for _, val := range m.gauges {
+		gauges = append(gauges, val)
+	}
+	return gauges, nil
 }
 
 func (m *Storage) ReplaceGauges(ctx context.Context, val models.GaugesList) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	m.gauges = val.Map()
+	m.gauges = make(map[string]models.Gauge, len(val))
+	for _, v := range val {
+		m.gauges[v.Key()] = v
+	}
 	return nil
 }
 
@@ -68,53 +92,207 @@ func (m *Storage) UpdateCounter(ctx context.Context, val models.Counter) (*model
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	counter, exists := m.counters[val.Name]
+	counter, exists := m.counters[val.Key()]
 	if exists {
-		if err := val.Value.Update(counter); err != nil {
+		if err := val.Value.Update(counter.Value); err != nil {
 			return nil, fmt.Errorf("update counter: %v", err)
 		}
 	}
-	m.counters[val.Name] = val.Value
+	m.counters[val.Key()] = val
+	m.addCounterSample(val, time.Now())
 
 	return &val, nil
 }
 
 func (m *Storage) UpdateCounters(ctx context.Context, vals models.CountersList) (models.CountersList, error) {
+	updated, err := m.UpdateBatch(ctx, service.Batch{Counters: vals})
+	if err != nil {
+		return nil, err
+	}
+	return updated.Counters, nil
+}
+
+func (m *Storage) FindCounter(ctx context.Context, name string, labels models.Labels) (*models.Counter, bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	val, exists := m.counters[models.SeriesKey(name, labels)]
+	return &val, exists, nil
+}
+
+func (m *Storage) ListCounters(ctx context.Context) (models.CountersList, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	counters := make(models.CountersList, 0, len(m.counters))
+	for _, val := range m.counters {
+		counters = append(counters, val)
+	}
+	return counters, nil
+}
+
+func (m *Storage) ReplaceCounters(ctx context.Context, val models.CountersList) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	for _, val := range vals {
-		counter, exists := m.counters[val.Name]
+	m.counters = make(map[string]models.Counter, len(val))
+	for _, v := range val {
+		m.counters[v.Key()] = v
+	}
+	return nil
+}
+
+func (m *Storage) UpdateHistogram(ctx context.Context, val models.Histogram) (*models.Histogram, error) {
+	updated, err := m.UpdateHistograms(ctx, models.HistogramsList{val})
+	if err != nil {
+		return nil, err
+	}
+	return &updated[0], nil
+}
+
+func (m *Storage) UpdateHistograms(ctx context.Context, vals models.HistogramsList) (models.HistogramsList, error) {
+	updated, err := m.UpdateBatch(ctx, service.Batch{Histograms: vals})
+	if err != nil {
+		return nil, err
+	}
+	return updated.Histograms, nil
+}
+
+func (m *Storage) FindHistogram(ctx context.Context, name string, labels models.Labels) (*models.Histogram, bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	val, exists := m.histograms[models.SeriesKey(name, labels)]
+	return &val, exists, nil
+}
+
+func (m *Storage) ListHistograms(ctx context.Context) (models.HistogramsList, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	histograms := make(models.HistogramsList, 0, len(m.histograms))
+	for _, val := range m.histograms {
+		histograms = append(histograms, val)
+	}
+	return histograms, nil
+}
+
+func (m *Storage) ReplaceHistograms(ctx context.Context, val models.HistogramsList) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.histograms = make(map[string]models.Histogram, len(val))
+	for _, v := range val {
+		m.histograms[v.Key()] = v
+	}
+	return nil
+}
+
+// all updated values are calculated before storing,
+// so storage is not modified if any of them fails
+func (m *Storage) UpdateBatch(ctx context.Context, batch service.Batch) (*service.Batch, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// the same series may be repeated in batch
+	counters := make(map[string]models.Counter, len(batch.Counters))
+	updatedCounters := make(models.CountersList, 0, len(batch.Counters))
+	for _, val := range batch.Counters {
+		counter, exists := counters[val.Key()]
+		if !exists {
+			counter, exists = m.counters[val.Key()]
+		}
 		if exists {
-			if err := val.Value.Update(counter); err != nil {
+			if err := val.Value.Update(counter.Value); err != nil {
 				return nil, fmt.Errorf("update counters: %v", err)
 			}
 		}
-		m.counters[val.Name] = val.Value
+		counters[val.Key()] = val
+		updatedCounters = append(updatedCounters, val)
+	}
+
+	histograms := make(map[string]models.Histogram, len(batch.Histograms))
+	updatedHistograms := make(models.HistogramsList, 0, len(batch.Histograms))
+	for _, val := range batch.Histograms {
+		histogram, exists := histograms[val.Key()]
+		if !exists {
+			histogram, exists = m.histograms[val.Key()]
+		}
+		if exists {
+			if err := val.Value.Update(histogram.Value); err != nil {
+				return nil, fmt.Errorf("update histograms: %v", err)
+			}
+		}
+		histograms[val.Key()] = val
+		updatedHistograms = append(updatedHistograms, val)
+	}
+
+	now := time.Now()
+	for _, val := range updatedCounters {
+		m.counters[val.Key()] = val
+		m.addCounterSample(val, now)
+	}
+	for _, val := range batch.Gauges {
+		m.gauges[val.Key()] = val
+		m.addGaugeSample(val, now)
+	}
+	for _, val := range updatedHistograms {
+		m.histograms[val.Key()] = val
 	}
 
-	return vals, nil
+	return &service.Batch{
+		Counters:   updatedCounters,
+		Gauges:     batch.Gauges,
+		Histograms: updatedHistograms,
+	}, nil
 }
 
-func (m *Storage) FindCounter(ctx context.Context, name string) (*models.Counter, bool, error) {
+func (m *Storage) GaugeHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.GaugeSamples, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
 
-	val, exists := m.counters[name]
-	return &models.Counter{Name: name, Value: val}, exists, nil
+	history, exists := m.gaugesHistory[models.SeriesKey(name, labels)]
+	if !exists {
+		return models.GaugeSamples{}, nil
+	}
+	return history.Filter(func(s models.GaugeSample) bool {
+		return inTimeRange(s.Time, from, to)
+	}), nil
 }
 
-func (m *Storage) ListCounters(ctx context.Context) (models.CountersList, error) {
+func (m *Storage) CounterHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.CounterSamples, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
 
-	return m.counters.List(), nil
+	history, exists := m.countersHistory[models.SeriesKey(name, labels)]
+	if !exists {
+		return models.CounterSamples{}, nil
+	}
+	return history.Filter(func(s models.CounterSample) bool {
+		return inTimeRange(s.Time, from, to)
+	}), nil
 }
 
-func (m *Storage) ReplaceCounters(ctx context.Context, val models.CountersList) error {
-	m.lock.Lock()
-	defer m.lock.Unlock()
+// must be called under write lock
+func (m *Storage) addGaugeSample(val models.Gauge, t time.Time) {
+	history, exists := m.gaugesHistory[val.Key()]
+	if !exists {
+		history = newRing[models.GaugeSample](service.HistoryDepth)
+		m.gaugesHistory[val.Key()] = history
+	}
+	history.Push(models.GaugeSample{Time: t, Value: val.Value})
+}
 
-	m.counters = val.Map()
-	return nil
+// must be called under write lock
+func (m *Storage) addCounterSample(val models.Counter, t time.Time) {
+	history, exists := m.countersHistory[val.Key()]
+	if !exists {
+		history = newRing[models.CounterSample](service.HistoryDepth)
+		m.countersHistory[val.Key()] = history
+	}
+	history.Push(models.CounterSample{Time: t, Value: val.Value})
+}
+
+func inTimeRange(t, from, to time.Time) bool {
+	return !t.Before(from) && !t.After(to)
 }
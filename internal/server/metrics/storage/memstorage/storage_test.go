@@ -0,0 +1,54 @@
+package memstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/metrics/service"
+)
+
+func TestUpdateBatch(t *testing.T) {
+	ctx := context.Background()
+	storage := New()
+
+	histogram := models.Histogram{Name: "histogram",
+		Value: models.HistogramValue{Bounds: []float64{1}, Counts: []uint64{1, 1}, Sum: 2, Count: 2}}
+	_, err := storage.UpdateHistogram(ctx, histogram)
+	require.NoError(t, err)
+
+	t.Run("batch is applied", func(t *testing.T) {
+		updated, err := storage.UpdateBatch(ctx, service.Batch{
+			Counters:   models.CountersList{{Name: "counter", Value: 1}, {Name: "counter", Value: 2}},
+			Gauges:     models.GaugesList{{Name: "gauge", Value: 1.5}},
+			Histograms: models.HistogramsList{histogram},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.CountersList{{Name: "counter", Value: 1}, {Name: "counter", Value: 3}}, updated.Counters)
+		assert.Equal(t, uint64(4), updated.Histograms[0].Value.Count)
+	})
+
+	t.Run("failed batch is not applied", func(t *testing.T) {
+		mismatched := models.Histogram{Name: "histogram",
+			Value: models.HistogramValue{Bounds: []float64{2}, Counts: []uint64{1, 0}, Sum: 1, Count: 1}}
+		_, err := storage.UpdateBatch(ctx, service.Batch{
+			Counters:   models.CountersList{{Name: "counter", Value: 10}},
+			Gauges:     models.GaugesList{{Name: "gauge", Value: 2.5}},
+			Histograms: models.HistogramsList{histogram, mismatched},
+		})
+		require.Error(t, err)
+
+		counter, _, err := storage.FindCounter(ctx, "counter", nil)
+		require.NoError(t, err)
+		assert.Equal(t, models.CounterValue(3), counter.Value)
+		gauge, _, err := storage.FindGauge(ctx, "gauge", nil)
+		require.NoError(t, err)
+		assert.Equal(t, models.GaugeValue(1.5), gauge.Value)
+		found, _, err := storage.FindHistogram(ctx, "histogram", nil)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(4), found.Value.Count)
+	})
+}
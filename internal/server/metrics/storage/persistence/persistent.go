@@ -2,6 +2,7 @@ package persistence
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -11,19 +12,36 @@ import (
 	"go.uber.org/zap"
 )
 
+// wal is checkpointed on this size even without store interval
+const walCheckpointSize = 64 << 20
+
 type Config struct {
 	StateStorage  StateStorage
 	StoreInterval time.Duration
 	Restore       bool
+	// optional write-ahead log path. with wal every modification
+	// is appended to log, and state is stored as checkpoint
+	// each StoreInterval, after which log is cleared.
+	WALPath string
 }
 
+var _ service.HistoryStorage = (*Storage)(nil)
+var _ service.BatchStorage = (*Storage)(nil)
+
 type Storage struct {
 	service.Storage
 	sstorage StateStorage
 
-	saveCh chan time.Time
-	cancel context.CancelFunc
-	wg     sync.WaitGroup
+	// nil if wal is not used. lock serializes
+	// modifications and checkpoints
+	wal   *WAL
+	walMu sync.Mutex
+
+	saveCh     chan time.Time
+	intervalCh chan time.Duration
+	done       <-chan struct{}
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
 
 	logger *zap.Logger
 }
@@ -38,12 +56,23 @@ func New(cfg Config, base service.Storage, logger *zap.Logger) (*Storage, error)
 	if logger == nil {
 		return nil, fmt.Errorf("logger is nil")
 	}
+	var walSeq uint64
 	if cfg.Restore {
 		state, err := cfg.StateStorage.LoadState()
 		if err != nil {
-			logger.Warn("state loading: %v", zap.Error(err))
+			logger.Warn("state loading", zap.Error(err))
 		} else if err := state.Export(context.TODO(), base); err != nil {
-			logger.Warn("state exporting: %v", zap.Error(err))
+			logger.Warn("state exporting", zap.Error(err))
+		} else {
+			walSeq = state.WALSeq
+		}
+	}
+
+	var wal *WAL
+	if cfg.WALPath != "" {
+		var err error
+		if wal, err = openWAL(cfg.WALPath, cfg.Restore, walSeq, base, logger); err != nil {
+			return nil, err
 		}
 	}
 
@@ -54,11 +83,14 @@ func New(cfg Config, base service.Storage, logger *zap.Logger) (*Storage, error)
 	// and in other cases storing are based on our channel.
 	// unoptimal? defenetly. simple? yes.
 	storage := &Storage{
-		Storage:  base,
-		sstorage: cfg.StateStorage,
-		saveCh:   make(chan time.Time),
-		cancel:   cancel,
-		logger:   logger,
+		Storage:    base,
+		sstorage:   cfg.StateStorage,
+		wal:        wal,
+		saveCh:     make(chan time.Time),
+		intervalCh: make(chan time.Duration),
+		done:       ctx.Done(),
+		cancel:     cancel,
+		logger:     logger,
 	}
 
 	// run storing loop, sync or async
@@ -71,9 +103,72 @@ func New(cfg Config, base service.Storage, logger *zap.Logger) (*Storage, error)
 	return storage, nil
 }
 
+// open wal and replay its records after snapshot,
+// or clear it if state is not restored
+func openWAL(path string, restore bool, afterSeq uint64, base service.Storage, logger *zap.Logger) (*WAL, error) {
+	wal, err := OpenWAL(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !restore {
+		if err := wal.Reset(); err != nil {
+			return nil, errors.Join(err, wal.Close())
+		}
+		return wal, nil
+	}
+
+	replayed, torn, err := wal.Replay(afterSeq, func(rec walRecord) error {
+		if err := applyWALRecord(context.TODO(), base, rec); err != nil {
+			// the same error was returned to client, skip record
+			logger.Warn("wal record applying", zap.Uint64("seq", rec.Seq), zap.Error(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Join(err, wal.Close())
+	}
+	if torn {
+		logger.Warn("wal torn tail is cut off")
+	}
+	logger.Info("wal replayed", zap.Int("records", replayed))
+
+	return wal, nil
+}
+
+func applyWALRecord(ctx context.Context, base service.Storage, rec walRecord) error {
+	switch rec.Op {
+	case opSetGauges:
+		return base.SetGauges(ctx, rec.Gauges)
+	case opReplaceGauges:
+		return base.ReplaceGauges(ctx, rec.Gauges)
+	case opUpdateCounters:
+		_, err := base.UpdateCounters(ctx, rec.Counters)
+		return err
+	case opReplaceCounters:
+		return base.ReplaceCounters(ctx, rec.Counters)
+	case opUpdateHistograms:
+		_, err := base.UpdateHistograms(ctx, rec.Histograms)
+		return err
+	case opReplaceHistograms:
+		return base.ReplaceHistograms(ctx, rec.Histograms)
+	case opUpdateBatch:
+		_, err := updateBatch(ctx, base, service.Batch{
+			Counters: rec.Counters, Gauges: rec.Gauges, Histograms: rec.Histograms})
+		return err
+	default:
+		return fmt.Errorf("unknown wal op %q", rec.Op)
+	}
+}
+
 func (s *Storage) Close() error {
 	s.cancel()
 	s.wg.Wait()
+	if s.wal != nil {
+		if err := s.wal.Close(); err != nil {
+			return fmt.Errorf("wal closing: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -81,44 +176,41 @@ func (s *Storage) SetGauge(ctx context.Context, val models.Gauge) error {
 	if s == nil || s.Storage == nil {
 		return fmt.Errorf("storage not exists")
 	}
-	if err := s.Storage.SetGauge(ctx, val); err != nil {
-		return err
-	}
-	s.onModify()
-	return nil
+	return s.modify(walRecord{Op: opSetGauges, Gauges: models.GaugesList{val}}, func() error {
+		return s.Storage.SetGauge(ctx, val)
+	})
 }
 
 func (s *Storage) SetGauges(ctx context.Context, vals models.GaugesList) error {
 	if s == nil || s.Storage == nil {
 		return fmt.Errorf("storage not exists")
 	}
-	if err := s.Storage.SetGauges(ctx, vals); err != nil {
-		return err
-	}
-	s.onModify()
-	return nil
+	return s.modify(walRecord{Op: opSetGauges, Gauges: vals}, func() error {
+		return s.Storage.SetGauges(ctx, vals)
+	})
 }
 
 func (s *Storage) ReplaceGauges(ctx context.Context, val models.GaugesList) error {
 	if s == nil || s.Storage == nil {
 		return fmt.Errorf("storage not exists")
 	}
-	if err := s.Storage.ReplaceGauges(ctx, val); err != nil {
-		return err
-	}
-	s.onModify()
-	return nil
+	return s.modify(walRecord{Op: opReplaceGauges, Gauges: val}, func() error {
+		return s.Storage.ReplaceGauges(ctx, val)
+	})
 }
 
 func (s *Storage) UpdateCounter(ctx context.Context, val models.Counter) (*models.Counter, error) {
 	if s == nil || s.Storage == nil {
 		return nil, fmt.Errorf("storage not exists")
 	}
-	updated, err := s.Storage.UpdateCounter(ctx, val)
+	var updated *models.Counter
+	err := s.modify(walRecord{Op: opUpdateCounters, Counters: models.CountersList{val}}, func() (err error) {
+		updated, err = s.Storage.UpdateCounter(ctx, val)
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
-	s.onModify()
 	return updated, nil
 }
 
@@ -126,11 +218,14 @@ func (s *Storage) UpdateCounters(ctx context.Context, vals models.CountersList)
 	if s == nil || s.Storage == nil {
 		return nil, fmt.Errorf("storage not exists")
 	}
-	updated, err := s.Storage.UpdateCounters(ctx, vals)
+	var updated models.CountersList
+	err := s.modify(walRecord{Op: opUpdateCounters, Counters: vals}, func() (err error) {
+		updated, err = s.Storage.UpdateCounters(ctx, vals)
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
-	s.onModify()
 	return updated, nil
 }
 
@@ -138,47 +233,202 @@ func (s *Storage) ReplaceCounters(ctx context.Context, val models.CountersList)
 	if s == nil || s.Storage == nil {
 		return fmt.Errorf("storage not exists")
 	}
-	if err := s.Storage.ReplaceCounters(ctx, val); err != nil {
-		return err
+	return s.modify(walRecord{Op: opReplaceCounters, Counters: val}, func() error {
+		return s.Storage.ReplaceCounters(ctx, val)
+	})
+}
+
+func (s *Storage) UpdateHistogram(ctx context.Context, val models.Histogram) (*models.Histogram, error) {
+	if s == nil || s.Storage == nil {
+		return nil, fmt.Errorf("storage not exists")
+	}
+	var updated *models.Histogram
+	err := s.modify(walRecord{Op: opUpdateHistograms, Histograms: models.HistogramsList{val}}, func() (err error) {
+		updated, err = s.Storage.UpdateHistogram(ctx, val)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *Storage) UpdateHistograms(ctx context.Context, vals models.HistogramsList) (models.HistogramsList, error) {
+	if s == nil || s.Storage == nil {
+		return nil, fmt.Errorf("storage not exists")
+	}
+	var updated models.HistogramsList
+	err := s.modify(walRecord{Op: opUpdateHistograms, Histograms: vals}, func() (err error) {
+		updated, err = s.Storage.UpdateHistograms(ctx, vals)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *Storage) UpdateBatch(ctx context.Context, batch service.Batch) (*service.Batch, error) {
+	if s == nil || s.Storage == nil {
+		return nil, fmt.Errorf("storage not exists")
+	}
+	rec := walRecord{Op: opUpdateBatch, Counters: batch.Counters, Gauges: batch.Gauges, Histograms: batch.Histograms}
+	var updated *service.Batch
+	err := s.modify(rec, func() (err error) {
+		updated, err = updateBatch(ctx, s.Storage, batch)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func updateBatch(ctx context.Context, base service.Storage, batch service.Batch) (*service.Batch, error) {
+	batchStorage, ok := base.(service.BatchStorage)
+	if !ok {
+		return nil, fmt.Errorf("base storage doesn't support batches")
+	}
+	return batchStorage.UpdateBatch(ctx, batch)
+}
+
+func (s *Storage) ReplaceHistograms(ctx context.Context, val models.HistogramsList) error {
+	if s == nil || s.Storage == nil {
+		return fmt.Errorf("storage not exists")
+	}
+	return s.modify(walRecord{Op: opReplaceHistograms, Histograms: val}, func() error {
+		return s.Storage.ReplaceHistograms(ctx, val)
+	})
+}
+
+// history is not persisted, just pass it from base storage
+
+func (s *Storage) GaugeHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.GaugeSamples, error) {
+	history, err := s.historyStorage()
+	if err != nil {
+		return nil, err
+	}
+	return history.GaugeHistory(ctx, name, labels, from, to)
+}
+
+func (s *Storage) CounterHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.CounterSamples, error) {
+	history, err := s.historyStorage()
+	if err != nil {
+		return nil, err
+	}
+	return history.CounterHistory(ctx, name, labels, from, to)
+}
+
+func (s *Storage) historyStorage() (service.HistoryStorage, error) {
+	if s == nil || s.Storage == nil {
+		return nil, fmt.Errorf("storage not exists")
+	}
+	history, ok := s.Storage.(service.HistoryStorage)
+	if !ok {
+		return nil, fmt.Errorf("storage has no history")
+	}
+	return history, nil
+}
+
+// store interval may be changed on the fly
+func (s *Storage) SetStoreInterval(interval time.Duration) {
+	select {
+	case s.intervalCh <- interval:
+	case <-s.done:
 	}
-	s.onModify()
-	return nil
 }
 
 func (s *Storage) runStoringLoop(ctx context.Context, interval time.Duration) {
-	if interval > 0 {
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
-		s.channelStoringLoop(ctx, ticker.C)
-	} else {
-		s.channelStoringLoop(ctx, s.saveCh)
+	for {
+		var ok bool
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			interval, ok = s.channelStoringLoop(ctx, ticker.C)
+			ticker.Stop()
+		} else {
+			interval, ok = s.channelStoringLoop(ctx, s.saveCh)
+		}
+		if !ok {
+			return
+		}
 	}
 }
 
-func (s *Storage) channelStoringLoop(ctx context.Context, ch <-chan time.Time) {
+// returns new store interval if it was changed,
+// or false if storing is finished
+func (s *Storage) channelStoringLoop(ctx context.Context, ch <-chan time.Time) (time.Duration, bool) {
 	for {
 		select {
 		case <-ch:
 			if err := s.storeState(ctx); err != nil {
 				s.logger.Error("store state", zap.Error(err))
 			}
+		case interval := <-s.intervalCh:
+			// modifications made before switching
+			// to sync storing shouldn't wait for next one
+			if err := s.storeState(ctx); err != nil {
+				s.logger.Error("store state", zap.Error(err))
+			}
+			return interval, true
 		case <-ctx.Done():
 			if err := s.storeState(ctx); err != nil {
 				s.logger.Error("store state", zap.Error(err))
 			}
-			return
+			return 0, false
 		}
 	}
 }
 
 func (s *Storage) storeState(ctx context.Context) error {
+	if s.wal != nil {
+		s.walMu.Lock()
+		defer s.walMu.Unlock()
+	}
+
 	var state State
 	if err := state.Import(ctx, s.Storage); err != nil {
 		return fmt.Errorf("storage state request: %v", err)
 	}
+	if s.wal != nil {
+		state.WALSeq = s.wal.Seq()
+	}
 	if err := s.sstorage.StoreState(state); err != nil {
 		return fmt.Errorf("storage state storing: %v", err)
 	}
+	// if we crash before reset, records
+	// will be skipped on replay by seq
+	if s.wal != nil {
+		if err := s.wal.Reset(); err != nil {
+			return fmt.Errorf("wal reset: %v", err)
+		}
+	}
+	return nil
+}
+
+// apply modification and notify storing loop.
+// with wal modification is logged before applying,
+// and storing loop is notified only when wal is too large.
+func (s *Storage) modify(rec walRecord, apply func() error) error {
+	if s.wal == nil {
+		if err := apply(); err != nil {
+			return err
+		}
+		s.onModify()
+		return nil
+	}
+
+	s.walMu.Lock()
+	defer s.walMu.Unlock()
+
+	if err := s.wal.Append(rec); err != nil {
+		return fmt.Errorf("wal append: %v", err)
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	if s.wal.Size() > walCheckpointSize {
+		s.onModify()
+	}
 	return nil
 }
 
@@ -9,8 +9,11 @@ import (
 )
 
 type State struct {
-	Counters []models.Counter `json:"counters"`
-	Gauges   []models.Gauge   `json:"gauge"`
+	Counters   []models.Counter   `json:"counters"`
+	Gauges     []models.Gauge     `json:"gauge"`
+	Histograms []models.Histogram `json:"histograms,omitempty"`
+	// seq of last wal record included in state
+	WALSeq uint64 `json:"wal_seq,omitempty"`
 }
 
 func (s *State) Export(ctx context.Context, storage service.Storage) error {
@@ -26,6 +29,9 @@ func (s *State) Export(ctx context.Context, storage service.Storage) error {
 	if err := storage.ReplaceGauges(ctx, s.Gauges); err != nil {
 		return fmt.Errorf("replacing storage gauges: %v", err)
 	}
+	if err := storage.ReplaceHistograms(ctx, s.Histograms); err != nil {
+		return fmt.Errorf("replacing storage histograms: %v", err)
+	}
 	return nil
 }
 
@@ -45,5 +51,9 @@ func (s *State) Import(ctx context.Context, storage service.Storage) error {
 	if err != nil {
 		return fmt.Errorf("requesting storage gauges: %v", err)
 	}
+	s.Histograms, err = storage.ListHistograms(ctx)
+	if err != nil {
+		return fmt.Errorf("requesting storage histograms: %v", err)
+	}
 	return nil
 }
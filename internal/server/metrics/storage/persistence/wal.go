@@ -0,0 +1,179 @@
+package persistence
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+type walOp string
+
+const (
+	opSetGauges         walOp = "sg"
+	opReplaceGauges     walOp = "rg"
+	opUpdateCounters    walOp = "uc"
+	opReplaceCounters   walOp = "rc"
+	opUpdateHistograms  walOp = "uh"
+	opReplaceHistograms walOp = "rh"
+	opUpdateBatch       walOp = "ub"
+)
+
+// one storage modification
+type walRecord struct {
+	// sequence number, records with seq less or equal than
+	// snapshot's one are already in snapshot
+	Seq        uint64             `json:"s"`
+	Op         walOp              `json:"op"`
+	Gauges     []models.Gauge     `json:"g,omitempty"`
+	Counters   []models.Counter   `json:"c,omitempty"`
+	Histograms []models.Histogram `json:"h,omitempty"`
+}
+
+// record is framed as payload length and crc32 of payload
+// (both are little endian uint32) followed by json payload,
+// so torn or corrupted record is detected on replay
+const walHeaderSize = 8
+
+// larger length in header is a garbage
+const walMaxRecordSize = 64 << 20
+
+// append-only log of storage modifications
+type WAL struct {
+	file *os.File
+	seq  uint64
+	size int64
+}
+
+func OpenWAL(path string) (*WAL, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("wal file opening: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("wal file stat: %v", err), file.Close())
+	}
+	return &WAL{file: file, size: info.Size()}, nil
+}
+
+// replay records after seq, torn or corrupted tail is cut off.
+// returns count of replayed records and if tail was cut.
+func (w *WAL) Replay(afterSeq uint64, apply func(walRecord) error) (int, bool, error) {
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return 0, false, fmt.Errorf("wal seek: %v", err)
+	}
+
+	w.seq = afterSeq
+	reader := bufio.NewReader(w.file)
+	var offset int64
+	replayed := 0
+	for {
+		rec, size, err := readWALRecord(reader)
+		if errors.Is(err, io.EOF) {
+			return replayed, false, nil
+		}
+		if err != nil {
+			// everything after last good record is garbage
+			if truncErr := w.file.Truncate(offset); truncErr != nil {
+				return replayed, true, fmt.Errorf("wal truncate torn tail: %v", truncErr)
+			}
+			w.size = offset
+			return replayed, true, nil
+		}
+		offset += size
+
+		if rec.Seq <= afterSeq {
+			continue
+		}
+		if err := apply(*rec); err != nil {
+			return replayed, false, fmt.Errorf("wal record %d replay: %v", rec.Seq, err)
+		}
+		w.seq = rec.Seq
+		replayed++
+	}
+}
+
+func readWALRecord(r io.Reader) (*walRecord, int64, error) {
+	var header [walHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, 0, fmt.Errorf("torn record header")
+		}
+		return nil, 0, err
+	}
+	length := binary.LittleEndian.Uint32(header[0:4])
+	checksum := binary.LittleEndian.Uint32(header[4:8])
+	if length > walMaxRecordSize {
+		return nil, 0, fmt.Errorf("invalid record length %d", length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, fmt.Errorf("torn record payload: %v", err)
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, 0, fmt.Errorf("record checksum mismatch")
+	}
+
+	var rec walRecord
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return nil, 0, fmt.Errorf("record decoding: %v", err)
+	}
+	return &rec, walHeaderSize + int64(length), nil
+}
+
+// append record with next seq and sync it to disk
+func (w *WAL) Append(rec walRecord) error {
+	rec.Seq = w.seq + 1
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("wal record encoding: %v", err)
+	}
+
+	data := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(data[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(data[4:8], crc32.ChecksumIEEE(payload))
+	copy(data[walHeaderSize:], payload)
+
+	if _, err := w.file.Write(data); err != nil {
+		return fmt.Errorf("wal write: %v", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("wal sync: %v", err)
+	}
+	w.seq = rec.Seq
+	w.size += int64(len(data))
+	return nil
+}
+
+// drop all records after checkpoint, seq is not reset
+func (w *WAL) Reset() error {
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("wal truncate: %v", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("wal sync: %v", err)
+	}
+	w.size = 0
+	return nil
+}
+
+// seq of last appended or replayed record
+func (w *WAL) Seq() uint64 {
+	return w.seq
+}
+
+func (w *WAL) Size() int64 {
+	return w.size
+}
+
+func (w *WAL) Close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,95 @@
+package persistence
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/metrics/storage/memstorage"
+)
+
+func TestWALTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.wal")
+
+	wal, err := OpenWAL(path)
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, wal.Append(walRecord{
+			Op:       opUpdateCounters,
+			Counters: models.CountersList{{Name: "counter", Value: models.CounterValue(i)}},
+		}))
+	}
+	require.NoError(t, wal.Close())
+
+	// cut last record in the middle like crash on write
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-5))
+
+	wal, err = OpenWAL(path)
+	require.NoError(t, err)
+	defer wal.Close()
+
+	var seqs []uint64
+	replayed, torn, err := wal.Replay(1, func(rec walRecord) error {
+		seqs = append(seqs, rec.Seq)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, torn)
+	assert.Equal(t, 1, replayed)
+	assert.Equal(t, []uint64{2}, seqs)
+
+	// next record is appended after last good one
+	require.NoError(t, wal.Append(walRecord{Op: opSetGauges}))
+	assert.Equal(t, uint64(3), wal.Seq())
+	replayed, torn, err = wal.Replay(0, func(rec walRecord) error { return nil })
+	require.NoError(t, err)
+	assert.False(t, torn)
+	assert.Equal(t, 3, replayed)
+}
+
+func TestWALStorageRestore(t *testing.T) {
+	dir := t.TempDir()
+	stateStorage := NewJSONStateStorage(filepath.Join(dir, "storage.json"))
+	cfg := Config{
+		StateStorage:  &stateStorage,
+		StoreInterval: time.Hour,
+		Restore:       true,
+		WALPath:       filepath.Join(dir, "storage.wal"),
+	}
+	ctx := context.Background()
+
+	// checkpoint, then modifications in wal only
+	storage, err := New(cfg, memstorage.New(), zap.NewNop())
+	require.NoError(t, err)
+	_, err = storage.UpdateCounter(ctx, models.Counter{Name: "counter", Value: 1})
+	require.NoError(t, err)
+	require.NoError(t, storage.storeState(ctx))
+	_, err = storage.UpdateCounter(ctx, models.Counter{Name: "counter", Value: 2})
+	require.NoError(t, err)
+	require.NoError(t, storage.SetGauge(ctx, models.Gauge{Name: "gauge", Value: 1.5}))
+
+	// restore without closing, like after crash
+	restored, err := New(cfg, memstorage.New(), zap.NewNop())
+	require.NoError(t, err)
+
+	counter, exists, err := restored.FindCounter(ctx, "counter", nil)
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, models.CounterValue(3), counter.Value)
+	gauge, exists, err := restored.FindGauge(ctx, "gauge", nil)
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, models.GaugeValue(1.5), gauge.Value)
+
+	require.NoError(t, restored.Close())
+	require.NoError(t, storage.Close())
+}
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/stepkareserva/obsermon/internal/models"
 	gomock "go.uber.org/mock/gomock"
@@ -157,6 +158,76 @@ func (mr *MockCountersServiceMockRecorder) UpdateCounter(ctx, val any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCounter", reflect.TypeOf((*MockCountersService)(nil).UpdateCounter), ctx, val)
 }
 
+// MockHistogramsService is a mock of HistogramsService interface.
+type MockHistogramsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistogramsServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockHistogramsServiceMockRecorder is the mock recorder for MockHistogramsService.
+type MockHistogramsServiceMockRecorder struct {
+	mock *MockHistogramsService
+}
+
+// NewMockHistogramsService creates a new mock instance.
+func NewMockHistogramsService(ctrl *gomock.Controller) *MockHistogramsService {
+	mock := &MockHistogramsService{ctrl: ctrl}
+	mock.recorder = &MockHistogramsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistogramsService) EXPECT() *MockHistogramsServiceMockRecorder {
+	return m.recorder
+}
+
+// FindHistogram mocks base method.
+func (m *MockHistogramsService) FindHistogram(ctx context.Context, name string) (*models.Histogram, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindHistogram", ctx, name)
+	ret0, _ := ret[0].(*models.Histogram)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindHistogram indicates an expected call of FindHistogram.
+func (mr *MockHistogramsServiceMockRecorder) FindHistogram(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindHistogram", reflect.TypeOf((*MockHistogramsService)(nil).FindHistogram), ctx, name)
+}
+
+// ListHistograms mocks base method.
+func (m *MockHistogramsService) ListHistograms(ctx context.Context) (models.HistogramsList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHistograms", ctx)
+	ret0, _ := ret[0].(models.HistogramsList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHistograms indicates an expected call of ListHistograms.
+func (mr *MockHistogramsServiceMockRecorder) ListHistograms(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHistograms", reflect.TypeOf((*MockHistogramsService)(nil).ListHistograms), ctx)
+}
+
+// UpdateHistogram mocks base method.
+func (m *MockHistogramsService) UpdateHistogram(ctx context.Context, val models.Histogram) (*models.Histogram, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHistogram", ctx, val)
+	ret0, _ := ret[0].(*models.Histogram)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateHistogram indicates an expected call of UpdateHistogram.
+func (mr *MockHistogramsServiceMockRecorder) UpdateHistogram(ctx, val any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHistogram", reflect.TypeOf((*MockHistogramsService)(nil).UpdateHistogram), ctx, val)
+}
+
 // MockMetricsService is a mock of MetricsService interface.
 type MockMetricsService struct {
 	ctrl     *gomock.Controller
@@ -182,9 +253,9 @@ func (m *MockMetricsService) EXPECT() *MockMetricsServiceMockRecorder {
 }
 
 // FindMetric mocks base method.
-func (m *MockMetricsService) FindMetric(ctx context.Context, t models.MetricType, name string) (*models.Metric, bool, error) {
+func (m *MockMetricsService) FindMetric(ctx context.Context, t models.MetricType, name string, labels models.Labels) (*models.Metric, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindMetric", ctx, t, name)
+	ret := m.ctrl.Call(m, "FindMetric", ctx, t, name, labels)
 	ret0, _ := ret[0].(*models.Metric)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(error)
@@ -192,9 +263,9 @@ func (m *MockMetricsService) FindMetric(ctx context.Context, t models.MetricType
 }
 
 // FindMetric indicates an expected call of FindMetric.
-func (mr *MockMetricsServiceMockRecorder) FindMetric(ctx, t, name any) *gomock.Call {
+func (mr *MockMetricsServiceMockRecorder) FindMetric(ctx, t, name, labels any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMetric", reflect.TypeOf((*MockMetricsService)(nil).FindMetric), ctx, t, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMetric", reflect.TypeOf((*MockMetricsService)(nil).FindMetric), ctx, t, name, labels)
 }
 
 // UpdateMetric mocks base method.
@@ -227,6 +298,192 @@ func (mr *MockMetricsServiceMockRecorder) UpdateMetrics(ctx, vals any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMetrics", reflect.TypeOf((*MockMetricsService)(nil).UpdateMetrics), ctx, vals)
 }
 
+// MockHistoryService is a mock of HistoryService interface.
+type MockHistoryService struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistoryServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockHistoryServiceMockRecorder is the mock recorder for MockHistoryService.
+type MockHistoryServiceMockRecorder struct {
+	mock *MockHistoryService
+}
+
+// NewMockHistoryService creates a new mock instance.
+func NewMockHistoryService(ctrl *gomock.Controller) *MockHistoryService {
+	mock := &MockHistoryService{ctrl: ctrl}
+	mock.recorder = &MockHistoryServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistoryService) EXPECT() *MockHistoryServiceMockRecorder {
+	return m.recorder
+}
+
+// CounterHistory mocks base method.
+func (m *MockHistoryService) CounterHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.CounterSamples, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CounterHistory", ctx, name, labels, from, to)
+	ret0, _ := ret[0].(models.CounterSamples)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CounterHistory indicates an expected call of CounterHistory.
+func (mr *MockHistoryServiceMockRecorder) CounterHistory(ctx, name, labels, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CounterHistory", reflect.TypeOf((*MockHistoryService)(nil).CounterHistory), ctx, name, labels, from, to)
+}
+
+// GaugeHistory mocks base method.
+func (m *MockHistoryService) GaugeHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.GaugeSamples, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GaugeHistory", ctx, name, labels, from, to)
+	ret0, _ := ret[0].(models.GaugeSamples)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GaugeHistory indicates an expected call of GaugeHistory.
+func (mr *MockHistoryServiceMockRecorder) GaugeHistory(ctx, name, labels, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GaugeHistory", reflect.TypeOf((*MockHistoryService)(nil).GaugeHistory), ctx, name, labels, from, to)
+}
+
+// MockStreamService is a mock of StreamService interface.
+type MockStreamService struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockStreamServiceMockRecorder is the mock recorder for MockStreamService.
+type MockStreamServiceMockRecorder struct {
+	mock *MockStreamService
+}
+
+// NewMockStreamService creates a new mock instance.
+func NewMockStreamService(ctrl *gomock.Controller) *MockStreamService {
+	mock := &MockStreamService{ctrl: ctrl}
+	mock.recorder = &MockStreamServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStreamService) EXPECT() *MockStreamServiceMockRecorder {
+	return m.recorder
+}
+
+// Subscribe mocks base method.
+func (m *MockStreamService) Subscribe(ctx context.Context, names []string) (<-chan models.Metric, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, names)
+	ret0, _ := ret[0].(<-chan models.Metric)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockStreamServiceMockRecorder) Subscribe(ctx, names any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockStreamService)(nil).Subscribe), ctx, names)
+}
+
+// MockAlertsService is a mock of AlertsService interface.
+type MockAlertsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAlertsServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockAlertsServiceMockRecorder is the mock recorder for MockAlertsService.
+type MockAlertsServiceMockRecorder struct {
+	mock *MockAlertsService
+}
+
+// NewMockAlertsService creates a new mock instance.
+func NewMockAlertsService(ctrl *gomock.Controller) *MockAlertsService {
+	mock := &MockAlertsService{ctrl: ctrl}
+	mock.recorder = &MockAlertsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAlertsService) EXPECT() *MockAlertsServiceMockRecorder {
+	return m.recorder
+}
+
+// ListAlerts mocks base method.
+func (m *MockAlertsService) ListAlerts(ctx context.Context) (models.AlertsList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAlerts", ctx)
+	ret0, _ := ret[0].(models.AlertsList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAlerts indicates an expected call of ListAlerts.
+func (mr *MockAlertsServiceMockRecorder) ListAlerts(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAlerts", reflect.TypeOf((*MockAlertsService)(nil).ListAlerts), ctx)
+}
+
+// MockIdempotencyService is a mock of IdempotencyService interface.
+type MockIdempotencyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockIdempotencyServiceMockRecorder is the mock recorder for MockIdempotencyService.
+type MockIdempotencyServiceMockRecorder struct {
+	mock *MockIdempotencyService
+}
+
+// NewMockIdempotencyService creates a new mock instance.
+func NewMockIdempotencyService(ctrl *gomock.Controller) *MockIdempotencyService {
+	mock := &MockIdempotencyService{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyService) EXPECT() *MockIdempotencyServiceMockRecorder {
+	return m.recorder
+}
+
+// FindResponse mocks base method.
+func (m *MockIdempotencyService) FindResponse(ctx context.Context, key string) (*models.IdempotentResponse, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindResponse", ctx, key)
+	ret0, _ := ret[0].(*models.IdempotentResponse)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindResponse indicates an expected call of FindResponse.
+func (mr *MockIdempotencyServiceMockRecorder) FindResponse(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResponse", reflect.TypeOf((*MockIdempotencyService)(nil).FindResponse), ctx, key)
+}
+
+// SaveResponse mocks base method.
+func (m *MockIdempotencyService) SaveResponse(ctx context.Context, key string, resp models.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveResponse", ctx, key, resp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveResponse indicates an expected call of SaveResponse.
+func (mr *MockIdempotencyServiceMockRecorder) SaveResponse(ctx, key, resp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveResponse", reflect.TypeOf((*MockIdempotencyService)(nil).SaveResponse), ctx, key, resp)
+}
+
 // MockPingableService is a mock of PingableService interface.
 type MockPingableService struct {
 	ctrl     *gomock.Controller
@@ -289,6 +546,21 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// CounterHistory mocks base method.
+func (m *MockService) CounterHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.CounterSamples, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CounterHistory", ctx, name, labels, from, to)
+	ret0, _ := ret[0].(models.CounterSamples)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CounterHistory indicates an expected call of CounterHistory.
+func (mr *MockServiceMockRecorder) CounterHistory(ctx, name, labels, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CounterHistory", reflect.TypeOf((*MockService)(nil).CounterHistory), ctx, name, labels, from, to)
+}
+
 // FindCounter mocks base method.
 func (m *MockService) FindCounter(ctx context.Context, name string) (*models.Counter, bool, error) {
 	m.ctrl.T.Helper()
@@ -321,10 +593,26 @@ func (mr *MockServiceMockRecorder) FindGauge(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindGauge", reflect.TypeOf((*MockService)(nil).FindGauge), ctx, name)
 }
 
+// FindHistogram mocks base method.
+func (m *MockService) FindHistogram(ctx context.Context, name string) (*models.Histogram, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindHistogram", ctx, name)
+	ret0, _ := ret[0].(*models.Histogram)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindHistogram indicates an expected call of FindHistogram.
+func (mr *MockServiceMockRecorder) FindHistogram(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindHistogram", reflect.TypeOf((*MockService)(nil).FindHistogram), ctx, name)
+}
+
 // FindMetric mocks base method.
-func (m *MockService) FindMetric(ctx context.Context, t models.MetricType, name string) (*models.Metric, bool, error) {
+func (m *MockService) FindMetric(ctx context.Context, t models.MetricType, name string, labels models.Labels) (*models.Metric, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindMetric", ctx, t, name)
+	ret := m.ctrl.Call(m, "FindMetric", ctx, t, name, labels)
 	ret0, _ := ret[0].(*models.Metric)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(error)
@@ -332,9 +620,55 @@ func (m *MockService) FindMetric(ctx context.Context, t models.MetricType, name
 }
 
 // FindMetric indicates an expected call of FindMetric.
-func (mr *MockServiceMockRecorder) FindMetric(ctx, t, name any) *gomock.Call {
+func (mr *MockServiceMockRecorder) FindMetric(ctx, t, name, labels any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMetric", reflect.TypeOf((*MockService)(nil).FindMetric), ctx, t, name, labels)
+}
+
+// FindResponse mocks base method.
+func (m *MockService) FindResponse(ctx context.Context, key string) (*models.IdempotentResponse, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindResponse", ctx, key)
+	ret0, _ := ret[0].(*models.IdempotentResponse)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindResponse indicates an expected call of FindResponse.
+func (mr *MockServiceMockRecorder) FindResponse(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResponse", reflect.TypeOf((*MockService)(nil).FindResponse), ctx, key)
+}
+
+// GaugeHistory mocks base method.
+func (m *MockService) GaugeHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.GaugeSamples, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GaugeHistory", ctx, name, labels, from, to)
+	ret0, _ := ret[0].(models.GaugeSamples)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GaugeHistory indicates an expected call of GaugeHistory.
+func (mr *MockServiceMockRecorder) GaugeHistory(ctx, name, labels, from, to any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMetric", reflect.TypeOf((*MockService)(nil).FindMetric), ctx, t, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GaugeHistory", reflect.TypeOf((*MockService)(nil).GaugeHistory), ctx, name, labels, from, to)
+}
+
+// ListAlerts mocks base method.
+func (m *MockService) ListAlerts(ctx context.Context) (models.AlertsList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAlerts", ctx)
+	ret0, _ := ret[0].(models.AlertsList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAlerts indicates an expected call of ListAlerts.
+func (mr *MockServiceMockRecorder) ListAlerts(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAlerts", reflect.TypeOf((*MockService)(nil).ListAlerts), ctx)
 }
 
 // ListCounters mocks base method.
@@ -367,6 +701,21 @@ func (mr *MockServiceMockRecorder) ListGauges(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGauges", reflect.TypeOf((*MockService)(nil).ListGauges), ctx)
 }
 
+// ListHistograms mocks base method.
+func (m *MockService) ListHistograms(ctx context.Context) (models.HistogramsList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHistograms", ctx)
+	ret0, _ := ret[0].(models.HistogramsList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHistograms indicates an expected call of ListHistograms.
+func (mr *MockServiceMockRecorder) ListHistograms(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHistograms", reflect.TypeOf((*MockService)(nil).ListHistograms), ctx)
+}
+
 // Ping mocks base method.
 func (m *MockService) Ping(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -381,6 +730,35 @@ func (mr *MockServiceMockRecorder) Ping(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockService)(nil).Ping), ctx)
 }
 
+// SaveResponse mocks base method.
+func (m *MockService) SaveResponse(ctx context.Context, key string, resp models.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveResponse", ctx, key, resp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveResponse indicates an expected call of SaveResponse.
+func (mr *MockServiceMockRecorder) SaveResponse(ctx, key, resp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveResponse", reflect.TypeOf((*MockService)(nil).SaveResponse), ctx, key, resp)
+}
+
+// Subscribe mocks base method.
+func (m *MockService) Subscribe(ctx context.Context, names []string) (<-chan models.Metric, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, names)
+	ret0, _ := ret[0].(<-chan models.Metric)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockServiceMockRecorder) Subscribe(ctx, names any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockService)(nil).Subscribe), ctx, names)
+}
+
 // UpdateCounter mocks base method.
 func (m *MockService) UpdateCounter(ctx context.Context, val models.Counter) (*models.Counter, error) {
 	m.ctrl.T.Helper()
@@ -411,6 +789,21 @@ func (mr *MockServiceMockRecorder) UpdateGauge(ctx, val any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGauge", reflect.TypeOf((*MockService)(nil).UpdateGauge), ctx, val)
 }
 
+// UpdateHistogram mocks base method.
+func (m *MockService) UpdateHistogram(ctx context.Context, val models.Histogram) (*models.Histogram, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHistogram", ctx, val)
+	ret0, _ := ret[0].(*models.Histogram)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateHistogram indicates an expected call of UpdateHistogram.
+func (mr *MockServiceMockRecorder) UpdateHistogram(ctx, val any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHistogram", reflect.TypeOf((*MockService)(nil).UpdateHistogram), ctx, val)
+}
+
 // UpdateMetric mocks base method.
 func (m *MockService) UpdateMetric(ctx context.Context, val models.Metric) (*models.Metric, error) {
 	m.ctrl.T.Helper()
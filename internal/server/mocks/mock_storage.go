@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/stepkareserva/obsermon/internal/models"
 	gomock "go.uber.org/mock/gomock"
@@ -42,9 +43,9 @@ func (m *MockGaugeStorage) EXPECT() *MockGaugeStorageMockRecorder {
 }
 
 // FindGauge mocks base method.
-func (m *MockGaugeStorage) FindGauge(ctx context.Context, name string) (*models.Gauge, bool, error) {
+func (m *MockGaugeStorage) FindGauge(ctx context.Context, name string, labels models.Labels) (*models.Gauge, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindGauge", ctx, name)
+	ret := m.ctrl.Call(m, "FindGauge", ctx, name, labels)
 	ret0, _ := ret[0].(*models.Gauge)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(error)
@@ -52,9 +53,9 @@ func (m *MockGaugeStorage) FindGauge(ctx context.Context, name string) (*models.
 }
 
 // FindGauge indicates an expected call of FindGauge.
-func (mr *MockGaugeStorageMockRecorder) FindGauge(ctx, name any) *gomock.Call {
+func (mr *MockGaugeStorageMockRecorder) FindGauge(ctx, name, labels any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindGauge", reflect.TypeOf((*MockGaugeStorage)(nil).FindGauge), ctx, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindGauge", reflect.TypeOf((*MockGaugeStorage)(nil).FindGauge), ctx, name, labels)
 }
 
 // ListGauges mocks base method.
@@ -139,9 +140,9 @@ func (m *MockCounterStorage) EXPECT() *MockCounterStorageMockRecorder {
 }
 
 // FindCounter mocks base method.
-func (m *MockCounterStorage) FindCounter(ctx context.Context, name string) (*models.Counter, bool, error) {
+func (m *MockCounterStorage) FindCounter(ctx context.Context, name string, labels models.Labels) (*models.Counter, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindCounter", ctx, name)
+	ret := m.ctrl.Call(m, "FindCounter", ctx, name, labels)
 	ret0, _ := ret[0].(*models.Counter)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(error)
@@ -149,9 +150,9 @@ func (m *MockCounterStorage) FindCounter(ctx context.Context, name string) (*mod
 }
 
 // FindCounter indicates an expected call of FindCounter.
-func (mr *MockCounterStorageMockRecorder) FindCounter(ctx, name any) *gomock.Call {
+func (mr *MockCounterStorageMockRecorder) FindCounter(ctx, name, labels any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCounter", reflect.TypeOf((*MockCounterStorage)(nil).FindCounter), ctx, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCounter", reflect.TypeOf((*MockCounterStorage)(nil).FindCounter), ctx, name, labels)
 }
 
 // ListCounters mocks base method.
@@ -213,6 +214,213 @@ func (mr *MockCounterStorageMockRecorder) UpdateCounters(ctx, vals any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCounters", reflect.TypeOf((*MockCounterStorage)(nil).UpdateCounters), ctx, vals)
 }
 
+// MockHistoryStorage is a mock of HistoryStorage interface.
+type MockHistoryStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistoryStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockHistoryStorageMockRecorder is the mock recorder for MockHistoryStorage.
+type MockHistoryStorageMockRecorder struct {
+	mock *MockHistoryStorage
+}
+
+// NewMockHistoryStorage creates a new mock instance.
+func NewMockHistoryStorage(ctrl *gomock.Controller) *MockHistoryStorage {
+	mock := &MockHistoryStorage{ctrl: ctrl}
+	mock.recorder = &MockHistoryStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistoryStorage) EXPECT() *MockHistoryStorageMockRecorder {
+	return m.recorder
+}
+
+// CounterHistory mocks base method.
+func (m *MockHistoryStorage) CounterHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.CounterSamples, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CounterHistory", ctx, name, labels, from, to)
+	ret0, _ := ret[0].(models.CounterSamples)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CounterHistory indicates an expected call of CounterHistory.
+func (mr *MockHistoryStorageMockRecorder) CounterHistory(ctx, name, labels, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CounterHistory", reflect.TypeOf((*MockHistoryStorage)(nil).CounterHistory), ctx, name, labels, from, to)
+}
+
+// GaugeHistory mocks base method.
+func (m *MockHistoryStorage) GaugeHistory(ctx context.Context, name string, labels models.Labels, from, to time.Time) (models.GaugeSamples, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GaugeHistory", ctx, name, labels, from, to)
+	ret0, _ := ret[0].(models.GaugeSamples)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GaugeHistory indicates an expected call of GaugeHistory.
+func (mr *MockHistoryStorageMockRecorder) GaugeHistory(ctx, name, labels, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GaugeHistory", reflect.TypeOf((*MockHistoryStorage)(nil).GaugeHistory), ctx, name, labels, from, to)
+}
+
+// MockHistogramStorage is a mock of HistogramStorage interface.
+type MockHistogramStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistogramStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockHistogramStorageMockRecorder is the mock recorder for MockHistogramStorage.
+type MockHistogramStorageMockRecorder struct {
+	mock *MockHistogramStorage
+}
+
+// NewMockHistogramStorage creates a new mock instance.
+func NewMockHistogramStorage(ctrl *gomock.Controller) *MockHistogramStorage {
+	mock := &MockHistogramStorage{ctrl: ctrl}
+	mock.recorder = &MockHistogramStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistogramStorage) EXPECT() *MockHistogramStorageMockRecorder {
+	return m.recorder
+}
+
+// FindHistogram mocks base method.
+func (m *MockHistogramStorage) FindHistogram(ctx context.Context, name string, labels models.Labels) (*models.Histogram, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindHistogram", ctx, name, labels)
+	ret0, _ := ret[0].(*models.Histogram)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindHistogram indicates an expected call of FindHistogram.
+func (mr *MockHistogramStorageMockRecorder) FindHistogram(ctx, name, labels any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindHistogram", reflect.TypeOf((*MockHistogramStorage)(nil).FindHistogram), ctx, name, labels)
+}
+
+// ListHistograms mocks base method.
+func (m *MockHistogramStorage) ListHistograms(ctx context.Context) (models.HistogramsList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHistograms", ctx)
+	ret0, _ := ret[0].(models.HistogramsList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHistograms indicates an expected call of ListHistograms.
+func (mr *MockHistogramStorageMockRecorder) ListHistograms(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHistograms", reflect.TypeOf((*MockHistogramStorage)(nil).ListHistograms), ctx)
+}
+
+// ReplaceHistograms mocks base method.
+func (m *MockHistogramStorage) ReplaceHistograms(ctx context.Context, val models.HistogramsList) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceHistograms", ctx, val)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceHistograms indicates an expected call of ReplaceHistograms.
+func (mr *MockHistogramStorageMockRecorder) ReplaceHistograms(ctx, val any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceHistograms", reflect.TypeOf((*MockHistogramStorage)(nil).ReplaceHistograms), ctx, val)
+}
+
+// UpdateHistogram mocks base method.
+func (m *MockHistogramStorage) UpdateHistogram(ctx context.Context, val models.Histogram) (*models.Histogram, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHistogram", ctx, val)
+	ret0, _ := ret[0].(*models.Histogram)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateHistogram indicates an expected call of UpdateHistogram.
+func (mr *MockHistogramStorageMockRecorder) UpdateHistogram(ctx, val any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHistogram", reflect.TypeOf((*MockHistogramStorage)(nil).UpdateHistogram), ctx, val)
+}
+
+// UpdateHistograms mocks base method.
+func (m *MockHistogramStorage) UpdateHistograms(ctx context.Context, vals models.HistogramsList) (models.HistogramsList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHistograms", ctx, vals)
+	ret0, _ := ret[0].(models.HistogramsList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateHistograms indicates an expected call of UpdateHistograms.
+func (mr *MockHistogramStorageMockRecorder) UpdateHistograms(ctx, vals any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHistograms", reflect.TypeOf((*MockHistogramStorage)(nil).UpdateHistograms), ctx, vals)
+}
+
+// MockIdempotencyStorage is a mock of IdempotencyStorage interface.
+type MockIdempotencyStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockIdempotencyStorageMockRecorder is the mock recorder for MockIdempotencyStorage.
+type MockIdempotencyStorageMockRecorder struct {
+	mock *MockIdempotencyStorage
+}
+
+// NewMockIdempotencyStorage creates a new mock instance.
+func NewMockIdempotencyStorage(ctrl *gomock.Controller) *MockIdempotencyStorage {
+	mock := &MockIdempotencyStorage{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyStorage) EXPECT() *MockIdempotencyStorageMockRecorder {
+	return m.recorder
+}
+
+// FindResponse mocks base method.
+func (m *MockIdempotencyStorage) FindResponse(ctx context.Context, key string) (*models.IdempotentResponse, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindResponse", ctx, key)
+	ret0, _ := ret[0].(*models.IdempotentResponse)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindResponse indicates an expected call of FindResponse.
+func (mr *MockIdempotencyStorageMockRecorder) FindResponse(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResponse", reflect.TypeOf((*MockIdempotencyStorage)(nil).FindResponse), ctx, key)
+}
+
+// SaveResponse mocks base method.
+func (m *MockIdempotencyStorage) SaveResponse(ctx context.Context, key string, resp models.IdempotentResponse, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveResponse", ctx, key, resp, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveResponse indicates an expected call of SaveResponse.
+func (mr *MockIdempotencyStorageMockRecorder) SaveResponse(ctx, key, resp, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveResponse", reflect.TypeOf((*MockIdempotencyStorage)(nil).SaveResponse), ctx, key, resp, ttl)
+}
+
 // MockPingable is a mock of Pingable interface.
 type MockPingable struct {
 	ctrl     *gomock.Controller
@@ -276,9 +484,9 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 }
 
 // FindCounter mocks base method.
-func (m *MockStorage) FindCounter(ctx context.Context, name string) (*models.Counter, bool, error) {
+func (m *MockStorage) FindCounter(ctx context.Context, name string, labels models.Labels) (*models.Counter, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindCounter", ctx, name)
+	ret := m.ctrl.Call(m, "FindCounter", ctx, name, labels)
 	ret0, _ := ret[0].(*models.Counter)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(error)
@@ -286,15 +494,15 @@ func (m *MockStorage) FindCounter(ctx context.Context, name string) (*models.Cou
 }
 
 // FindCounter indicates an expected call of FindCounter.
-func (mr *MockStorageMockRecorder) FindCounter(ctx, name any) *gomock.Call {
+func (mr *MockStorageMockRecorder) FindCounter(ctx, name, labels any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCounter", reflect.TypeOf((*MockStorage)(nil).FindCounter), ctx, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCounter", reflect.TypeOf((*MockStorage)(nil).FindCounter), ctx, name, labels)
 }
 
 // FindGauge mocks base method.
-func (m *MockStorage) FindGauge(ctx context.Context, name string) (*models.Gauge, bool, error) {
+func (m *MockStorage) FindGauge(ctx context.Context, name string, labels models.Labels) (*models.Gauge, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindGauge", ctx, name)
+	ret := m.ctrl.Call(m, "FindGauge", ctx, name, labels)
 	ret0, _ := ret[0].(*models.Gauge)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(error)
@@ -302,9 +510,25 @@ func (m *MockStorage) FindGauge(ctx context.Context, name string) (*models.Gauge
 }
 
 // FindGauge indicates an expected call of FindGauge.
-func (mr *MockStorageMockRecorder) FindGauge(ctx, name any) *gomock.Call {
+func (mr *MockStorageMockRecorder) FindGauge(ctx, name, labels any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindGauge", reflect.TypeOf((*MockStorage)(nil).FindGauge), ctx, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindGauge", reflect.TypeOf((*MockStorage)(nil).FindGauge), ctx, name, labels)
+}
+
+// FindHistogram mocks base method.
+func (m *MockStorage) FindHistogram(ctx context.Context, name string, labels models.Labels) (*models.Histogram, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindHistogram", ctx, name, labels)
+	ret0, _ := ret[0].(*models.Histogram)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindHistogram indicates an expected call of FindHistogram.
+func (mr *MockStorageMockRecorder) FindHistogram(ctx, name, labels any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindHistogram", reflect.TypeOf((*MockStorage)(nil).FindHistogram), ctx, name, labels)
 }
 
 // ListCounters mocks base method.
@@ -337,6 +561,21 @@ func (mr *MockStorageMockRecorder) ListGauges(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGauges", reflect.TypeOf((*MockStorage)(nil).ListGauges), ctx)
 }
 
+// ListHistograms mocks base method.
+func (m *MockStorage) ListHistograms(ctx context.Context) (models.HistogramsList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHistograms", ctx)
+	ret0, _ := ret[0].(models.HistogramsList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHistograms indicates an expected call of ListHistograms.
+func (mr *MockStorageMockRecorder) ListHistograms(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHistograms", reflect.TypeOf((*MockStorage)(nil).ListHistograms), ctx)
+}
+
 // ReplaceCounters mocks base method.
 func (m *MockStorage) ReplaceCounters(ctx context.Context, val models.CountersList) error {
 	m.ctrl.T.Helper()
@@ -365,6 +604,20 @@ func (mr *MockStorageMockRecorder) ReplaceGauges(ctx, val any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceGauges", reflect.TypeOf((*MockStorage)(nil).ReplaceGauges), ctx, val)
 }
 
+// ReplaceHistograms mocks base method.
+func (m *MockStorage) ReplaceHistograms(ctx context.Context, val models.HistogramsList) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceHistograms", ctx, val)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceHistograms indicates an expected call of ReplaceHistograms.
+func (mr *MockStorageMockRecorder) ReplaceHistograms(ctx, val any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceHistograms", reflect.TypeOf((*MockStorage)(nil).ReplaceHistograms), ctx, val)
+}
+
 // SetGauge mocks base method.
 func (m *MockStorage) SetGauge(ctx context.Context, val models.Gauge) error {
 	m.ctrl.T.Helper()
@@ -422,3 +675,33 @@ func (mr *MockStorageMockRecorder) UpdateCounters(ctx, vals any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCounters", reflect.TypeOf((*MockStorage)(nil).UpdateCounters), ctx, vals)
 }
+
+// UpdateHistogram mocks base method.
+func (m *MockStorage) UpdateHistogram(ctx context.Context, val models.Histogram) (*models.Histogram, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHistogram", ctx, val)
+	ret0, _ := ret[0].(*models.Histogram)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateHistogram indicates an expected call of UpdateHistogram.
+func (mr *MockStorageMockRecorder) UpdateHistogram(ctx, val any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHistogram", reflect.TypeOf((*MockStorage)(nil).UpdateHistogram), ctx, val)
+}
+
+// UpdateHistograms mocks base method.
+func (m *MockStorage) UpdateHistograms(ctx context.Context, vals models.HistogramsList) (models.HistogramsList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHistograms", ctx, vals)
+	ret0, _ := ret[0].(models.HistogramsList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateHistograms indicates an expected call of UpdateHistograms.
+func (mr *MockStorageMockRecorder) UpdateHistograms(ctx, vals any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHistograms", reflect.TypeOf((*MockStorage)(nil).UpdateHistograms), ctx, vals)
+}
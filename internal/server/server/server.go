@@ -3,11 +3,15 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+
+	"go.uber.org/zap"
 )
 
 type Server struct {
 	srv *http.Server
+	tls bool
 }
 
 func New(addr string, handler http.Handler) *Server {
@@ -19,11 +23,37 @@ func New(addr string, handler http.Handler) *Server {
 	}
 }
 
+// https server, certificates are reloaded when their files
+// are changed, with client CA clients certificates are required
+func NewTLS(addr string, handler http.Handler, cfg TLSConfig, log *zap.Logger) (*Server, error) {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	reloader, err := newCertReloader(cfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("tls config: %v", err)
+	}
+	return &Server{
+		srv: &http.Server{
+			Addr:      addr,
+			Handler:   handler,
+			TLSConfig: reloader.ServerConfig(),
+		},
+		tls: true,
+	}, nil
+}
+
 func (s *Server) Start() <-chan error {
 	errCh := make(chan error, 1)
 
 	go func() {
-		err := s.srv.ListenAndServe()
+		var err error
+		if s.tls {
+			// certificates are set by tls config
+			err = s.srv.ListenAndServeTLS("", "")
+		} else {
+			err = s.srv.ListenAndServe()
+		}
 		if !errors.Is(err, http.ErrServerClosed) {
 			errCh <- err
 		}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// files are checked for changes not more often than this,
+// so rotated certificates are used without restart
+const certsCheckInterval = 10 * time.Second
+
+type TLSConfig struct {
+	CertPath string
+	KeyPath  string
+	// CA of clients certificates, if passed
+	// clients must have certificates signed by it
+	ClientCAPath string
+}
+
+// tls config for every connection, reloaded when files are changed
+type certReloader struct {
+	cfg TLSConfig
+	log *zap.Logger
+
+	mu       sync.Mutex
+	current  *tls.Config
+	modTimes []time.Time
+	checked  time.Time
+}
+
+func newCertReloader(cfg TLSConfig, log *zap.Logger) (*certReloader, error) {
+	r := &certReloader{cfg: cfg, log: log}
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.reload(modTimes); err != nil {
+		return nil, err
+	}
+	r.checked = time.Now()
+	return r, nil
+}
+
+func (r *certReloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.config(time.Now()), nil
+		},
+	}
+}
+
+// on reloading error previous config is kept
+func (r *certReloader) config(now time.Time) *tls.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.checked) < certsCheckInterval {
+		return r.current
+	}
+	r.checked = now
+
+	modTimes, err := r.statFiles()
+	if err != nil {
+		r.log.Error("tls files checking", zap.Error(err))
+		return r.current
+	}
+	if !r.changed(modTimes) {
+		return r.current
+	}
+	if err := r.reload(modTimes); err != nil {
+		r.log.Error("tls files reloading", zap.Error(err))
+		return r.current
+	}
+	r.log.Info("tls certificates reloaded")
+	return r.current
+}
+
+func (r *certReloader) reload(modTimes []time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertPath, r.cfg.KeyPath)
+	if err != nil {
+		return fmt.Errorf("certificate loading: %v", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if r.cfg.ClientCAPath != "" {
+		pool, err := LoadCertPool(r.cfg.ClientCAPath)
+		if err != nil {
+			return err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	r.current = config
+	r.modTimes = modTimes
+	return nil
+}
+
+func (r *certReloader) paths() []string {
+	paths := []string{r.cfg.CertPath, r.cfg.KeyPath}
+	if r.cfg.ClientCAPath != "" {
+		paths = append(paths, r.cfg.ClientCAPath)
+	}
+	return paths
+}
+
+func (r *certReloader) statFiles() ([]time.Time, error) {
+	paths := r.paths()
+	modTimes := make([]time.Time, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+func (r *certReloader) changed(modTimes []time.Time) bool {
+	for i := range modTimes {
+		if !modTimes[i].Equal(r.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// PEM encoded certificates
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ca file reading: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: no certificates found", path)
+	}
+	return pool, nil
+}
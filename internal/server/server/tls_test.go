@@ -0,0 +1,136 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// self signed if parent is nil
+func newTestCert(t *testing.T, cn string, serial int64, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, certPath, keyPath string) {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	require.NoError(t, os.WriteFile(certPath, certPEM, 0o600))
+	if keyPath == "" {
+		return
+	}
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0o600))
+}
+
+func (c *testCert) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	cfg := TLSConfig{
+		CertPath:     filepath.Join(dir, "server.pem"),
+		KeyPath:      filepath.Join(dir, "server-key.pem"),
+		ClientCAPath: filepath.Join(dir, "ca.pem"),
+	}
+	ca := newTestCert(t, "ca", 1, nil)
+	ca.write(t, cfg.ClientCAPath, "")
+	newTestCert(t, "server", 2, ca).write(t, cfg.CertPath, cfg.KeyPath)
+	agent := newTestCert(t, "agent-1", 3, ca)
+
+	reloader, err := newCertReloader(cfg, zap.NewNop())
+	require.NoError(t, err)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", reloader.ServerConfig())
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+	})}
+	go func() { _ = srv.Serve(ln) }()
+	defer func() { _ = srv.Close() }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(certs ...tls.Certificate) (*http.Response, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+		return client.Get("https://" + ln.Addr().String())
+	}
+
+	t.Run("client with certificate", func(t *testing.T) {
+		res, err := get(agent.tlsCert())
+		require.NoError(t, err)
+		defer func() { require.NoError(t, res.Body.Close()) }()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "agent-1", string(body))
+		assert.Equal(t, int64(2), res.TLS.PeerCertificates[0].SerialNumber.Int64())
+	})
+
+	t.Run("client without certificate", func(t *testing.T) {
+		res, err := get()
+		if err == nil {
+			_ = res.Body.Close()
+		}
+		assert.Error(t, err)
+	})
+
+	t.Run("rotated certificate", func(t *testing.T) {
+		newTestCert(t, "server", 4, ca).write(t, cfg.CertPath, cfg.KeyPath)
+		// files mod time may be the same on coarse clock
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(cfg.CertPath, later, later))
+		reloader.mu.Lock()
+		reloader.checked = time.Time{}
+		reloader.mu.Unlock()
+
+		res, err := get(agent.tlsCert())
+		require.NoError(t, err)
+		defer func() { require.NoError(t, res.Body.Close()) }()
+		assert.Equal(t, int64(4), res.TLS.PeerCertificates[0].SerialNumber.Int64())
+	})
+}
@@ -0,0 +1,109 @@
+package signkeys
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// keys file content, like
+//
+//	{
+//	  "keys": [
+//	    {"id": "agent-1", "secret": "first secret"},
+//	    {"id": "agent-2", "secret": "second secret", "revoked": true}
+//	  ]
+//	}
+type Config struct {
+	Keys []Key `json:"keys"`
+}
+
+type Key struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+	// revoked key is rejected, like removed one
+	Revoked bool `json:"revoked,omitempty"`
+}
+
+// secrets of agents signing keys by key id,
+// may be reloaded while server is running
+type Registry struct {
+	secrets atomic.Pointer[map[string]string]
+}
+
+func Load(path string) (*Registry, error) {
+	r := &Registry{}
+	if err := r.Reload(path); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// on error registry keeps previous keys
+func (r *Registry) Reload(path string) error {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	secrets := make(map[string]string, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		if !key.Revoked {
+			secrets[key.ID] = key.Secret
+		}
+	}
+	r.secrets.Store(&secrets)
+	return nil
+}
+
+// take keys of other registry, so handlers
+// using this one get them
+func (r *Registry) Replace(other *Registry) {
+	r.secrets.Store(other.secrets.Load())
+}
+
+// secret of not revoked key
+func (r *Registry) Secret(id string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	secrets := r.secrets.Load()
+	if secrets == nil {
+		return "", false
+	}
+	secret, ok := (*secrets)[id]
+	return secret, ok
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read keys file: %v", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse keys file: %v", err)
+	}
+	if err := Validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid keys file: %v", err)
+	}
+	return &cfg, nil
+}
+
+func Validate(c Config) error {
+	var errs []error
+	ids := make(map[string]struct{}, len(c.Keys))
+	for i, key := range c.Keys {
+		if key.ID == "" {
+			errs = append(errs, fmt.Errorf("keys[%d]: empty id", i))
+		} else if _, exists := ids[key.ID]; exists {
+			errs = append(errs, fmt.Errorf("keys[%d]: duplicated id %q", i, key.ID))
+		}
+		ids[key.ID] = struct{}{}
+		if key.Secret == "" && !key.Revoked {
+			errs = append(errs, fmt.Errorf("keys[%d]: empty secret", i))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package signkeys
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKeys(t *testing.T, path, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeys(t, path, `{"keys": [
+		{"id": "agent-1", "secret": "one"},
+		{"id": "agent-2", "secret": "two"}
+	]}`)
+
+	registry, err := Load(path)
+	require.NoError(t, err)
+	secret, ok := registry.Secret("agent-1")
+	require.True(t, ok)
+	assert.Equal(t, "one", secret)
+	_, ok = registry.Secret("agent-3")
+	assert.False(t, ok)
+
+	t.Run("revoke key", func(t *testing.T) {
+		writeKeys(t, path, `{"keys": [
+			{"id": "agent-1", "secret": "one"},
+			{"id": "agent-2", "secret": "two", "revoked": true}
+		]}`)
+		require.NoError(t, registry.Reload(path))
+		_, ok := registry.Secret("agent-1")
+		assert.True(t, ok)
+		_, ok = registry.Secret("agent-2")
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid file keeps keys", func(t *testing.T) {
+		writeKeys(t, path, `{"keys": [
+			{"id": "agent-1", "secret": "one"},
+			{"id": "agent-1", "secret": ""}
+		]}`)
+		err := registry.Reload(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "keys[1]: duplicated id")
+		assert.Contains(t, err.Error(), "keys[1]: empty secret")
+		_, ok := registry.Secret("agent-1")
+		assert.True(t, ok)
+	})
+}
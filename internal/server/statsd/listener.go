@@ -0,0 +1,208 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// max udp payload
+	maxPacketSize = 65535
+	// timeout of service update on flush
+	flushTimeout = 5 * time.Second
+)
+
+type Service interface {
+	UpdateMetrics(ctx context.Context, vals models.Metrics) (models.Metrics, error)
+}
+
+type Config struct {
+	// udp address, like :8125
+	Endpoint string
+	// interval of sending collected metrics to service
+	FlushInterval time.Duration
+	// collected metrics are sent immediately
+	// if batch reaches this size
+	BatchSize int
+}
+
+// udp listener of statsd protocol, collects metrics into
+// batches and sends them to service.
+// invalid lines are counted and skipped.
+type Listener struct {
+	conn    *net.UDPConn
+	service Service
+	cfg     Config
+	log     *zap.Logger
+
+	mu    sync.Mutex
+	batch models.Metrics
+
+	parseErrors atomic.Uint64
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(cfg Config, s Service, log *zap.Logger) (*Listener, error) {
+	if s == nil {
+		return nil, fmt.Errorf("service not exists")
+	}
+	if cfg.FlushInterval <= 0 {
+		return nil, fmt.Errorf("invalid flush interval %v", cfg.FlushInterval)
+	}
+	if cfg.BatchSize <= 0 {
+		return nil, fmt.Errorf("invalid batch size %d", cfg.BatchSize)
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("resolve statsd endpoint: %v", err)
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen statsd endpoint: %v", err)
+	}
+
+	return &Listener{
+		conn:    conn,
+		service: s,
+		cfg:     cfg,
+		log:     log,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// actual listening address, useful for ":0" endpoint
+func (l *Listener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// count of skipped invalid lines
+func (l *Listener) ParseErrors() uint64 {
+	return l.parseErrors.Load()
+}
+
+func (l *Listener) Start() <-chan error {
+	errCh := make(chan error, 1)
+
+	l.wg.Add(2)
+	go func() {
+		defer l.wg.Done()
+		defer close(errCh)
+		if err := l.listen(); err != nil {
+			errCh <- err
+		}
+	}()
+	go func() {
+		defer l.wg.Done()
+		l.flushLoop()
+	}()
+
+	return errCh
+}
+
+// stop listening and send remaining metrics
+func (l *Listener) Shutdown(ctx context.Context) error {
+	close(l.done)
+	err := l.conn.Close()
+
+	stopped := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	l.flush()
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+func (l *Listener) listen() error {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := l.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("statsd read: %v", err)
+		}
+
+		// invalid lines are skipped, valid ones of the same packet are kept
+		metrics, errs := ParsePacket(string(buf[:n]))
+		l.parseErrors.Add(uint64(len(errs)))
+		for _, err := range errs {
+			l.log.Warn("statsd line skipped", zap.Error(err))
+		}
+		if len(metrics) > 0 && l.add(metrics) {
+			l.flush()
+		}
+	}
+}
+
+func (l *Listener) flushLoop() {
+	ticker := time.NewTicker(l.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			l.flush()
+		}
+	}
+}
+
+// add metrics to batch, returns true if batch is full
+func (l *Listener) add(metrics models.Metrics) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.batch = append(l.batch, metrics...)
+	return len(l.batch) >= l.cfg.BatchSize
+}
+
+func (l *Listener) flush() {
+	l.mu.Lock()
+	batch := l.batch
+	l.batch = nil
+	l.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	_, err := l.service.UpdateMetrics(ctx, batch)
+	if err == nil {
+		return
+	}
+	// batch is applied all or nothing, so metrics are sent
+	// one by one to lose only ones which are rejected
+	l.log.Warn("statsd batch update", zap.Int("count", len(batch)), zap.Error(err))
+	for _, m := range batch {
+		if _, err := l.service.UpdateMetrics(ctx, models.Metrics{m}); err != nil {
+			// metric is lost, but it's udp, we are used to it
+			l.log.Error("statsd metric update", zap.String("name", m.ID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+	"github.com/stepkareserva/obsermon/internal/server/mocks"
+)
+
+func TestListener(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	cfg := Config{
+		Endpoint:      "127.0.0.1:0",
+		FlushInterval: time.Hour,
+		BatchSize:     2,
+	}
+	listener, err := New(cfg, mockService, nil)
+	require.NoError(t, err)
+	listener.Start()
+
+	updated := make(chan models.Metrics, 1)
+	mockService.
+		EXPECT().
+		UpdateMetrics(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, vals models.Metrics) (models.Metrics, error) {
+			updated <- vals
+			return vals, nil
+		})
+
+	conn, err := net.Dial("udp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// batch is full after second valid metric
+	_, err = conn.Write([]byte("requests:1|c\ninvalid"))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("load:2.5|g"))
+	require.NoError(t, err)
+
+	select {
+	case vals := <-updated:
+		assert.Equal(t, models.Metrics{
+			models.CounterMetric(models.Counter{Name: "requests", Value: 1}),
+			models.GaugeMetric(models.Gauge{Name: "load", Value: 2.5}),
+		}, vals)
+	case <-time.After(5 * time.Second):
+		t.Fatal("metrics are not flushed")
+	}
+	assert.Equal(t, uint64(1), listener.ParseErrors())
+
+	require.NoError(t, listener.Shutdown(context.Background()))
+}
+
+func TestListenerSkipsRejectedMetric(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	cfg := Config{
+		Endpoint:      "127.0.0.1:0",
+		FlushInterval: time.Hour,
+		BatchSize:     2,
+	}
+	listener, err := New(cfg, mockService, nil)
+	require.NoError(t, err)
+	listener.Start()
+
+	updated := make(chan models.Metrics, 2)
+	gomock.InOrder(
+		mockService.
+			EXPECT().
+			UpdateMetrics(gomock.Any(), gomock.Len(2)).
+			Return(nil, errors.New("counter overflow")),
+		mockService.
+			EXPECT().
+			UpdateMetrics(gomock.Any(), gomock.Len(1)).
+			DoAndReturn(func(_ context.Context, vals models.Metrics) (models.Metrics, error) {
+				updated <- vals
+				return vals, nil
+			}),
+		mockService.
+			EXPECT().
+			UpdateMetrics(gomock.Any(), gomock.Len(1)).
+			DoAndReturn(func(_ context.Context, vals models.Metrics) (models.Metrics, error) {
+				updated <- vals
+				return nil, errors.New("counter overflow")
+			}),
+	)
+
+	conn, err := net.Dial("udp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("requests:1|c\noverflowed:1|c"))
+	require.NoError(t, err)
+
+	for _, name := range []string{"requests", "overflowed"} {
+		select {
+		case vals := <-updated:
+			assert.Equal(t, name, vals[0].ID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("metrics are not updated one by one")
+		}
+	}
+	require.NoError(t, listener.Shutdown(context.Background()))
+}
@@ -0,0 +1,137 @@
+package statsd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+// timers are stored as histograms with these bounds, ms
+var TimerBounds = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// parse statsd line like name:value|type[|@rate][|#tag:v,tag2:v2].
+// supported types are c (counter), g (gauge), ms and h (timer,
+// stored as histogram). relative gauges (+1, -1) are not supported,
+// because storage has no gauge increments.
+func ParseLine(line string) (*models.Metric, error) {
+	name, rest, found := strings.Cut(line, ":")
+	if !found || name == "" {
+		return nil, fmt.Errorf("invalid statsd line %q: no metric name", line)
+	}
+
+	fields := strings.Split(rest, "|")
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid statsd line %q: no metric type", line)
+	}
+	value, mtype := fields[0], fields[1]
+
+	rate := 1.0
+	var labels models.Labels
+	for _, field := range fields[2:] {
+		switch {
+		case strings.HasPrefix(field, "@"):
+			r, err := strconv.ParseFloat(field[1:], 64)
+			if err != nil || r <= 0 || r > 1 {
+				return nil, fmt.Errorf("invalid statsd line %q: invalid sample rate", line)
+			}
+			rate = r
+		case strings.HasPrefix(field, "#"):
+			labels = parseTags(field[1:])
+		default:
+			return nil, fmt.Errorf("invalid statsd line %q: unknown field %q", line, field)
+		}
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, fmt.Errorf("invalid statsd line %q: invalid value", line)
+	}
+
+	switch mtype {
+	case "c":
+		// float64(math.MaxInt64) is 2^63, which is out of range
+		delta := math.Round(v / rate)
+		if delta < math.MinInt64 || delta >= math.MaxInt64 {
+			return nil, fmt.Errorf("invalid statsd line %q: counter value out of range", line)
+		}
+		m := models.CounterMetric(models.Counter{
+			Name:   name,
+			Value:  models.CounterValue(delta),
+			Labels: labels,
+		})
+		return &m, nil
+	case "g":
+		if value[0] == '+' || value[0] == '-' {
+			return nil, fmt.Errorf("invalid statsd line %q: relative gauges are not supported", line)
+		}
+		m := models.GaugeMetric(models.Gauge{
+			Name:   name,
+			Value:  models.GaugeValue(v),
+			Labels: labels,
+		})
+		return &m, nil
+	case "ms", "h":
+		m := models.HistogramMetric(models.Histogram{
+			Name:   name,
+			Value:  timerHistogram(v),
+			Labels: labels,
+		})
+		return &m, nil
+	default:
+		return nil, fmt.Errorf("invalid statsd line %q: unsupported type %q", line, mtype)
+	}
+}
+
+// parse packet with newline-separated lines, collect valid metrics
+// and errors of invalid lines, empty lines are ignored
+func ParsePacket(packet string) (models.Metrics, []error) {
+	var metrics models.Metrics
+	var errs []error
+	for _, line := range strings.Split(packet, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		m, err := ParseLine(line)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		metrics = append(metrics, *m)
+	}
+	return metrics, errs
+}
+
+// dogstatsd tags, "tag:value" or just "tag" with empty value
+func parseTags(s string) models.Labels {
+	labels := make(models.Labels)
+	for _, tag := range strings.Split(s, ",") {
+		if tag == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(tag, ":")
+		labels[k] = v
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+func timerHistogram(v float64) models.HistogramValue {
+	counts := make([]uint64, len(TimerBounds)+1)
+	i := 0
+	for i < len(TimerBounds) && v > TimerBounds[i] {
+		i++
+	}
+	counts[i] = 1
+	return models.HistogramValue{
+		Bounds: TimerBounds,
+		Counts: counts,
+		Sum:    v,
+		Count:  1,
+	}
+}
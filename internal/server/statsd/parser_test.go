@@ -0,0 +1,79 @@
+package statsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stepkareserva/obsermon/internal/models"
+)
+
+func TestParseLine(t *testing.T) {
+	testCases := []struct {
+		line     string
+		expected models.Metric
+	}{
+		{
+			line:     "requests:1|c",
+			expected: models.CounterMetric(models.Counter{Name: "requests", Value: 1}),
+		},
+		{
+			line:     "requests:2|c|@0.1",
+			expected: models.CounterMetric(models.Counter{Name: "requests", Value: 20}),
+		},
+		{
+			line:     "load:3.2|g|#host:a,env:prod",
+			expected: models.GaugeMetric(models.Gauge{Name: "load", Value: 3.2, Labels: models.Labels{"host": "a", "env": "prod"}}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.line, func(t *testing.T) {
+			m, err := ParseLine(tc.line)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, *m)
+		})
+	}
+
+	t.Run("timer", func(t *testing.T) {
+		m, err := ParseLine("latency:120|ms")
+		require.NoError(t, err)
+		h, err := m.AsHistogram()
+		require.NoError(t, err)
+		assert.Equal(t, "latency", h.Name)
+		assert.Equal(t, uint64(1), h.Value.Count)
+		assert.Equal(t, 120.0, h.Value.Sum)
+		// 100 < 120 <= 250
+		assert.Equal(t, uint64(1), h.Value.Counts[6])
+	})
+}
+
+func TestParseInvalidLine(t *testing.T) {
+	invalidLines := []string{
+		"requests",
+		":1|c",
+		"requests:1",
+		"requests:abc|c",
+		"requests:1|x",
+		"requests:1|c|@2",
+		"requests:1|c|unknown",
+		"load:+1|g",
+		"load:NaN|g",
+		"requests:1e19|c",
+		"requests:-1e19|c",
+		"requests:1e18|c|@0.01",
+	}
+	for _, line := range invalidLines {
+		t.Run(line, func(t *testing.T) {
+			_, err := ParseLine(line)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParsePacket(t *testing.T) {
+	metrics, errs := ParsePacket("a:1|c\ninvalid\n\nb:2|g\n")
+	assert.Len(t, metrics, 2)
+	assert.Len(t, errs, 1)
+}